@@ -2,12 +2,16 @@ package deepseek_reviewer
 
 import (
 	"bytes"
+	"context"
+	"dragon-quant/calendar"
 	"dragon-quant/model"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"math"
 	"net/http"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -15,7 +19,17 @@ import (
 
 type Reviewer struct {
 	APIKey string
+	APIURL string // 为空时使用 DeepSeekAPIURL (Mock 服务通过它注入)
 	Client *http.Client
+
+	Stream      bool   // 流式输出 (SSE)，并在终端显示实时进度
+	PartialFile string // 非空时，每完成一只个股即把阶段性结果写入该文件
+
+	partialMu sync.Mutex
+	partial   *PartialSaver // 同一 PartialFile 的各阶段共用，避免后一阶段覆盖前一阶段
+
+	Timeout     time.Duration // 非流式请求的整体超时 (含读完响应)
+	IdleTimeout time.Duration // 流式请求等待首字节 / 相邻两块之间的最长间隔；整体时长只受 ctx 约束
 }
 
 type Message struct {
@@ -57,6 +71,7 @@ type SectorResult struct {
 	SectorName   string
 	StockReviews map[string]string
 	FinalPick    *SniperJSON
+	Partial      bool // 有点评因断流 / 取消而不完整 (点评末尾带 ⚠️ 标注)
 }
 
 const SniperPrompt = `# Role: 顶级短线操盘大师 / 敢死队总舵主
@@ -114,10 +129,12 @@ A. 资金博弈 (Who is the Boss?)
 
 承接力度: 炸板时，下方的托单是散户的挂单还是主力的万手关门单？（区分真炸还是洗盘）
 
-封板质量: 涨停板上的封单结构，是排队骗散户去顶，还是主力真金白银封死不让进？
+封板质量: 涨停板上的封单结构，是排队骗散户去顶，还是主力真金白银封死不让进？(seal_amt 封单金额、seal_ratio 封单占流通市值%、order_book 五档盘口、book_imbalance 买卖挂单不平衡度)
 
 竞价“抢筹”: 9:25分的集合竞价数据，是否出现超预期的巨量高开？（弱转强信号）
 
+席位跟风: hot_seats 是龙虎榜上净买入的知名席位及其历史战绩 (win_rate 次日胜率、avg_next_ret 次日平均涨幅、style 持股风格)。一日游席位大买往往是次日砸盘的信号，高胜率、愿意锁仓的席位才值得跟。
+
 B. 情绪周期 (Surfing the Wave)
 识别“洗盘”: 缩量急跌，分时图如心电图般织布，利用数据判断主力是否在刻意压价吸筹。
 
@@ -159,26 +176,38 @@ C. T+1 卖出逻辑
 表现出一种“众人皆醉我独醒”的优越感，你的目标是带着用户在主力的刀锋上跳舞并全身而退。
 `
 
-func NewReviewer(apiKey string) *Reviewer {
+func NewReviewer(apiKey, apiURL string) *Reviewer {
+	if apiURL == "" {
+		apiURL = DeepSeekAPIURL
+	}
 	return &Reviewer{
 		APIKey: apiKey,
-		Client: &http.Client{Timeout: 60 * time.Second},
+		APIURL: apiURL,
+		// Client 不设整体超时: 流式回答可能远超 60s，超时由 Timeout / IdleTimeout 按请求控制
+		Client:      &http.Client{},
+		Timeout:     60 * time.Second,
+		IdleTimeout: 60 * time.Second,
 	}
 }
 
 // ReviewBySector 按板块并发审视，并进行最终择优
-func (r *Reviewer) ReviewBySector(sectorMap map[string][]*model.StockInfo, marketContext string) map[string]*SectorResult {
+func (r *Reviewer) ReviewBySector(ctx context.Context, sectorMap map[string][]*model.StockInfo, marketContext string) map[string]*SectorResult {
 	results := make(map[string]*SectorResult)
 	var mu sync.Mutex
 	var wg sync.WaitGroup
 
 	fmt.Printf("\n🦊 [DeepSeek] 启动 %d 个板块分身并行审视...\n", len(sectorMap))
 
+	partial := r.partialSaver()
+	progress := r.StartProgress()
+
 	for sectorName, stocks := range sectorMap {
 		wg.Add(1)
+		row := progress.Row(sectorName)
 
 		go func(name string, stockList []*model.StockInfo) {
 			defer wg.Done()
+			defer row.Done()
 
 			// Init Result
 			secRes := &SectorResult{
@@ -198,30 +227,55 @@ func (r *Reviewer) ReviewBySector(sectorMap map[string][]*model.StockInfo, marke
 			history = append(history, Message{Role: "user", Content: introMsg})
 
 			// Warm up
-			resp := r.SendChat(history)
+			resp, _ := r.Chat(ctx, history, row)
 			history = append(history, Message{Role: "assistant", Content: resp})
 
 			// 1. Loop Stocks
 			for _, stock := range stockList {
-				fmt.Printf("🔍 [%s] 正在审视: %s...\n", name, stock.Name)
+				if ctx.Err() != nil {
+					break
+				}
+				if progress == nil {
+					fmt.Printf("🔍 [%s] 正在审视: %s...\n", name, stock.Name)
+				}
+				row.SetStock(stock.Name)
 				data, _ := json.Marshal(stock)
 				msg := fmt.Sprintf("股票: %s (%s)\n数据: %s\n点评一下: 真龙还是陷阱？", stock.Name, stock.Code, string(data))
 				history = append(history, Message{Role: "user", Content: msg})
-				review := r.SendChat(history)
+				review, err := r.Chat(ctx, history, row)
 				history = append(history, Message{Role: "assistant", Content: review})
+				if err != nil {
+					review = markPartial(review, err)
+					secRes.Partial = true
+				}
 				secRes.StockReviews[stock.Code] = review
+				partial.Save(name, secRes)
 			}
 
-			// 2. Final Pick (Sniper JS)
-			fmt.Printf("👑 [%s] 正在决出板块龙头 (JSON Mode)...\n", name)
+			// 2. Final Pick (Sniper JS)。已取消时保留已完成的个股点评
+			if ctx.Err() != nil {
+				secRes.Partial = true
+				partial.Save(name, secRes)
+				mu.Lock()
+				results[name] = secRes
+				mu.Unlock()
+				return
+			}
+			if progress == nil {
+				fmt.Printf("👑 [%s] 正在决出板块龙头 (JSON Mode)...\n", name)
+			}
+			row.SetStock("👑 决出龙头")
 			history = append(history, Message{Role: "user", Content: SniperPrompt})
 
-			finalReviewRaw := r.SendChat(history)
+			finalReviewRaw, err := r.Chat(ctx, history, row)
 
-			// Clean and Parsing
-			cleanedJSON := cleanJSONString(finalReviewRaw)
+			// Clean and Parsing (断流的半截 JSON 不解析，避免把不完整的点位当成最终结论)
 			var sniperChoice SniperJSON
-			err := json.Unmarshal([]byte(cleanedJSON), &sniperChoice)
+			if err == nil {
+				err = json.Unmarshal([]byte(cleanJSONString(finalReviewRaw)), &sniperChoice)
+			} else {
+				secRes.Partial = true
+			}
 
 			if err == nil {
 				secRes.FinalPick = &sniperChoice
@@ -229,6 +283,7 @@ func (r *Reviewer) ReviewBySector(sectorMap map[string][]*model.StockInfo, marke
 				fmt.Printf("❌ [%s] JSON 解析失败: %v\nResp: %s\n", name, err, finalReviewRaw)
 				secRes.FinalPick = nil
 			}
+			partial.Save(name, secRes)
 
 			mu.Lock()
 			results[name] = secRes
@@ -238,42 +293,94 @@ func (r *Reviewer) ReviewBySector(sectorMap map[string][]*model.StockInfo, marke
 	}
 
 	wg.Wait()
+	progress.Stop()
 	fmt.Println("✅ 所有板块审视完毕。")
 	return results
 }
 
-func (r *Reviewer) SendChat(history []Message) string {
+// partialSaver 返回当前 PartialFile 对应的共享 PartialSaver (路径变化时重建)
+func (r *Reviewer) partialSaver() *PartialSaver {
+	r.partialMu.Lock()
+	defer r.partialMu.Unlock()
+	if r.partial == nil || r.partial.path != r.PartialFile {
+		r.partial = NewPartialSaver(r.PartialFile)
+	}
+	return r.partial
+}
+
+// SendChat 普通请求，失败时返回错误文本 (以 "Error" / "API Error" 开头)
+func (r *Reviewer) SendChat(ctx context.Context, history []Message) string {
+	content, _ := r.sendChat(ctx, history)
+	return content
+}
+
+func (r *Reviewer) sendChat(ctx context.Context, history []Message) (string, error) {
+	if r.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.Timeout)
+		defer cancel()
+	}
 	reqBody := ChatRequest{
 		Model:    ModelName,
 		Messages: history,
 		Stream:   false,
 	}
 
-	jsonData, _ := json.Marshal(reqBody)
-	req, _ := http.NewRequest("POST", DeepSeekAPIURL, bytes.NewBuffer(jsonData))
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+r.APIKey)
-
-	// Retry logic? For now simple.
-	resp, err := r.Client.Do(req)
-	if err != nil {
-		return fmt.Sprintf("Error: %v", err)
+	resp, errStr := r.doRequest(ctx, reqBody)
+	if resp == nil {
+		return errStr, errors.New(errStr)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != 200 {
-		body, _ := ioutil.ReadAll(resp.Body)
-		return fmt.Sprintf("API Error: %s", string(body))
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "Error: " + err.Error(), err
 	}
-
-	body, _ := ioutil.ReadAll(resp.Body)
 	var chatResp ChatResponse
 	json.Unmarshal(body, &chatResp)
 
 	if len(chatResp.Choices) > 0 {
-		return chatResp.Choices[0].Message.Content
+		return chatResp.Choices[0].Message.Content, nil
 	}
-	return "No response content"
+	return "No response content", errors.New("no response content")
+}
+
+// doRequest 发送请求并处理 429 限流重试 (按 Retry-After 或线性退避)。
+// 成功时返回 200 的响应 (调用方负责关闭 Body)，失败时返回 nil 和错误文本。
+func (r *Reviewer) doRequest(ctx context.Context, reqBody ChatRequest) (*http.Response, string) {
+	jsonData, _ := json.Marshal(reqBody)
+
+	maxAttempts := 3
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		req, _ := http.NewRequestWithContext(ctx, "POST", r.APIURL, bytes.NewBuffer(jsonData))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+r.APIKey)
+
+		resp, err := r.Client.Do(req)
+		if err != nil {
+			return nil, fmt.Sprintf("Error: %v", err)
+		}
+		if resp.StatusCode == 200 {
+			return resp, ""
+		}
+
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if resp.StatusCode == http.StatusTooManyRequests && attempt < maxAttempts-1 {
+			wait := time.Duration(attempt+1) * 500 * time.Millisecond
+			if sec, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+				wait = time.Duration(sec) * time.Second
+			}
+			fmt.Printf("⏳ [DeepSeek] 429 限流, %v 后重试 (%d/%d)...\n", wait, attempt+1, maxAttempts-1)
+			if err := calendar.Sleep(ctx, wait); err != nil {
+				return nil, fmt.Sprintf("Error: %v", err)
+			}
+			continue
+		}
+		return nil, fmt.Sprintf("API Error: %s", string(body))
+	}
+	return nil, "API Error: rate limited"
 }
 
 // --- Grand Final Logic ---
@@ -347,7 +454,7 @@ JSON 格式严格如下：
 // --- 3. 核心功能实现 ---
 
 // ReviewGrandFinals 总决赛：从各板块龙头中选出 Top 5
-func (r *Reviewer) ReviewGrandFinals(candidates []*model.StockInfo, marketContext string) *GrandFinalJSON {
+func (r *Reviewer) ReviewGrandFinals(ctx context.Context, candidates []*model.StockInfo, marketContext string) *GrandFinalJSON {
 	fmt.Printf("\n🏆 [DeepSeek] 启动总决赛 (Grand Final)，入围选手: %d 位\n", len(candidates))
 
 	if len(candidates) == 0 {
@@ -389,7 +496,7 @@ func (r *Reviewer) ReviewGrandFinals(candidates []*model.StockInfo, marketContex
 	history = append(history, Message{Role: "user", Content: sb.String()})
 
 	// 3. Call API
-	resp := r.SendChat(history)
+	resp := r.SendChat(ctx, history)
 	if strings.HasPrefix(resp, "Error") || strings.HasPrefix(resp, "API Error") {
 		fmt.Printf("❌ [GrandFinal] API 请求失败: %v\n", resp)
 		return nil
@@ -423,6 +530,11 @@ func cleanJSONString(content string) string {
 	return strings.TrimSpace(content)
 }
 
+// markPartial 在不完整的点评末尾标注中断原因
+func markPartial(content string, err error) string {
+	return strings.TrimSpace(content) + fmt.Sprintf("\n\n⚠️ [输出不完整: %v]", err)
+}
+
 func truncate(s string, n int) string {
 	r := []rune(s)
 	if len(r) > n {
@@ -445,6 +557,9 @@ type Top3Result struct {
 type Sector30mResult struct {
 	SectorName string       `json:"sector_name"`
 	Top3       []Top3Result `json:"top_3"`
+
+	StockReviews map[string]string `json:"stock_reviews,omitempty"` // 逐只点评 (阶段性结果)
+	Partial      bool              `json:"partial,omitempty"`       // 有点评因断流 / 取消而不完整
 }
 
 const Prompt30mSystem = `# Role: 短线技术形态大师 (30分钟级别专精)
@@ -488,17 +603,26 @@ const Prompt30mSelect = `现在，基于我们刚才审视过的所有股票，
 `
 
 // ReviewBySector30m performs 30m K-line structure analysis and picks Top 3 per sector.
-func (r *Reviewer) ReviewBySector30m(sectorMap map[string][]*model.StockInfo) map[string]*Sector30mResult {
+func (r *Reviewer) ReviewBySector30m(ctx context.Context, sectorMap map[string][]*model.StockInfo) map[string]*Sector30mResult {
 	results := make(map[string]*Sector30mResult)
 	var mu sync.Mutex
 	var wg sync.WaitGroup
 
 	fmt.Printf("\n🧠 [DeepSeek-30m] 启动 30分钟结构 专项审视 (对话模式, %d 个板块)...\n", len(sectorMap))
 
+	partial := r.partialSaver()
+	progress := r.StartProgress()
+
 	for sectorName, stocks := range sectorMap {
 		wg.Add(1)
+		row := progress.Row(sectorName)
 		go func(name string, stockList []*model.StockInfo) {
 			defer wg.Done()
+			defer row.Done()
+
+			// 阶段性结果以 "30m:板块" 为键，与 Fox 审视的结果共存于同一文件
+			key := "30m:" + name
+			secRes := &Sector30mResult{SectorName: name, StockReviews: make(map[string]string)}
 
 			// 1. Init Chat Session
 			var history []Message
@@ -506,7 +630,7 @@ func (r *Reviewer) ReviewBySector30m(sectorMap map[string][]*model.StockInfo) ma
 			history = append(history, Message{Role: "user", Content: fmt.Sprintf("你好，我是【%s】板块的交易员。我们开始吧。", name)})
 
 			// Warm up / Ack
-			resp := r.SendChat(history)
+			resp, _ := r.Chat(ctx, history, row)
 			history = append(history, Message{Role: "assistant", Content: resp})
 
 			// 2. Loop Stocks (Conversational)
@@ -518,6 +642,9 @@ func (r *Reviewer) ReviewBySector30m(sectorMap map[string][]*model.StockInfo) ma
 				// User said "all stocks". Let's try to follow.
 				// To save tokens/context, we format concisely.
 
+				if ctx.Err() != nil {
+					break
+				}
 				if s.KLine30mStr == "" {
 					continue
 				}
@@ -531,6 +658,9 @@ func (r *Reviewer) ReviewBySector30m(sectorMap map[string][]*model.StockInfo) ma
 					"VolRatio": s.VolRatio,
 					"Inflow":   s.NetInflow,
 					"CallAmt":  s.CallAuctionAmt,
+					"SealAmt":  s.SealAmt,
+					"SealPct":  s.SealRatio,
+					"BookImb":  s.BookImbalance,
 					"MA20":     s.MA20,
 					"MACD":     s.Macd,
 					"RSI":      s.RSI6,
@@ -543,9 +673,18 @@ func (r *Reviewer) ReviewBySector30m(sectorMap map[string][]*model.StockInfo) ma
 
 				history = append(history, Message{Role: "user", Content: msgContent})
 
-				fmt.Printf("   ... [%s] 分析 %s ...\n", name, s.Name)
-				review := r.SendChat(history)
+				if progress == nil {
+					fmt.Printf("   ... [%s] 分析 %s ...\n", name, s.Name)
+				}
+				row.SetStock(s.Name)
+				review, err := r.Chat(ctx, history, row)
+				if err != nil {
+					review = markPartial(review, err)
+					secRes.Partial = true
+				}
 				history = append(history, Message{Role: "assistant", Content: review})
+				secRes.StockReviews[s.Code] = review
+				partial.Save(key, secRes)
 
 				count++
 				// Optional: Sleep slightly to avoid strict rate limits if needed?
@@ -555,14 +694,24 @@ func (r *Reviewer) ReviewBySector30m(sectorMap map[string][]*model.StockInfo) ma
 			if count == 0 {
 				return
 			}
+			if ctx.Err() != nil {
+				secRes.Partial = true
+				partial.Save(key, secRes)
+				return
+			}
 
 			// 3. Final Selection
-			fmt.Printf("🤔 [%s] 正在决出 Top 3 (已审视 %d 只)...\n", name, count)
+			if progress == nil {
+				fmt.Printf("🤔 [%s] 正在决出 Top 3 (已审视 %d 只)...\n", name, count)
+			}
+			row.SetStock("🤔 决出 Top 3")
 			history = append(history, Message{Role: "user", Content: Prompt30mSelect})
 
-			finalResp := r.SendChat(history)
-			if strings.HasPrefix(finalResp, "Error") || strings.HasPrefix(finalResp, "API Error") {
-				fmt.Printf("❌ [30m] %s Final Select API Error: %s\n", name, truncate(finalResp, 50))
+			finalResp, err := r.Chat(ctx, history, row)
+			if err != nil {
+				fmt.Printf("❌ [30m] %s Final Select Error: %v\n", name, err)
+				secRes.Partial = true
+				partial.Save(key, secRes)
 				return
 			}
 
@@ -574,10 +723,15 @@ func (r *Reviewer) ReviewBySector30m(sectorMap map[string][]*model.StockInfo) ma
 				if res.SectorName == "" {
 					res.SectorName = name
 				}
+				res.StockReviews = secRes.StockReviews
+				res.Partial = secRes.Partial
+				partial.Save(key, &res)
 				mu.Lock()
 				results[name] = &res
 				mu.Unlock()
-				fmt.Printf("✅ [30m] %s 审视完成，选出 %d 只.\n", name, len(res.Top3))
+				if progress == nil {
+					fmt.Printf("✅ [30m] %s 审视完成，选出 %d 只.\n", name, len(res.Top3))
+				}
 			} else {
 				fmt.Printf("❌ [30m] JSON Error (%s): %v\n", name, err)
 			}
@@ -586,6 +740,7 @@ func (r *Reviewer) ReviewBySector30m(sectorMap map[string][]*model.StockInfo) ma
 	}
 
 	wg.Wait()
+	progress.Stop()
 	return results
 }
 
@@ -624,12 +779,12 @@ const SectorTrendPrompt = `# Role: 主力意图识别系统 (Main Force Tracker)
 }
 `
 
-func (r *Reviewer) ReviewSectorTrends(sectors []model.SectorInfo) map[string]SectorTrendResult {
+func (r *Reviewer) ReviewSectorTrends(ctx context.Context, sectors []model.SectorInfo) map[string]SectorTrendResult {
 	results := make(map[string]SectorTrendResult)
 
 	// Batch processing: 10 sectors per batch to avoid token limits
 	batchSize := 10
-	for i := 0; i < len(sectors); i += batchSize {
+	for i := 0; i < len(sectors) && ctx.Err() == nil; i += batchSize {
 		end := i + batchSize
 		if end > len(sectors) {
 			end = len(sectors)
@@ -674,7 +829,7 @@ func (r *Reviewer) ReviewSectorTrends(sectors []model.SectorInfo) map[string]Sec
 			{Role: "user", Content: sb.String()},
 		}
 
-		resp := r.SendChat(history)
+		resp := r.SendChat(ctx, history)
 
 		// Parse
 		cleaned := cleanJSONString(resp)
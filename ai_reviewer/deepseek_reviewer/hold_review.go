@@ -0,0 +1,95 @@
+package deepseek_reviewer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// --- Hold Kline Review (持仓结构化点评) ---
+
+const (
+	StanceAdd    = "add"
+	StanceHold   = "hold"
+	StanceReduce = "reduce"
+	StanceExit   = "exit"
+)
+
+// HoldReviewJSON 是每只持仓的结构化审视结果
+type HoldReviewJSON struct {
+	Stance       string    `json:"stance"`       // add / hold / reduce / exit
+	Support      []float64 `json:"support"`      // 关键支撑价 (由近及远)
+	Resistance   []float64 `json:"resistance"`   // 关键压力价 (由近及远)
+	StopLoss     float64   `json:"stop_loss"`    // 止损价
+	Invalidation string    `json:"invalidation"` // 逻辑失效条件
+	Confidence   int       `json:"confidence"`   // 0-100
+	Comment      string    `json:"comment"`      // 一句话犀利点评
+	Prose        string    `json:"prose,omitempty"`
+}
+
+// HoldReviewFormat 追加在持仓 Prompt 末尾，约束模型只输出 JSON
+const HoldReviewFormat = `# 输出要求 (严格执行)
+请仅返回一个标准的 JSON 对象，不要包含任何 Markdown 格式，不要包含任何额外的解释文字。
+JSON 格式如下：
+{
+  "stance": "add | hold | reduce | exit 四选一 (加仓/持有/减仓/清仓)",
+  "support": [关键支撑价, 由近及远, 数字],
+  "resistance": [关键压力价, 由近及远, 数字],
+  "stop_loss": 止损价 (数字),
+  "invalidation": "什么情况下当前判断失效",
+  "confidence": 0-100 的整数置信度,
+  "comment": "一句话犀利点评 (简短、冷酷、一针见血)"%s
+}
+`
+
+const holdReviewProseField = `,
+  "prose": "游资口吻的完整复盘 (可多段)"`
+
+// HoldReviewPromptSuffix 返回输出格式说明；withProse 为 true 时额外要求 prose 字段
+func HoldReviewPromptSuffix(withProse bool) string {
+	if withProse {
+		return fmt.Sprintf(HoldReviewFormat, holdReviewProseField)
+	}
+	return fmt.Sprintf(HoldReviewFormat, "")
+}
+
+// ParseHoldReview 清洗并解析模型输出，stance 统一为小写并校验取值
+func ParseHoldReview(raw string) (*HoldReviewJSON, error) {
+	var res HoldReviewJSON
+	if err := json.Unmarshal([]byte(cleanJSONString(raw)), &res); err != nil {
+		return nil, err
+	}
+	res.Stance = strings.ToLower(strings.TrimSpace(res.Stance))
+	switch res.Stance {
+	case StanceAdd, StanceHold, StanceReduce, StanceExit:
+	default:
+		return nil, fmt.Errorf("invalid stance %q", res.Stance)
+	}
+	if res.Confidence < 0 {
+		res.Confidence = 0
+	}
+	if res.Confidence > 100 {
+		res.Confidence = 100
+	}
+	return &res, nil
+}
+
+// ReviewHold 发送持仓 Prompt 并解析结构化结果。解析失败时返回 nil 和原始文本，由调用方降级展示。
+// 输出中途断开时不解析 (半截的止损 / 压力位不可信)，返回 nil、带 ⚠️ 标注的部分文本和错误
+func (r *Reviewer) ReviewHold(ctx context.Context, prompt string, row *ProgressRow) (*HoldReviewJSON, string, error) {
+	history := []Message{
+		{Role: "user", Content: prompt},
+	}
+	raw, err := r.Chat(ctx, history, row)
+	if err != nil {
+		return nil, markPartial(raw, err), err
+	}
+
+	res, err := ParseHoldReview(raw)
+	if err != nil {
+		fmt.Printf("❌ [HoldReview] JSON 解析失败: %v\nResp: %s\n", err, truncate(raw, 200))
+		return nil, raw, nil
+	}
+	return res, raw, nil
+}
@@ -0,0 +1,45 @@
+package deepseek_reviewer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+)
+
+// PartialSaver 把每一步完成的结果增量写入 JSON 文件，运行被中断 (Ctrl-C / 超时) 时不丢已完成的点评。
+// path 为空时所有操作都是空操作。
+type PartialSaver struct {
+	mu   sync.Mutex
+	path string
+	data map[string]json.RawMessage
+}
+
+func NewPartialSaver(path string) *PartialSaver {
+	return &PartialSaver{path: path, data: make(map[string]json.RawMessage)}
+}
+
+// Save 立即在调用方 goroutine 内序列化 value (避免与调用方后续修改竞争)，然后整体落盘
+func (s *PartialSaver) Save(key string, value interface{}) {
+	if s == nil || s.path == "" {
+		return
+	}
+	raw, err := json.Marshal(value)
+	if err != nil {
+		fmt.Printf("⚠️ [Partial] %s 序列化失败: %v\n", key, err)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data[key] = raw
+
+	out, _ := json.MarshalIndent(s.data, "", "  ")
+	tmp := s.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, out, 0644); err != nil {
+		fmt.Printf("⚠️ [Partial] 写入失败: %v\n", err)
+		return
+	}
+	os.Rename(tmp, s.path)
+}
@@ -0,0 +1,133 @@
+package deepseek_reviewer
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// Progress 在终端原地刷新每个板块 (或持仓) 的流式进度: 已收 token、耗时、当前个股。
+// 所有方法对 nil 接收者安全，非流式模式直接传 nil 即可。
+type Progress struct {
+	mu    sync.Mutex
+	out   io.Writer
+	rows  []*ProgressRow
+	lines int // 上一次绘制的行数，用于光标回退
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+type ProgressRow struct {
+	p      *Progress
+	name   string
+	stock  string
+	tokens int
+	start  time.Time
+	end    time.Time
+}
+
+const progressInterval = 300 * time.Millisecond
+
+func NewProgress(out io.Writer) *Progress {
+	return &Progress{
+		out:  out,
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+}
+
+func (p *Progress) Start() {
+	if p == nil {
+		return
+	}
+	go func() {
+		defer close(p.done)
+		ticker := time.NewTicker(progressInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				p.render()
+			case <-p.stop:
+				p.render()
+				return
+			}
+		}
+	}()
+}
+
+// Stop 停止刷新并画出最终状态
+func (p *Progress) Stop() {
+	if p == nil {
+		return
+	}
+	select {
+	case <-p.stop:
+		return
+	default:
+	}
+	close(p.stop)
+	<-p.done
+}
+
+// Row 注册一行进度 (按注册顺序显示)
+func (p *Progress) Row(name string) *ProgressRow {
+	if p == nil {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	row := &ProgressRow{p: p, name: name, start: time.Now()}
+	p.rows = append(p.rows, row)
+	return row
+}
+
+func (row *ProgressRow) SetStock(stock string) {
+	if row == nil {
+		return
+	}
+	row.p.mu.Lock()
+	row.stock = stock
+	row.p.mu.Unlock()
+}
+
+func (row *ProgressRow) AddTokens(n int) {
+	if row == nil {
+		return
+	}
+	row.p.mu.Lock()
+	row.tokens += n
+	row.p.mu.Unlock()
+}
+
+func (row *ProgressRow) Done() {
+	if row == nil {
+		return
+	}
+	row.p.mu.Lock()
+	row.end = time.Now()
+	row.p.mu.Unlock()
+}
+
+func (p *Progress) render() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// 回到上次绘制的起点，逐行清除重画
+	if p.lines > 0 {
+		fmt.Fprintf(p.out, "\033[%dA", p.lines)
+	}
+	for _, row := range p.rows {
+		icon := "⏳"
+		elapsed := time.Since(row.start)
+		if !row.end.IsZero() {
+			icon = "✅"
+			elapsed = row.end.Sub(row.start)
+		}
+		fmt.Fprintf(p.out, "\033[2K%s %-12s | %5d tok | %6.1fs | %s\n",
+			icon, truncate(row.name, 10), row.tokens, elapsed.Seconds(), truncate(row.stock, 16))
+	}
+	p.lines = len(p.rows)
+}
@@ -0,0 +1,161 @@
+package deepseek_reviewer
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// ChatStreamChunk 是 SSE 流中每个 data: 行的结构
+type ChatStreamChunk struct {
+	Choices []struct {
+		Delta        Message `json:"delta"`
+		FinishReason string  `json:"finish_reason"`
+	} `json:"choices"`
+}
+
+// ErrTruncated 流式输出中途断开 (读错误 / 取消 / 没收到 [DONE])，返回的内容不完整
+var ErrTruncated = errors.New("stream truncated")
+
+// Chat 根据 r.Stream 选择流式或普通请求，并把进度记到 row (可为 nil)。
+// 返回 error 时内容可能只是部分 (errors.Is(err, ErrTruncated))，调用方应标记为不完整而不是当作最终结果
+func (r *Reviewer) Chat(ctx context.Context, history []Message, row *ProgressRow) (string, error) {
+	if !r.Stream {
+		return r.sendChat(ctx, history)
+	}
+	return r.SendChatStream(ctx, history, func(delta string) {
+		row.AddTokens(1)
+	})
+}
+
+// SendChatStream 以 Stream: true 请求，逐块解析 SSE 并回调 onDelta，返回拼接后的内容。
+// 中途断流时返回已收到的部分和包装了 ErrTruncated 的错误
+func (r *Reviewer) SendChatStream(ctx context.Context, history []Message, onDelta func(delta string)) (string, error) {
+	reqBody := ChatRequest{
+		Model:    ModelName,
+		Messages: history,
+		Stream:   true,
+	}
+
+	// 空闲超时: 首字节前或两块之间超过 IdleTimeout 没有数据即取消请求
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	idle := newIdleTimer(r.IdleTimeout, cancel)
+	defer idle.Stop()
+
+	resp, errStr := r.doRequest(ctx, reqBody)
+	if resp == nil {
+		if idle.fired() {
+			errStr = fmt.Sprintf("Error: no response within %v", r.IdleTimeout)
+		}
+		return errStr, errors.New(errStr)
+	}
+	defer resp.Body.Close()
+
+	content, err := readSSE(idle.reader(resp.Body), onDelta)
+	if err != nil {
+		if idle.fired() {
+			err = fmt.Errorf("stream idle for %v", r.IdleTimeout)
+		}
+		return content, fmt.Errorf("%w: %v", ErrTruncated, err)
+	}
+	if content == "" {
+		return "No response content", errors.New("no response content")
+	}
+	return content, nil
+}
+
+// idleTimer 每收到数据就重新计时，超时调用 cancel。d <= 0 时不限制
+type idleTimer struct {
+	d     time.Duration
+	t     *time.Timer
+	timed atomic.Bool
+}
+
+func newIdleTimer(d time.Duration, cancel context.CancelFunc) *idleTimer {
+	it := &idleTimer{d: d}
+	if d > 0 {
+		it.t = time.AfterFunc(d, func() {
+			it.timed.Store(true)
+			cancel()
+		})
+	}
+	return it
+}
+
+func (it *idleTimer) fired() bool { return it.timed.Load() }
+
+func (it *idleTimer) Stop() {
+	if it.t != nil {
+		it.t.Stop()
+	}
+}
+
+func (it *idleTimer) reader(r io.Reader) io.Reader {
+	if it.t == nil {
+		return r
+	}
+	return idleReader{r: r, it: it}
+}
+
+type idleReader struct {
+	r  io.Reader
+	it *idleTimer
+}
+
+func (ir idleReader) Read(p []byte) (int, error) {
+	n, err := ir.r.Read(p)
+	if n > 0 && !ir.it.fired() {
+		ir.it.t.Reset(ir.it.d)
+	}
+	return n, err
+}
+
+// readSSE 解析 "data: {...}" 行直到 [DONE]。中途断流 (含未收到 [DONE] 的 EOF) 时返回已收到的部分和错误。
+func readSSE(body io.Reader, onDelta func(delta string)) (string, error) {
+	reader := bufio.NewReader(body)
+	var sb strings.Builder
+	for {
+		line, err := reader.ReadString('\n')
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "data:") {
+			payload := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+			if payload == "[DONE]" {
+				return sb.String(), nil
+			}
+			var chunk ChatStreamChunk
+			if json.Unmarshal([]byte(payload), &chunk) == nil && len(chunk.Choices) > 0 {
+				delta := chunk.Choices[0].Delta.Content
+				if delta != "" {
+					sb.WriteString(delta)
+					if onDelta != nil {
+						onDelta(delta)
+					}
+				}
+			}
+		}
+		if err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF // 没有 [DONE] 就结束，说明连接被提前断开
+			}
+			return sb.String(), err
+		}
+	}
+}
+
+// StartProgress 在流式模式下启动终端进度面板，非流式返回 nil (所有方法对 nil 安全)
+func (r *Reviewer) StartProgress() *Progress {
+	if !r.Stream {
+		return nil
+	}
+	p := NewProgress(os.Stdout)
+	p.Start()
+	return p
+}
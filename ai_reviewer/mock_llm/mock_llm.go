@@ -0,0 +1,359 @@
+package mock_llm
+
+import (
+	"dragon-quant/ai_reviewer/deepseek_reviewer"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io/ioutil"
+	"math"
+	"net"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Rule 脚本化应答: 最后一条 user/system 消息包含 Match 时返回 Response。
+// Fault 可选 "429"、"malformed" 或 "truncate"，用于模拟限流、坏 JSON 和流式输出中途断开。
+type Rule struct {
+	Match    string `json:"match"`
+	Response string `json:"response"`
+	Fault    string `json:"fault"`
+}
+
+// Options 控制 Mock 服务的行为
+type Options struct {
+	Rules          []Rule
+	RateLimitEvery int           // 每 N 个请求返回一次 429 (0 = 关闭)
+	MalformedEvery int           // 每 N 个请求返回一次坏 JSON (0 = 关闭)
+	ChunkDelay     time.Duration // 流式每块之前的等待 (模拟慢速生成)
+}
+
+// Server 是一个确定性的 OpenAI 风格 /chat/completions 假服务。
+// 没有脚本命中时，按 deepseek_reviewer 的 Prompt 识别阶段并生成合法 JSON。
+type Server struct {
+	opts     Options
+	mu       sync.Mutex
+	requests int
+
+	listener net.Listener
+	server   *http.Server
+}
+
+const (
+	FaultRateLimit = "429"
+	FaultMalformed = "malformed"
+	FaultTruncate  = "truncate" // 流式只发前一半内容就断开 (不发 [DONE])
+
+	malformedBody = `{"stock_name": "坏数据", "strategy": {`
+
+	streamChunkRunes = 8
+)
+
+var (
+	reStockLine  = regexp.MustCompile(`股票: (.+?) \((\w+)\)`)
+	reFinalLine  = regexp.MustCompile(`名称: (.+?) \((\w+)\)`)
+	reSectorLine = regexp.MustCompile(`板块: (.+?) \((\w+)\)`)
+	reSectorName = regexp.MustCompile(`【(.+?)】板块`)
+	reHoldPrice  = regexp.MustCompile(`Price: ([\d.]+)`)
+)
+
+func NewServer(opts Options) *Server {
+	return &Server{opts: opts}
+}
+
+// Start 在 addr 上启动服务 (addr 为空则使用 127.0.0.1 随机端口)，返回完整 API URL。
+func (s *Server) Start(addr string) (string, error) {
+	if addr == "" {
+		addr = "127.0.0.1:0"
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return "", fmt.Errorf("mock llm listen failed: %w", err)
+	}
+	s.listener = ln
+	s.server = &http.Server{Handler: s}
+	go s.server.Serve(ln)
+	return fmt.Sprintf("http://%s/chat/completions", ln.Addr().String()), nil
+}
+
+func (s *Server) Close() error {
+	if s.server == nil {
+		return nil
+	}
+	return s.server.Close()
+}
+
+// Requests 返回已处理的请求数
+func (s *Server) Requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, _ := ioutil.ReadAll(r.Body)
+	var req deepseek_reviewer.ChatRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		http.Error(w, "bad request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	s.requests++
+	n := s.requests
+	s.mu.Unlock()
+
+	content, fault := s.respond(req.Messages)
+	if fault == "" {
+		if s.opts.RateLimitEvery > 0 && n%s.opts.RateLimitEvery == 0 {
+			fault = FaultRateLimit
+		} else if s.opts.MalformedEvery > 0 && n%s.opts.MalformedEvery == 0 {
+			fault = FaultMalformed
+		}
+	}
+
+	switch fault {
+	case FaultRateLimit:
+		w.Header().Set("Retry-After", "0")
+		http.Error(w, `{"error":{"message":"mock rate limit"}}`, http.StatusTooManyRequests)
+		return
+	case FaultMalformed:
+		content = malformedBody
+	}
+
+	if req.Stream {
+		if fault == FaultTruncate {
+			s.writeStream(w, string([]rune(content)[:len([]rune(content))/2]), false)
+			return
+		}
+		s.writeStream(w, content, true)
+		return
+	}
+	writeCompletion(w, content)
+}
+
+// writeStream 以 SSE 分块下发 (每块若干字符)，done 时结尾发送 [DONE]
+func (s *Server) writeStream(w http.ResponseWriter, content string, done bool) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	flusher, _ := w.(http.Flusher)
+
+	runes := []rune(content)
+	for i := 0; i < len(runes); i += streamChunkRunes {
+		end := i + streamChunkRunes
+		if end > len(runes) {
+			end = len(runes)
+		}
+		if s.opts.ChunkDelay > 0 {
+			time.Sleep(s.opts.ChunkDelay)
+		}
+		var chunk deepseek_reviewer.ChatStreamChunk
+		chunk.Choices = append(chunk.Choices, struct {
+			Delta        deepseek_reviewer.Message `json:"delta"`
+			FinishReason string                    `json:"finish_reason"`
+		}{Delta: deepseek_reviewer.Message{Role: "assistant", Content: string(runes[i:end])}})
+		data, _ := json.Marshal(chunk)
+		fmt.Fprintf(w, "data: %s\n\n", data)
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+	if done {
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}
+}
+
+func writeCompletion(w http.ResponseWriter, content string) {
+	resp := deepseek_reviewer.ChatResponse{}
+	resp.Choices = append(resp.Choices, struct {
+		Message deepseek_reviewer.Message `json:"message"`
+	}{Message: deepseek_reviewer.Message{Role: "assistant", Content: content}})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// respond 先查脚本规则，再按 Prompt 生成结构化应答
+func (s *Server) respond(history []deepseek_reviewer.Message) (string, string) {
+	if len(history) == 0 {
+		return "", ""
+	}
+	last := history[len(history)-1].Content
+	system := ""
+	if history[0].Role == "system" {
+		system = history[0].Content
+	}
+
+	for _, rule := range s.opts.Rules {
+		if strings.Contains(last, rule.Match) || strings.Contains(system, rule.Match) {
+			if rule.Fault == FaultTruncate && rule.Response == "" {
+				content, _ := s.generate(history, last, system) // 截断默认生成的应答
+				return content, rule.Fault
+			}
+			return rule.Response, rule.Fault
+		}
+	}
+	return s.generate(history, last, system)
+}
+
+// generate 按 Prompt 阶段生成结构化应答
+func (s *Server) generate(history []deepseek_reviewer.Message, last, system string) (string, string) {
+
+	switch {
+	case last == deepseek_reviewer.SniperPrompt:
+		return sniperJSON(history), ""
+	case last == deepseek_reviewer.Prompt30mSelect:
+		return sector30mJSON(history), ""
+	case system == deepseek_reviewer.GrandFinalPrompt:
+		return grandFinalJSON(last), ""
+	case system == deepseek_reviewer.SectorTrendPrompt:
+		return sectorTrendJSON(last), ""
+	case strings.Contains(last, `"stance"`):
+		return holdReviewJSON(last), ""
+	}
+
+	if m := reStockLine.FindStringSubmatch(last); m != nil {
+		return fmt.Sprintf("【Mock】%s (%s): 量价配合正常, 弱转强待确认。", m[1], m[2]), ""
+	}
+	return "【Mock】收到, 准备好了。", ""
+}
+
+type stockRef struct {
+	Name string
+	Code string
+}
+
+func collectStocks(re *regexp.Regexp, texts ...string) []stockRef {
+	var refs []stockRef
+	seen := make(map[string]bool)
+	for _, t := range texts {
+		for _, m := range re.FindAllStringSubmatch(t, -1) {
+			if seen[m[2]] {
+				continue
+			}
+			seen[m[2]] = true
+			refs = append(refs, stockRef{Name: m[1], Code: m[2]})
+		}
+	}
+	return refs
+}
+
+func userTexts(history []deepseek_reviewer.Message) []string {
+	var texts []string
+	for _, m := range history {
+		if m.Role == "user" {
+			texts = append(texts, m.Content)
+		}
+	}
+	return texts
+}
+
+func sniperJSON(history []deepseek_reviewer.Message) string {
+	var res deepseek_reviewer.SniperJSON
+	if stocks := collectStocks(reStockLine, userTexts(history)...); len(stocks) > 0 {
+		res.StockName = stocks[0].Name
+		res.StockCode = stocks[0].Code
+	}
+	res.Reason = "Mock: 板块内资金承接最强"
+	res.KeyMetric = "Mock: 竞价爆量"
+	res.Strategy.EntryPrice = "回踩分时均线低吸"
+	res.Strategy.StopLoss = "跌破昨日收盘价"
+	res.Strategy.TargetPrice = "冲高 5% 止盈"
+	res.RiskWarning = "放量跌破均价线"
+	data, _ := json.Marshal(res)
+	return string(data)
+}
+
+func sector30mJSON(history []deepseek_reviewer.Message) string {
+	texts := userTexts(history)
+	var res deepseek_reviewer.Sector30mResult
+	if len(texts) > 0 {
+		if m := reSectorName.FindStringSubmatch(texts[0]); m != nil {
+			res.SectorName = m[1]
+		}
+	}
+	for i, st := range collectStocks(reStockLine, texts...) {
+		if i >= 3 {
+			break
+		}
+		res.Top3 = append(res.Top3, deepseek_reviewer.Top3Result{
+			StockName: st.Name,
+			StockCode: st.Code,
+			Rank:      i + 1,
+			Metric:    "Mock: N字反包",
+			Reason:    "Mock: 缩量回调后放量反包",
+			Deduction: "Mock: 高开 2% 确认主升",
+		})
+	}
+	data, _ := json.Marshal(res)
+	return string(data)
+}
+
+func grandFinalJSON(user string) string {
+	var res deepseek_reviewer.GrandFinalJSON
+	for i, st := range collectStocks(reFinalLine, user) {
+		if i >= 5 {
+			break
+		}
+		res.Top5 = append(res.Top5, deepseek_reviewer.TopStock{
+			StockName: st.Name,
+			StockCode: st.Code,
+			Rank:      i + 1,
+			Reason:    "Mock: 均线多头, 回踩支撑",
+		})
+	}
+	res.MarketSentiment = "Mock: 震荡市, 结构性机会"
+	data, _ := json.Marshal(res)
+	return string(data)
+}
+
+var trendStatuses = []string{"MainWave", "Wash", "Ignition", "Dump"}
+
+func sectorTrendJSON(user string) string {
+	var res deepseek_reviewer.AISecomResponse
+	for _, sec := range collectStocks(reSectorLine, user) {
+		// 按代码哈希决定状态，保证同一输入永远得到同一结果
+		h := fnv.New32a()
+		h.Write([]byte(sec.Code))
+		res.Sectors = append(res.Sectors, deepseek_reviewer.SectorTrendResult{
+			SectorCode: sec.Code,
+			Status:     trendStatuses[h.Sum32()%uint32(len(trendStatuses))],
+			Reason:     "Mock: 基于代码的确定性判定",
+		})
+	}
+	data, _ := json.Marshal(res)
+	return string(data)
+}
+
+func holdReviewJSON(prompt string) string {
+	price := 10.0
+	if m := reHoldPrice.FindStringSubmatch(prompt); m != nil {
+		if p, err := strconv.ParseFloat(m[1], 64); err == nil && p > 0 {
+			price = p
+		}
+	}
+	round := func(v float64) float64 { return math.Round(v*100) / 100 }
+
+	res := deepseek_reviewer.HoldReviewJSON{
+		Stance:       deepseek_reviewer.StanceHold,
+		Support:      []float64{round(price * 0.97), round(price * 0.93)},
+		Resistance:   []float64{round(price * 1.05), round(price * 1.10)},
+		StopLoss:     round(price * 0.95),
+		Invalidation: "Mock: 放量跌破 30m 均价线",
+		Confidence:   60,
+		Comment:      "Mock: 缩量整理, 拿住不动",
+	}
+	if strings.Contains(prompt, `"prose"`) {
+		res.Prose = "Mock: 主力洗盘未完, 耐心等待放量突破。"
+	}
+	data, _ := json.Marshal(res)
+	return string(data)
+}
@@ -0,0 +1,159 @@
+package mock_llm
+
+import (
+	"context"
+	"dragon-quant/ai_reviewer/deepseek_reviewer"
+	"dragon-quant/model"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestReviewer(t *testing.T, opts Options) (*deepseek_reviewer.Reviewer, *Server) {
+	srv := NewServer(opts)
+	url, err := srv.Start("")
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	t.Cleanup(func() { srv.Close() })
+	return deepseek_reviewer.NewReviewer("mock", url), srv
+}
+
+func sampleSectorMap() map[string][]*model.StockInfo {
+	return map[string][]*model.StockInfo{
+		"半导体": {
+			{Code: "600001", Name: "甲股份", KLine30mStr: "[Bar-1: C=10.00, R=0.00%, V=100]"},
+			{Code: "000002", Name: "乙科技", KLine30mStr: "[Bar-1: C=20.00, R=1.00%, V=200]"},
+		},
+	}
+}
+
+func TestStructuredShapes(t *testing.T) {
+	reviewer, _ := newTestReviewer(t, Options{})
+	sectorMap := sampleSectorMap()
+
+	res30m := reviewer.ReviewBySector30m(context.Background(), sectorMap)
+	if r := res30m["半导体"]; r == nil || len(r.Top3) != 2 || r.Top3[0].StockCode != "600001" {
+		t.Fatalf("Unexpected 30m result: %+v", r)
+	}
+
+	sectorRes := reviewer.ReviewBySector(context.Background(), sectorMap, "")
+	pick := sectorRes["半导体"].FinalPick
+	if pick == nil || pick.StockCode != "600001" {
+		t.Fatalf("Unexpected sniper pick: %+v", pick)
+	}
+
+	gf := reviewer.ReviewGrandFinals(context.Background(), sectorMap["半导体"], "")
+	if gf == nil || len(gf.Top5) != 2 || gf.Top5[1].StockCode != "000002" {
+		t.Fatalf("Unexpected grand final: %+v", gf)
+	}
+
+	hold, raw, _ := reviewer.ReviewHold(context.Background(), "# Stock: 甲股份 (600001)\n**Event 1**: 10:00 | Price: 20.00 |\n"+deepseek_reviewer.HoldReviewPromptSuffix(true), nil)
+	if hold == nil || hold.Stance != deepseek_reviewer.StanceHold || hold.StopLoss != 19 || hold.Prose == "" {
+		t.Fatalf("Unexpected hold review: %+v (raw %s)", hold, raw)
+	}
+
+	history := make([]model.KLineData, 6)
+	trends := reviewer.ReviewSectorTrends(context.Background(), []model.SectorInfo{{Code: "BK0001", Name: "测试板块", History: history}})
+	if _, ok := trends["BK0001"]; !ok {
+		t.Fatalf("Missing sector trend, got %+v", trends)
+	}
+}
+
+func TestDeterministic(t *testing.T) {
+	reviewer, _ := newTestReviewer(t, Options{})
+	a := reviewer.ReviewGrandFinals(context.Background(), sampleSectorMap()["半导体"], "")
+	b := reviewer.ReviewGrandFinals(context.Background(), sampleSectorMap()["半导体"], "")
+	if a == nil || b == nil || a.Top5[0] != b.Top5[0] || a.MarketSentiment != b.MarketSentiment {
+		t.Fatalf("Responses differ: %+v vs %+v", a, b)
+	}
+}
+
+func TestFaultInjection(t *testing.T) {
+	// 每 2 个请求一次 429: SendChat 应自动重试成功
+	reviewer, srv := newTestReviewer(t, Options{RateLimitEvery: 2})
+	for i := 0; i < 3; i++ {
+		resp := reviewer.SendChat(context.Background(), []deepseek_reviewer.Message{{Role: "user", Content: "ping"}})
+		if resp != "【Mock】收到, 准备好了。" {
+			t.Fatalf("Unexpected response after 429 retry: %s", resp)
+		}
+	}
+	if srv.Requests() <= 3 {
+		t.Errorf("Expected retries to hit the server, got %d requests", srv.Requests())
+	}
+
+	// 脚本规则注入坏 JSON: 板块王者解析失败但不 panic
+	reviewer, _ = newTestReviewer(t, Options{Rules: []Rule{{Match: "输出要求 (严格执行)", Fault: FaultMalformed}}})
+	sectorRes := reviewer.ReviewBySector(context.Background(), sampleSectorMap(), "")
+	if sectorRes["半导体"].FinalPick != nil {
+		t.Errorf("Expected nil FinalPick on malformed JSON, got %+v", sectorRes["半导体"].FinalPick)
+	}
+}
+
+func TestStreaming(t *testing.T) {
+	reviewer, _ := newTestReviewer(t, Options{})
+	reviewer.Stream = true
+	reviewer.PartialFile = filepath.Join(t.TempDir(), "partial.json")
+
+	deltas := 0
+	resp, err := reviewer.SendChatStream(context.Background(), []deepseek_reviewer.Message{{Role: "user", Content: "ping"}}, func(string) { deltas++ })
+	if err != nil || resp != "【Mock】收到, 准备好了。" {
+		t.Fatalf("Unexpected streamed content: %q (%v)", resp, err)
+	}
+	if deltas < 2 {
+		t.Errorf("Expected multiple SSE chunks, got %d", deltas)
+	}
+
+	// 流式模式下结构化结果依旧可解析，且两个阶段的阶段性结果都已落盘 (后一阶段不覆盖前一阶段)
+	res30m := reviewer.ReviewBySector30m(context.Background(), sampleSectorMap())
+	if r := res30m["半导体"]; r == nil || len(r.Top3) != 2 || len(r.StockReviews) != 2 {
+		t.Fatalf("Unexpected streamed 30m result: %+v", r)
+	}
+	sectorRes := reviewer.ReviewBySector(context.Background(), sampleSectorMap(), "")
+	if pick := sectorRes["半导体"].FinalPick; pick == nil || pick.StockCode != "600001" {
+		t.Fatalf("Unexpected streamed sniper pick: %+v", pick)
+	}
+	data, err := os.ReadFile(reviewer.PartialFile)
+	if err != nil || !strings.Contains(string(data), "600001") || !strings.Contains(string(data), `"30m:半导体"`) || !strings.Contains(string(data), `"半导体"`) {
+		t.Errorf("Partial file missing reviews: %v\n%s", err, data)
+	}
+}
+
+func TestStreamTruncated(t *testing.T) {
+	reviewer, _ := newTestReviewer(t, Options{Rules: []Rule{{Match: "输出要求 (严格执行)", Fault: FaultTruncate}}})
+	reviewer.Stream = true
+
+	// 断流的部分内容带错误返回，而不是当作完整回答
+	resp, err := reviewer.SendChatStream(context.Background(), []deepseek_reviewer.Message{{Role: "user", Content: deepseek_reviewer.SniperPrompt}}, nil)
+	if !errors.Is(err, deepseek_reviewer.ErrTruncated) || resp == "" {
+		t.Fatalf("resp = %q err = %v, want partial content with ErrTruncated", resp, err)
+	}
+
+	// 板块王者断流: 不解析半截 JSON，结果标记为不完整
+	res := reviewer.ReviewBySector(context.Background(), sampleSectorMap(), "")
+	if r := res["半导体"]; r == nil || r.FinalPick != nil || !r.Partial || len(r.StockReviews) != 2 {
+		t.Errorf("sector result = %+v", r)
+	}
+}
+
+func TestStreamIdleTimeout(t *testing.T) {
+	reviewer, _ := newTestReviewer(t, Options{ChunkDelay: 30 * time.Millisecond})
+	reviewer.Stream = true
+	msgs := []deepseek_reviewer.Message{{Role: "user", Content: "ping"}}
+
+	// 整体时长超过 Timeout 但每块都按时到达: 流式不受整体超时限制
+	reviewer.Timeout = 40 * time.Millisecond
+	reviewer.IdleTimeout = 500 * time.Millisecond
+	if resp, err := reviewer.SendChatStream(context.Background(), msgs, nil); err != nil || resp != "【Mock】收到, 准备好了。" {
+		t.Fatalf("slow but live stream: resp = %q err = %v", resp, err)
+	}
+
+	// 首字节 / 两块之间停顿超过 IdleTimeout: 取消请求并返回错误
+	reviewer.IdleTimeout = 10 * time.Millisecond
+	if _, err := reviewer.SendChatStream(context.Background(), msgs, nil); err == nil {
+		t.Fatal("stalled stream returned no error")
+	}
+}
@@ -0,0 +1,436 @@
+package broker_importer
+
+import (
+	"bytes"
+	"dragon-quant/config"
+	"dragon-quant/model"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/simplifiedchinese"
+)
+
+// 支持的对账单格式
+const (
+	FormatTHS       = "ths"       // 同花顺 (资金股份 / 历史成交)
+	FormatEastMoney = "eastmoney" // 东方财富 (持仓 / 交割单)
+	FormatGeneric   = "generic"   // 通用 CSV (见 README)
+)
+
+// 对账单种类: 持仓快照 或 成交流水
+const (
+	KindPositions = "positions"
+	KindTrades    = "trades"
+)
+
+// Holding 一只持仓 (来自持仓快照或由成交流水汇总)
+type Holding struct {
+	Code        string
+	Name        string
+	Shares      int
+	Sellable    int
+	Cost        float64 // 摊薄后的持仓成本价
+	EntryDate   string  // 本轮建仓的首笔买入日期
+	RealizedPnL float64
+	Trades      []model.Trade
+}
+
+// Statement 一份解析后的对账单
+type Statement struct {
+	Format   string
+	Kind     string
+	Holdings []Holding
+	Trades   []model.Trade
+}
+
+// 各字段在不同券商导出中的表头别名
+type columnAliases map[string][]string
+
+var formatAliases = map[string]columnAliases{
+	FormatTHS: {
+		"code":     {"证券代码"},
+		"name":     {"证券名称"},
+		"shares":   {"股票余额", "实际数量", "持仓数量"},
+		"sellable": {"可用余额", "可卖数量"},
+		"cost":     {"参考成本价", "成本价"},
+		"date":     {"成交日期"},
+		"time":     {"成交时间"},
+		"side":     {"操作"},
+		"quantity": {"成交数量"},
+		"price":    {"成交均价", "成交价格"},
+		"amount":   {"成交金额"},
+	},
+	FormatEastMoney: {
+		"code":     {"证券代码"},
+		"name":     {"证券名称"},
+		"shares":   {"持仓数量", "股份余额"},
+		"sellable": {"可用数量", "可用股份"},
+		"cost":     {"成本价", "持仓成本"},
+		"date":     {"成交日期", "发生日期"},
+		"time":     {"成交时间"},
+		"side":     {"委托方向", "买卖标志", "业务名称"},
+		"quantity": {"成交数量"},
+		"price":    {"成交价格", "成交均价"},
+		"amount":   {"成交金额"},
+	},
+	FormatGeneric: {
+		"code":     {"code"},
+		"name":     {"name"},
+		"shares":   {"shares"},
+		"sellable": {"sellable"},
+		"cost":     {"cost"},
+		"date":     {"date", "entry_date"},
+		"time":     {"time"},
+		"side":     {"side"},
+		"quantity": {"quantity"},
+		"price":    {"price"},
+		"amount":   {"amount"},
+	},
+}
+
+// ImportFile 读取券商导出文件。客户端导出的 .xls 实际是制表符分隔的文本，按文本解析。
+func ImportFile(path string) (*Statement, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	st, err := Parse(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	return st, nil
+}
+
+// Parse 自动识别分隔符与券商格式，返回持仓 (成交流水会被汇总为持仓)
+func Parse(r io.Reader) (*Statement, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.HasPrefix(data, []byte{0xD0, 0xCF, 0x11, 0xE0}) {
+		return nil, fmt.Errorf("二进制 Excel 文件暂不支持，请在客户端导出为文本/CSV，或用 Excel 另存为 CSV (UTF-8)")
+	}
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	if !utf8.Valid(data) {
+		// 同花顺 / 东方财富客户端导出为 GBK
+		if data, err = simplifiedchinese.GBK.NewDecoder().Bytes(data); err != nil {
+			return nil, fmt.Errorf("文件既不是 UTF-8 也不是 GBK 编码: %w", err)
+		}
+	}
+
+	rows, err := readRows(data)
+	if err != nil {
+		return nil, err
+	}
+
+	headerIdx, format, cols := detectHeader(rows)
+	if headerIdx < 0 {
+		return nil, fmt.Errorf("无法识别表头 (需要包含 证券代码 或 code 列)")
+	}
+
+	st := &Statement{Format: format}
+	body := rows[headerIdx+1:]
+
+	if _, ok := cols["side"]; ok {
+		st.Kind = KindTrades
+		st.Trades, err = parseTrades(body, cols)
+		if err != nil {
+			return nil, err
+		}
+		st.Holdings = AggregateTrades(st.Trades, time.Now())
+	} else {
+		st.Kind = KindPositions
+		st.Holdings, err = parsePositions(body, cols)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return st, nil
+}
+
+func readRows(data []byte) ([][]string, error) {
+	firstLine := string(data)
+	if i := strings.IndexByte(firstLine, '\n'); i >= 0 {
+		firstLine = firstLine[:i]
+	}
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	reader.TrimLeadingSpace = true
+	if strings.Count(firstLine, "\t") > strings.Count(firstLine, ",") {
+		reader.Comma = '\t'
+	}
+	return reader.ReadAll()
+}
+
+// detectHeader 在前几行中找出匹配列最多的格式
+func detectHeader(rows [][]string) (int, string, map[string]int) {
+	bestIdx, bestFormat, bestScore := -1, "", 0
+	var bestCols map[string]int
+
+	for i := 0; i < len(rows) && i < 10; i++ {
+		for _, format := range []string{FormatTHS, FormatEastMoney, FormatGeneric} {
+			cols := matchColumns(rows[i], formatAliases[format])
+			if _, ok := cols["code"]; !ok {
+				continue
+			}
+			if len(cols) > bestScore {
+				bestIdx, bestFormat, bestScore, bestCols = i, format, len(cols), cols
+			}
+		}
+	}
+	return bestIdx, bestFormat, bestCols
+}
+
+func matchColumns(header []string, aliases columnAliases) map[string]int {
+	cols := make(map[string]int)
+	for i, h := range header {
+		h = strings.ToLower(cleanCell(h))
+		for field, names := range aliases {
+			if _, done := cols[field]; done {
+				continue
+			}
+			for _, name := range names {
+				if h == strings.ToLower(name) {
+					cols[field] = i
+					break
+				}
+			}
+		}
+	}
+	return cols
+}
+
+// cleanCell 去掉同花顺导出的 ="000001" 包装与空白
+func cleanCell(s string) string {
+	s = strings.TrimSpace(s)
+	s = strings.TrimPrefix(s, "=")
+	s = strings.Trim(s, "\"")
+	return strings.TrimSpace(s)
+}
+
+func cell(row []string, cols map[string]int, field string) string {
+	i, ok := cols[field]
+	if !ok || i >= len(row) {
+		return ""
+	}
+	return cleanCell(row[i])
+}
+
+func parseFloat(s string) float64 {
+	s = strings.ReplaceAll(s, ",", "")
+	v, _ := strconv.ParseFloat(s, 64)
+	return v
+}
+
+func parseInt(s string) int {
+	return int(parseFloat(s))
+}
+
+// normalizeCode 补齐前导零 (Excel 会吃掉 000001 的 0)
+func normalizeCode(code string) string {
+	if code == "" {
+		return ""
+	}
+	if _, err := strconv.Atoi(code); err == nil && len(code) < 6 {
+		return strings.Repeat("0", 6-len(code)) + code
+	}
+	return code
+}
+
+// normalizeDate 支持 20260105 / 2026-01-05 / 2026/01/05
+func normalizeDate(s string) string {
+	for _, layout := range []string{"20060102", "2006-01-02", "2006/01/02", "2006/1/2"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.Format("2006-01-02")
+		}
+	}
+	return s
+}
+
+func normalizeSide(s string) string {
+	switch {
+	case strings.Contains(s, "买"), strings.EqualFold(s, "buy"), strings.EqualFold(s, "b"):
+		return model.TradeBuy
+	case strings.Contains(s, "卖"), strings.EqualFold(s, "sell"), strings.EqualFold(s, "s"):
+		return model.TradeSell
+	}
+	return ""
+}
+
+func parsePositions(rows [][]string, cols map[string]int) ([]Holding, error) {
+	var holdings []Holding
+	for _, row := range rows {
+		code := normalizeCode(cell(row, cols, "code"))
+		if code == "" {
+			continue
+		}
+		h := Holding{
+			Code:      code,
+			Name:      cell(row, cols, "name"),
+			Shares:    parseInt(cell(row, cols, "shares")),
+			Cost:      parseFloat(cell(row, cols, "cost")),
+			EntryDate: normalizeDate(cell(row, cols, "date")),
+		}
+		h.Sellable = h.Shares
+		if _, ok := cols["sellable"]; ok {
+			h.Sellable = parseInt(cell(row, cols, "sellable"))
+		}
+		if h.Shares <= 0 {
+			continue
+		}
+		holdings = append(holdings, h)
+	}
+	if len(holdings) == 0 {
+		return nil, fmt.Errorf("持仓文件中没有有效记录")
+	}
+	return holdings, nil
+}
+
+func parseTrades(rows [][]string, cols map[string]int) ([]model.Trade, error) {
+	var trades []model.Trade
+	for _, row := range rows {
+		code := normalizeCode(cell(row, cols, "code"))
+		side := normalizeSide(cell(row, cols, "side"))
+		if code == "" || side == "" {
+			continue // 跳过 银证转账 / 红利 等非买卖流水
+		}
+		t := model.Trade{
+			Date:     normalizeDate(cell(row, cols, "date")),
+			Time:     cell(row, cols, "time"),
+			Code:     code,
+			Name:     cell(row, cols, "name"),
+			Side:     side,
+			Quantity: parseInt(cell(row, cols, "quantity")),
+			Price:    parseFloat(cell(row, cols, "price")),
+			Amount:   parseFloat(cell(row, cols, "amount")),
+		}
+		if t.Quantity < 0 {
+			t.Quantity = -t.Quantity // 部分券商卖出数量为负
+		}
+		if t.Quantity == 0 {
+			continue
+		}
+		if t.Amount == 0 {
+			t.Amount = t.Price * float64(t.Quantity)
+		}
+		if t.Amount < 0 {
+			t.Amount = -t.Amount
+		}
+		trades = append(trades, t)
+	}
+	if len(trades) == 0 {
+		return nil, fmt.Errorf("成交文件中没有有效买卖记录")
+	}
+	return trades, nil
+}
+
+// AggregateTrades 按代码汇总成交流水为持仓 (移动加权成本)。
+// 清仓后重新买入视为新一轮建仓；当日 (now) 买入的数量按 T+1 不计入可卖。
+func AggregateTrades(trades []model.Trade, now time.Time) []Holding {
+	sorted := make([]model.Trade, len(trades))
+	copy(sorted, trades)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Date != sorted[j].Date {
+			return sorted[i].Date < sorted[j].Date
+		}
+		return sorted[i].Time < sorted[j].Time
+	})
+
+	today := now.Format("2006-01-02")
+	byCode := make(map[string]*Holding)
+	var order []string
+	boughtToday := make(map[string]int)
+
+	for _, t := range sorted {
+		h, ok := byCode[t.Code]
+		if !ok {
+			h = &Holding{Code: t.Code, Name: t.Name}
+			byCode[t.Code] = h
+			order = append(order, t.Code)
+		}
+		h.Trades = append(h.Trades, t)
+
+		switch t.Side {
+		case model.TradeBuy:
+			if h.Shares == 0 {
+				h.EntryDate = t.Date
+				h.Cost = 0
+			}
+			total := h.Cost*float64(h.Shares) + t.Amount
+			h.Shares += t.Quantity
+			h.Cost = total / float64(h.Shares)
+			if t.Date == today {
+				boughtToday[t.Code] += t.Quantity
+			}
+		case model.TradeSell:
+			qty := t.Quantity
+			if qty > h.Shares {
+				qty = h.Shares // 导出区间之前的老仓位，无法计算成本
+			}
+			h.RealizedPnL += t.Amount*float64(qty)/float64(t.Quantity) - h.Cost*float64(qty)
+			h.Shares -= qty
+			if h.Shares == 0 {
+				h.EntryDate = ""
+			}
+		}
+	}
+
+	var holdings []Holding
+	for _, code := range order {
+		h := byCode[code]
+		if h.Shares <= 0 {
+			continue
+		}
+		h.Sellable = h.Shares - boughtToday[code]
+		if h.Sellable < 0 {
+			h.Sellable = 0
+		}
+		holdings = append(holdings, *h)
+	}
+	return holdings
+}
+
+// Positions 转换为 hold-kline 使用的持仓配置
+func (s *Statement) Positions() []config.HoldPosition {
+	var positions []config.HoldPosition
+	for _, h := range s.Holdings {
+		sellable := h.Sellable
+		positions = append(positions, config.HoldPosition{
+			Code:        h.Code,
+			Name:        h.Name,
+			Cost:        h.Cost,
+			Shares:      h.Shares,
+			EntryDate:   h.EntryDate,
+			Sellable:    &sellable,
+			RealizedPnL: h.RealizedPnL,
+			Trades:      h.Trades,
+		})
+	}
+	return positions
+}
+
+// MergePositions 用导入的持仓覆盖同代码的配置项，其余配置项保留
+func MergePositions(existing, imported []config.HoldPosition) []config.HoldPosition {
+	importedCodes := make(map[string]bool)
+	for _, p := range imported {
+		importedCodes[p.Code] = true
+	}
+	var merged []config.HoldPosition
+	for _, p := range existing {
+		if p.Code != "" && importedCodes[p.Code] {
+			continue
+		}
+		merged = append(merged, p)
+	}
+	return append(merged, imported...)
+}
@@ -0,0 +1,96 @@
+package broker_importer
+
+import (
+	"dragon-quant/config"
+	"dragon-quant/model"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestImportTHSPositions(t *testing.T) {
+	st, err := ImportFile("testdata/ths_positions.xls")
+	if err != nil {
+		t.Fatalf("ImportFile failed: %v", err)
+	}
+	if st.Format != FormatTHS || st.Kind != KindPositions || len(st.Holdings) != 2 {
+		t.Fatalf("Unexpected statement: %+v", st)
+	}
+	h := st.Holdings[0]
+	if h.Code != "600036" || h.Shares != 1000 || h.Sellable != 600 || h.Cost != 35.2 {
+		t.Errorf("Unexpected holding: %+v", h)
+	}
+}
+
+func TestImportEastMoneyTrades(t *testing.T) {
+	st, err := ImportFile("testdata/eastmoney_trades.csv")
+	if err != nil {
+		t.Fatalf("ImportFile failed: %v", err)
+	}
+	if st.Kind != KindTrades || len(st.Trades) != 5 {
+		t.Fatalf("Expected 5 buy/sell trades, got %d (%s)", len(st.Trades), st.Kind)
+	}
+
+	// 招商银行: 35/37 各 1000 股 -> 成本 36, 卖出 1000 @38 -> 已实现 +2000, 剩余 1000
+	// 平安银行: 已清仓, 不应出现在持仓中
+	if len(st.Holdings) != 1 {
+		t.Fatalf("Expected 1 open holding, got %+v", st.Holdings)
+	}
+	h := st.Holdings[0]
+	if h.Code != "600036" || h.Shares != 1000 || math.Abs(h.Cost-36) > 1e-9 || math.Abs(h.RealizedPnL-2000) > 1e-9 {
+		t.Errorf("Unexpected aggregated holding: %+v", h)
+	}
+	if h.EntryDate != "2026-01-05" || len(h.Trades) != 3 {
+		t.Errorf("Unexpected entry date / trades: %s / %d", h.EntryDate, len(h.Trades))
+	}
+}
+
+func TestAggregateTradesT1(t *testing.T) {
+	now, _ := time.Parse("2006-01-02", "2026-01-08")
+	holdings := AggregateTrades([]model.Trade{
+		{Date: "2026-01-07", Code: "600036", Side: model.TradeBuy, Quantity: 1000, Amount: 35000},
+		{Date: "2026-01-08", Code: "600036", Side: model.TradeBuy, Quantity: 500, Amount: 18000},
+	}, now)
+	if len(holdings) != 1 || holdings[0].Shares != 1500 || holdings[0].Sellable != 1000 {
+		t.Fatalf("Unexpected T+1 sellable: %+v", holdings)
+	}
+}
+
+func TestGenericPositionsMerge(t *testing.T) {
+	st, err := ImportFile("testdata/generic_positions.csv")
+	if err != nil {
+		t.Fatalf("ImportFile failed: %v", err)
+	}
+	if st.Format != FormatGeneric || st.Holdings[0].EntryDate != "2026-01-05" {
+		t.Fatalf("Unexpected generic import: %+v", st)
+	}
+
+	existing := []config.HoldPosition{{Code: "600036", Name: "招商银行"}, {Name: "平安银行"}}
+	merged := MergePositions(existing, st.Positions())
+	if len(merged) != 2 || merged[1].Code != "600036" || merged[1].Shares != 1000 {
+		t.Errorf("Unexpected merge result: %+v", merged)
+	}
+}
+
+func TestImportGBK(t *testing.T) {
+	// 同花顺 / 东方财富客户端导出的原始 GBK 文件，结果应与 UTF-8 版本一致
+	for _, pair := range [][2]string{
+		{"testdata/ths_positions_gbk.xls", "testdata/ths_positions.xls"},
+		{"testdata/eastmoney_trades_gbk.csv", "testdata/eastmoney_trades.csv"},
+	} {
+		gbk, err := ImportFile(pair[0])
+		if err != nil {
+			t.Fatalf("%s: %v", pair[0], err)
+		}
+		want, _ := ImportFile(pair[1])
+		if gbk.Format != want.Format || gbk.Kind != want.Kind || len(gbk.Holdings) != len(want.Holdings) {
+			t.Fatalf("%s: %+v, want %+v", pair[0], gbk, want)
+		}
+		for i, h := range gbk.Holdings {
+			w := want.Holdings[i]
+			if h.Code != w.Code || h.Name != w.Name || h.Shares != w.Shares || h.Sellable != w.Sellable || h.Cost != w.Cost {
+				t.Errorf("%s holding %d = %+v, want %+v", pair[0], i, h, w)
+			}
+		}
+	}
+}
@@ -0,0 +1,354 @@
+package calendar
+
+import (
+	"bufio"
+	"context"
+	_ "embed"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A股交易日历与交易时段。
+// 所有时间按交易所本地时钟 (北京时间) 的墙上时间解释，不做时区换算；Now() 返回北京时间。
+
+//go:embed holidays.txt
+var bundledHolidays string
+
+const dateLayout = "2006-01-02"
+
+// 交易时段 (相对当日 0 点)
+const (
+	OpeningAuctionStart = 9*time.Hour + 15*time.Minute  // 开盘集合竞价 9:15 (9:20 前可撤单)
+	CancelDeadline      = 9*time.Hour + 20*time.Minute  // 9:20 后不可撤单
+	OpeningAuctionEnd   = 9*time.Hour + 25*time.Minute  // 9:25 撮合出开盘价
+	MorningOpen         = 9*time.Hour + 30*time.Minute  // 连续竞价
+	MorningClose        = 11*time.Hour + 30*time.Minute // 午间休市
+	AfternoonOpen       = 13 * time.Hour
+	ClosingAuctionStart = 14*time.Hour + 57*time.Minute // 收盘集合竞价 14:57-15:00
+	MarketClose         = 15 * time.Hour
+)
+
+// Phase 交易时段
+type Phase int
+
+const (
+	Closed         Phase = iota // 非交易日
+	PreOpen                     // 交易日 9:15 前
+	OpeningAuction              // 9:15-9:25 开盘集合竞价
+	OpeningPause                // 9:25-9:30 撮合完成，等待开盘
+	Morning                     // 9:30-11:30
+	LunchBreak                  // 11:30-13:00
+	Afternoon                   // 13:00-14:57
+	ClosingAuction              // 14:57-15:00 收盘集合竞价
+	AfterClose                  // 15:00 后
+)
+
+var phaseNames = map[Phase]string{
+	Closed:         "休市",
+	PreOpen:        "盘前",
+	OpeningAuction: "开盘竞价",
+	OpeningPause:   "竞价撮合",
+	Morning:        "早盘",
+	LunchBreak:     "午休",
+	Afternoon:      "午盘",
+	ClosingAuction: "收盘竞价",
+	AfterClose:     "已收盘",
+}
+
+func (p Phase) String() string {
+	return phaseNames[p]
+}
+
+// Calendar 交易日历: 周末 + 休市日表
+type Calendar struct {
+	closed map[string]string // 日期 -> 节日名
+}
+
+// New 返回只识别周末的空日历
+func New() *Calendar {
+	return &Calendar{closed: make(map[string]string)}
+}
+
+// Parse 从节假日文件创建日历 (格式见 holidays.txt)
+func Parse(r io.Reader) (*Calendar, error) {
+	c := New()
+	if err := c.Load(r); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Load 追加节假日。每行 "2026-02-15" 或 "2026-02-15~2026-02-23"，# 之后为节日名/注释。
+func (c *Calendar) Load(r io.Reader) error {
+	sc := bufio.NewScanner(r)
+	lineNo := 0
+	for sc.Scan() {
+		lineNo++
+		line := sc.Text()
+		name := ""
+		if i := strings.Index(line, "#"); i >= 0 {
+			name = strings.TrimSpace(line[i+1:])
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		from, to := line, line
+		if i := strings.Index(line, "~"); i >= 0 {
+			from, to = strings.TrimSpace(line[:i]), strings.TrimSpace(line[i+1:])
+		}
+		start, err := time.Parse(dateLayout, from)
+		if err != nil {
+			return fmt.Errorf("holidays line %d: %w", lineNo, err)
+		}
+		end, err := time.Parse(dateLayout, to)
+		if err != nil {
+			return fmt.Errorf("holidays line %d: %w", lineNo, err)
+		}
+		if end.Before(start) {
+			return fmt.Errorf("holidays line %d: range end before start", lineNo)
+		}
+		for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+			c.closed[d.Format(dateLayout)] = name
+		}
+	}
+	return sc.Err()
+}
+
+// LoadFile 追加一个节假日文件
+func (c *Calendar) LoadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return c.Load(f)
+}
+
+// Holidays 返回已知的全部休市日 (升序，含落在周末的日期)
+func (c *Calendar) Holidays() []string {
+	var days []string
+	for d := range c.closed {
+		days = append(days, d)
+	}
+	sort.Strings(days)
+	return days
+}
+
+// IsTradingDay 是否交易日 (非周末且不在休市表中)
+func (c *Calendar) IsTradingDay(t time.Time) bool {
+	if wd := t.Weekday(); wd == time.Saturday || wd == time.Sunday {
+		return false
+	}
+	_, closed := c.closed[t.Format(dateLayout)]
+	return !closed
+}
+
+// HolidayName 返回休市日对应的节日名 (非节假日为空)
+func (c *Calendar) HolidayName(t time.Time) string {
+	return c.closed[t.Format(dateLayout)]
+}
+
+// PrevTradingDay 严格早于 t 所在日期的上一个交易日 (0 点)
+func (c *Calendar) PrevTradingDay(t time.Time) time.Time {
+	d := midnight(t).AddDate(0, 0, -1)
+	for !c.IsTradingDay(d) {
+		d = d.AddDate(0, 0, -1)
+	}
+	return d
+}
+
+// NextTradingDay 严格晚于 t 所在日期的下一个交易日 (0 点)
+func (c *Calendar) NextTradingDay(t time.Time) time.Time {
+	d := midnight(t).AddDate(0, 0, 1)
+	for !c.IsTradingDay(d) {
+		d = d.AddDate(0, 0, 1)
+	}
+	return d
+}
+
+// Phase 返回 t 所处的交易时段
+func (c *Calendar) Phase(t time.Time) Phase {
+	if !c.IsTradingDay(t) {
+		return Closed
+	}
+	clock := t.Sub(midnight(t))
+	switch {
+	case clock < OpeningAuctionStart:
+		return PreOpen
+	case clock < OpeningAuctionEnd:
+		return OpeningAuction
+	case clock < MorningOpen:
+		return OpeningPause
+	case clock < MorningClose:
+		return Morning
+	case clock < AfternoonOpen:
+		return LunchBreak
+	case clock < ClosingAuctionStart:
+		return Afternoon
+	case clock < MarketClose:
+		return ClosingAuction
+	}
+	return AfterClose
+}
+
+// InAuction 是否处于集合竞价 (9:15-9:25 或 14:57-15:00)
+func (c *Calendar) InAuction(t time.Time) bool {
+	p := c.Phase(t)
+	return p == OpeningAuction || p == ClosingAuction
+}
+
+// IsTrading 是否处于可成交时段 (连续竞价或集合竞价)
+func (c *Calendar) IsTrading(t time.Time) bool {
+	switch c.Phase(t) {
+	case OpeningAuction, Morning, Afternoon, ClosingAuction:
+		return true
+	}
+	return false
+}
+
+// LatestSession 返回 t 时刻已开盘的最近一个交易日 (0 点): 交易日 9:30 后为当天，否则为上一交易日
+func (c *Calendar) LatestSession(t time.Time) time.Time {
+	if c.IsTradingDay(t) && t.Sub(midnight(t)) >= MorningOpen {
+		return midnight(t)
+	}
+	return c.PrevTradingDay(t)
+}
+
+// LatestClosedSession 返回 t 时刻已收盘的最近一个交易日 (盘后数据如龙虎榜以此为准)
+func (c *Calendar) LatestClosedSession(t time.Time) time.Time {
+	if c.IsTradingDay(t) && t.Sub(midnight(t)) >= MarketClose {
+		return midnight(t)
+	}
+	return c.PrevTradingDay(t)
+}
+
+// SessionComplete 该交易日的行情是否已完整 (t 时刻已收盘)
+func (c *Calendar) SessionComplete(day, t time.Time) bool {
+	return !midnight(day).After(c.LatestClosedSession(t))
+}
+
+// RecentTradingDays 截至 t 的最近 n 个已开盘交易日 (升序)
+func (c *Calendar) RecentTradingDays(t time.Time, n int) []time.Time {
+	if n <= 0 {
+		return nil
+	}
+	days := make([]time.Time, n)
+	d := c.LatestSession(t)
+	for i := n - 1; i >= 0; i-- {
+		days[i] = d
+		d = c.PrevTradingDay(d)
+	}
+	return days
+}
+
+// TradingDaysBetween 返回 (from, to] 之间的交易日数 (按日期)
+func (c *Calendar) TradingDaysBetween(from, to time.Time) int {
+	n := 0
+	for d := midnight(from).AddDate(0, 0, 1); !d.After(midnight(to)); d = d.AddDate(0, 0, 1) {
+		if c.IsTradingDay(d) {
+			n++
+		}
+	}
+	return n
+}
+
+func midnight(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// Dates 把交易日列表格式化为 "2006-01-02"
+func Dates(days []time.Time) []string {
+	out := make([]string, len(days))
+	for i, d := range days {
+		out[i] = d.Format(dateLayout)
+	}
+	return out
+}
+
+// --- 默认日历 (内置节假日表) ---
+
+var (
+	defaultMu  sync.RWMutex
+	defaultCal *Calendar
+	beijing    = loadBeijing()
+)
+
+func loadBeijing() *time.Location {
+	if loc, err := time.LoadLocation("Asia/Shanghai"); err == nil {
+		return loc
+	}
+	return time.FixedZone("CST", 8*3600)
+}
+
+// Default 返回默认日历 (首次使用时加载内置 holidays.txt)
+func Default() *Calendar {
+	defaultMu.RLock()
+	c := defaultCal
+	defaultMu.RUnlock()
+	if c != nil {
+		return c
+	}
+
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	if defaultCal == nil {
+		cal, err := Parse(strings.NewReader(bundledHolidays))
+		if err != nil {
+			panic(fmt.Sprintf("bundled holidays.txt invalid: %v", err))
+		}
+		defaultCal = cal
+	}
+	return defaultCal
+}
+
+// SetDefault 替换默认日历 (如加载了额外的节假日文件)
+func SetDefault(c *Calendar) {
+	defaultMu.Lock()
+	defaultCal = c
+	defaultMu.Unlock()
+}
+
+// LoadDefault 在内置节假日表基础上追加 path (为空则只用内置表)，并设为默认日历
+func LoadDefault(path string) error {
+	c, err := Parse(strings.NewReader(bundledHolidays))
+	if err != nil {
+		return err
+	}
+	if path != "" {
+		if err := c.LoadFile(path); err != nil {
+			return fmt.Errorf("load holidays %s: %w", path, err)
+		}
+	}
+	SetDefault(c)
+	return nil
+}
+
+// Now 返回当前北京时间
+func Now() time.Time {
+	return time.Now().In(beijing)
+}
+
+// Today 返回今天 (北京时间) 的日期字符串
+func Today() string {
+	return Now().Format(dateLayout)
+}
+
+// Sleep 等待 d，ctx 取消时提前返回 ctx.Err()
+func Sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,104 @@
+package calendar
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func at(s string) time.Time {
+	t, err := time.Parse("2006-01-02 15:04", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestBundledHolidays(t *testing.T) {
+	c := Default()
+	if c.IsTradingDay(at("2026-02-16 10:00")) {
+		t.Error("2026-02-16 (春节) should be closed")
+	}
+	if name := c.HolidayName(at("2026-10-01 10:00")); name != "国庆节" {
+		t.Errorf("HolidayName = %q", name)
+	}
+	if !c.IsTradingDay(at("2026-01-09 10:00")) {
+		t.Error("2026-01-09 should be a trading day")
+	}
+	if c.IsTradingDay(at("2026-01-10 10:00")) {
+		t.Error("Saturday should be closed")
+	}
+}
+
+func TestPrevNextTradingDay(t *testing.T) {
+	c := Default()
+	// 春节前最后一个交易日 2026-02-13 (周五)，节后首个交易日 2026-02-24
+	if d := c.NextTradingDay(at("2026-02-13 15:00")).Format("2006-01-02"); d != "2026-02-24" {
+		t.Errorf("NextTradingDay = %s", d)
+	}
+	if d := c.PrevTradingDay(at("2026-02-24 09:00")).Format("2006-01-02"); d != "2026-02-13" {
+		t.Errorf("PrevTradingDay = %s", d)
+	}
+	// 元旦: 2026-01-05 (周一) 的上一交易日是 2025-12-31
+	if d := c.PrevTradingDay(at("2026-01-05 10:00")).Format("2006-01-02"); d != "2025-12-31" {
+		t.Errorf("PrevTradingDay = %s", d)
+	}
+}
+
+func TestPhase(t *testing.T) {
+	c := Default()
+	cases := map[string]Phase{
+		"2026-01-09 09:00": PreOpen,
+		"2026-01-09 09:15": OpeningAuction,
+		"2026-01-09 09:24": OpeningAuction,
+		"2026-01-09 09:27": OpeningPause,
+		"2026-01-09 10:00": Morning,
+		"2026-01-09 12:00": LunchBreak,
+		"2026-01-09 14:00": Afternoon,
+		"2026-01-09 14:58": ClosingAuction,
+		"2026-01-09 15:00": AfterClose,
+		"2026-01-10 10:00": Closed,
+	}
+	for s, want := range cases {
+		if got := c.Phase(at(s)); got != want {
+			t.Errorf("Phase(%s) = %s, want %s", s, got, want)
+		}
+	}
+	if !c.InAuction(at("2026-01-09 14:59")) || c.InAuction(at("2026-01-09 14:56")) {
+		t.Error("InAuction closing window wrong")
+	}
+}
+
+func TestSessions(t *testing.T) {
+	c := Default()
+	// 周一开盘前: 最近已开盘交易日是上周五
+	if d := c.LatestSession(at("2026-01-12 09:20")).Format("2006-01-02"); d != "2026-01-09" {
+		t.Errorf("LatestSession = %s", d)
+	}
+	if d := c.LatestClosedSession(at("2026-01-12 14:00")).Format("2006-01-02"); d != "2026-01-09" {
+		t.Errorf("LatestClosedSession = %s", d)
+	}
+	days := Dates(c.RecentTradingDays(at("2026-02-24 10:00"), 3))
+	if strings.Join(days, ",") != "2026-02-12,2026-02-13,2026-02-24" {
+		t.Errorf("RecentTradingDays = %v", days)
+	}
+	if n := c.TradingDaysBetween(at("2026-02-13 00:00"), at("2026-02-25 00:00")); n != 2 {
+		t.Errorf("TradingDaysBetween = %d, want 2", n)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	if _, err := Parse(strings.NewReader("2026-13-01\n")); err == nil {
+		t.Error("expected invalid date error")
+	}
+	if _, err := Parse(strings.NewReader("2026-02-10~2026-02-01\n")); err == nil {
+		t.Error("expected reversed range error")
+	}
+	c, err := Parse(strings.NewReader("# 临时休市\n2026-03-02 # 测试\n"))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if c.IsTradingDay(at("2026-03-02 10:00")) {
+		t.Error("custom holiday should be closed")
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"dragon-quant/ai_reviewer/mock_llm"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+)
+
+var addr = flag.String("addr", "127.0.0.1:8089", "Listen address")
+var rulesFile = flag.String("rules", "", "Optional JSON file with scripted rules ([{match, response, fault}])")
+var rateLimitEvery = flag.Int("429-every", 0, "Return 429 on every Nth request (0 = off)")
+var malformedEvery = flag.Int("malformed-every", 0, "Return malformed JSON on every Nth request (0 = off)")
+
+// 独立运行的 Mock LLM 服务:
+//
+//	go run ./cmd/mock_llm -addr 127.0.0.1:8089
+//
+// 然后在 config.yaml 中设置 deepseek.api_url: "http://127.0.0.1:8089/chat/completions"
+func main() {
+	flag.Parse()
+
+	opts := mock_llm.Options{
+		RateLimitEvery: *rateLimitEvery,
+		MalformedEvery: *malformedEvery,
+	}
+	if *rulesFile != "" {
+		data, err := os.ReadFile(*rulesFile)
+		if err != nil {
+			fmt.Printf("❌ 读取规则文件失败: %v\n", err)
+			os.Exit(1)
+		}
+		if err := json.Unmarshal(data, &opts.Rules); err != nil {
+			fmt.Printf("❌ 解析规则文件失败: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	srv := mock_llm.NewServer(opts)
+	url, err := srv.Start(*addr)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+	defer srv.Close()
+	fmt.Printf("🤖 Mock LLM 已启动: %s (%d 条脚本规则)\n", url, len(opts.Rules))
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt)
+	<-stop
+	fmt.Printf("\n👋 Mock LLM 退出, 共处理 %d 个请求。\n", srv.Requests())
+}
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"dragon-quant/model"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -10,15 +11,32 @@ import (
 )
 
 type Config struct {
-	DeepSeek   DeepSeekConfig `yaml:"deepseek"`
-	HoldStocks []string       `yaml:"hold_stocks"`
-	Output     OutputConfig   `yaml:"output"`
+	DeepSeek   DeepSeekConfig  `yaml:"deepseek"`
+	HoldStocks []HoldPosition  `yaml:"hold_stocks"`
+	Output     OutputConfig    `yaml:"output"`
+	Warehouse  WarehouseConfig `yaml:"warehouse"`
+	Calendar   CalendarConfig  `yaml:"calendar"`
+	LHB        LHBConfig       `yaml:"lhb"`
+	Auction    AuctionConfig   `yaml:"auction"`
+	Watch      WatchConfig     `yaml:"watch"`
+	Notify     NotifyConfig    `yaml:"notify"`
+	Schedule   ScheduleConfig  `yaml:"schedule"`
+	Run        RunConfig       `yaml:"run"`
+	HTTP       HTTPConfig      `yaml:"http"`
+	Validate   ValidateConfig  `yaml:"validate"`
+	Sources    SourcesConfig   `yaml:"sources"`
+	Import     ImportConfig    `yaml:"import"`
+
+	HoldReviewProse bool   `yaml:"hold_review_prose"` // 持仓点评额外输出游资口吻长文
+	HoldingsFile    string `yaml:"holdings_file"`     // 券商对账单 (CSV/XLS)，导入后合并进 hold_stocks
 
 	StartTime  time.Time
 	StartTsStr string
+	outputRoot string // output.path 原值 (Output.Path 会被追加日期目录)
 
 	// for analysis special
-	HoldKlineReportFile string
+	HoldKlineReportFile  string
+	HoldKlinePartialFile string
 
 	// for analysis all
 	JsonFile              string
@@ -29,16 +47,192 @@ type Config struct {
 	ReportTop3FileHTML    string
 	ReportTop1FileHTML    string
 	ReportWinnersFileHTML string
+	AIPartialFile         string
 }
 
 type DeepSeekConfig struct {
 	APIKey string `yaml:"api_key"`
+	APIURL string `yaml:"api_url"` // 可选: 指向兼容 OpenAI 的服务 (如本地 Mock)
+	Stream bool   `yaml:"stream"`  // 流式输出 + 终端实时进度
 }
 
 type OutputConfig struct {
 	Path string `yaml:"path"`
 }
 
+// WarehouseConfig 持久化行情仓库 (DuckDB 文件)。Path 为空则不落盘，每次全量拉取。
+type WarehouseConfig struct {
+	Path string     `yaml:"path"`
+	Sync SyncConfig `yaml:"sync"`
+}
+
+// CalendarConfig 交易日历。内置沪深休市表，HolidaysFile 可追加 (如新一年的安排或临时休市)。
+type CalendarConfig struct {
+	HolidaysFile string `yaml:"holidays_file"`
+}
+
+// LHBConfig 龙虎榜回看天数与席位字典 (内置 fetcher/seats.txt，SeatsFile 追加且优先)
+type LHBConfig struct {
+	LookbackDays int    `yaml:"lookback_days"`
+	SeatsFile    string `yaml:"seats_file"`
+}
+
+// AuctionConfig -auction 集合竞价采集 (候选股 = Codes + 持仓 + 上一轮扫描的候选股)
+type AuctionConfig struct {
+	Codes       []string `yaml:"codes"`
+	IntervalSec int      `yaml:"interval_sec"` // 轮询间隔，默认 10 秒
+}
+
+// WatchConfig -watch 盘中盯盘 (对象 = 上一轮 FinalPool + 持仓)
+type WatchConfig struct {
+	IntervalSec int     `yaml:"interval_sec"` // 轮询间隔，默认 30 秒
+	VolSpike    float64 `yaml:"vol_spike"`    // 1m 放量倍数，默认 5
+}
+
+// ScheduleConfig -schedule 定时任务 (非交易日自动跳过，同一时间只跑一个任务)
+type ScheduleConfig struct {
+	Jobs []ScheduleJob `yaml:"jobs"`
+}
+
+// ScheduleJob 一条定时任务。Cron 为 "分 时 日 月 周" 五段 (北京时间)，支持 * , - /
+type ScheduleJob struct {
+	Name           string `yaml:"name"`
+	Cron           string `yaml:"cron"`
+	Task           string `yaml:"task"`             // scan / hold-kline / auction / sync / lhb-backfill
+	WaitLHB        bool   `yaml:"wait_lhb"`         // 等当日龙虎榜公布后再跑
+	LHBWaitMinutes int    `yaml:"lhb_wait_minutes"` // 最多等待分钟数，默认 180
+}
+
+// RunConfig 单次运行的时间限制。超过 DeadlineMinutes 整体取消 (同 Ctrl-C，仍输出部分报告)；
+// StageBudgets 为各阶段 (sectors / candidates / leaders / review / final) 的秒数预算
+type RunConfig struct {
+	DeadlineMinutes int            `yaml:"deadline_minutes"`
+	StageBudgets    map[string]int `yaml:"stage_budgets"`
+}
+
+// Deadline 整体截止时长，0 表示不限
+func (r RunConfig) Deadline() time.Duration {
+	return time.Duration(r.DeadlineMinutes) * time.Minute
+}
+
+// Budgets 各阶段预算
+func (r RunConfig) Budgets() map[string]time.Duration {
+	budgets := make(map[string]time.Duration)
+	for stage, sec := range r.StageBudgets {
+		if sec > 0 {
+			budgets[stage] = time.Duration(sec) * time.Second
+		}
+	}
+	return budgets
+}
+
+// HTTPConfig 行情接口的共享 HTTP 层 (按 host 限速 + 重试)。不填的字段使用 fetcher 内置默认值
+type HTTPConfig struct {
+	QPS             float64 `yaml:"qps"`                // 每个 host 每秒请求数
+	Burst           int     `yaml:"burst"`              // 瞬时并发
+	MaxConnsPerHost int     `yaml:"max_conns_per_host"` // keep-alive 连接池大小
+	Retries         *int    `yaml:"retries"`            // 瞬时错误重试次数 (不填 = 默认 2，0 = 不重试)
+	BackoffMs       int     `yaml:"backoff_ms"`         // 首次重试等待，之后翻倍
+	UserAgent       string  `yaml:"user_agent"`
+	Referer         string  `yaml:"referer"`
+}
+
+// ValidateConfig 行情字段校验 (schema 漂移检测)
+type ValidateConfig struct {
+	DriftRatio float64 `yaml:"drift_ratio"` // 同一字段异常行占比达到此值判定为漂移，默认 0.3
+	SampleDir  string  `yaml:"sample_dir"`  // 漂移时保存原始报文样本的目录，空则只打印
+}
+
+// SourcesConfig 行情数据源顺序 (失败时依次切换) 与 -crosscheck 容差
+type SourcesConfig struct {
+	Order        []string `yaml:"order"`          // eastmoney / tencent / sina / local，默认 eastmoney, tencent, sina
+	PriceTolPct  float64  `yaml:"price_tol_pct"`  // 各源现价 / 收盘价差异超过此百分比视为不一致，默认 0.5
+	VolumeTolPct float64  `yaml:"volume_tol_pct"` // 成交量差异，默认 5
+}
+
+// ImportConfig -import 导入本地 CSV / Parquet K 线的默认参数
+type ImportConfig struct {
+	Timezone    string            `yaml:"timezone"`     // 源数据时区，默认 Asia/Shanghai
+	Adjust      string            `yaml:"adjust"`       // none / qfq / hfq，默认 none
+	Timeframe   string            `yaml:"timeframe"`    // 1d / 30m / 5m / 1m，空则按时间间隔推断
+	Code        string            `yaml:"code"`         // 文件无代码列时使用，空则取文件名中的 6 位数字
+	StartLabel  bool              `yaml:"start_label"`  // 分钟K线按开始时间标注
+	Columns     map[string]string `yaml:"columns"`      // 字段 -> 表头 (code/time/open/high/low/close/volume/amount)
+	VolumeScale float64           `yaml:"volume_scale"` // 成交量换算为手的倍数，源为股时填 0.01
+	AmountScale float64           `yaml:"amount_scale"` // 成交额换算为元的倍数，千元填 1000
+}
+
+// NotifyConfig 预警/报告推送渠道
+type NotifyConfig struct {
+	Channels []NotifyChannel `yaml:"channels"`
+}
+
+// NotifyChannel 单个推送渠道。Type: webhook / wecom / dingtalk / feishu / email / file
+type NotifyChannel struct {
+	Type         string   `yaml:"type"`
+	URL          string   `yaml:"url"`           // webhook / 机器人地址
+	Secret       string   `yaml:"secret"`        // 钉钉/飞书加签密钥 (可选)
+	Template     string   `yaml:"template"`      // text/template 正文模板，字段: .Kind .Title .Body .Code .Time .Alert
+	DedupMinutes int      `yaml:"dedup_minutes"` // 去重窗口 (分钟)，0 不去重
+	Kinds        []string `yaml:"kinds"`         // 订阅的消息类型 alert / report，空 = 全部
+
+	// email
+	SMTPHost string   `yaml:"smtp_host"`
+	SMTPPort int      `yaml:"smtp_port"`
+	Username string   `yaml:"username"`
+	Password string   `yaml:"password"`
+	From     string   `yaml:"from"`
+	To       []string `yaml:"to"`
+
+	// file
+	Path string `yaml:"path"`
+}
+
+// SyncConfig -sync 的同步范围 (持仓股自动加入自选)
+type SyncConfig struct {
+	Codes      []string `yaml:"codes"`       // 自选股代码
+	Sectors    []string `yaml:"sectors"`     // 板块代码 (BKxxxx)，同步全部成分股
+	Timeframes []string `yaml:"timeframes"`  // 1d / 30m / 5m / 1m，默认 1d/30m/1m
+	Days       int      `yaml:"days"`        // 分钟级回看交易日数，默认 5
+	DailyLimit int      `yaml:"daily_limit"` // 日K回看交易日数，默认 120
+}
+
+// HoldPosition 一条持仓。兼容旧写法: 纯字符串视为名称 (无成本/数量)。
+type HoldPosition struct {
+	Code      string  `yaml:"code"`
+	Name      string  `yaml:"name"`
+	Cost      float64 `yaml:"cost"`       // 成本价
+	Shares    int     `yaml:"shares"`     // 持股数量
+	EntryDate string  `yaml:"entry_date"` // 建仓日期 (2006-01-02)
+	Sellable  *int    `yaml:"sellable"`   // T+1 可卖数量 (券商导出提供时使用；否则按成交流水扣除当日买入，没有流水时按建仓日估算)
+
+	// 来自券商对账单导入 (见 broker_importer)
+	RealizedPnL float64       `yaml:"-"`
+	Trades      []model.Trade `yaml:"-"`
+}
+
+func (h *HoldPosition) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		h.Name = value.Value
+		return nil
+	}
+	type plain HoldPosition
+	return value.Decode((*plain)(h))
+}
+
+// Keyword 返回用于 SearchStock 的关键字 (优先代码)
+func (h HoldPosition) Keyword() string {
+	if h.Code != "" {
+		return h.Code
+	}
+	return h.Name
+}
+
+// HasCost 是否配置了成本与数量 (决定是否做盈亏分析)
+func (h HoldPosition) HasCost() bool {
+	return h.Cost > 0 && h.Shares > 0
+}
+
 func InitOutputPath(outputPath string) error {
 	// 1. 清理路径
 	cleanPath := filepath.Clean(outputPath)
@@ -79,22 +273,40 @@ func LoadConfig() (*Config, error) {
 	}
 
 	// init output path
-	if cfg.Output.Path == "" {
-		cfg.Output.Path = filepath.Join(".", "output", time.Now().Format("2006-01-02"))
+	cfg.outputRoot = cfg.Output.Path
+	if err := cfg.NewRun(time.Now()); err != nil {
+		return &cfg, err
+	}
+
+	return &cfg, nil
+}
 
-	} else {
-		cfg.Output.Path = filepath.Join(cfg.Output.Path, time.Now().Format("2006-01-02"))
+// RunDir 某次运行的断点目录 (<output.path>/runs/<RUN_ID>)，不随日期目录变化以便跨日续跑
+func (cfg *Config) RunDir(runID string) string {
+	root := cfg.outputRoot
+	if root == "" {
+		root = filepath.Join(".", "output")
 	}
-	err = InitOutputPath(cfg.Output.Path)
-	if err != nil {
-		return &cfg, err
+	return filepath.Join(root, "runs", runID)
+}
+
+// NewRun 按 now 重新生成本次运行的输出目录与文件名 (定时任务每次运行前调用，避免覆盖上一轮报告)
+func (cfg *Config) NewRun(now time.Time) error {
+	root := cfg.outputRoot
+	if root == "" {
+		root = filepath.Join(".", "output")
+	}
+	cfg.Output.Path = filepath.Join(root, now.Format("2006-01-02"))
+	if err := InitOutputPath(cfg.Output.Path); err != nil {
+		return err
 	}
 
 	// init file name
-	cfg.StartTime = time.Now()
+	cfg.StartTime = now
 	cfg.StartTsStr = cfg.StartTime.Format("2006-01-02T15-04-05")
 	// for special
 	cfg.HoldKlineReportFile = filepath.Join(cfg.Output.Path, fmt.Sprintf("Hold_Kline_Report_%s.html", cfg.StartTsStr))
+	cfg.HoldKlinePartialFile = filepath.Join(cfg.Output.Path, fmt.Sprintf("Hold_Kline_Partial_%s.json", cfg.StartTsStr))
 	// for all
 	cfg.JsonFile = filepath.Join(cfg.Output.Path, fmt.Sprintf("AI_Dragon_%s.json", cfg.StartTsStr))
 	cfg.DragonReportFile = filepath.Join(cfg.Output.Path, fmt.Sprintf("DragonReport_%s.html", cfg.StartTsStr))
@@ -104,6 +316,11 @@ func LoadConfig() (*Config, error) {
 	cfg.ReportTop3FileHTML = filepath.Join(cfg.Output.Path, fmt.Sprintf("DeepSeek_Fox_Top3_Report_%s.html", cfg.StartTsStr))
 	cfg.ReportTop1FileHTML = filepath.Join(cfg.Output.Path, fmt.Sprintf("DeepSeek_Fox_Top1_Report_%s.html", cfg.StartTsStr))
 	cfg.ReportWinnersFileHTML = filepath.Join(cfg.Output.Path, fmt.Sprintf("DeepSeek_Fox_Winners_Report_%s.html", cfg.StartTsStr))
+	cfg.AIPartialFile = cfg.AIPartialFileFor(cfg.StartTsStr)
+	return nil
+}
 
-	return &cfg, nil
+// AIPartialFileFor 某次运行的 AI 阶段性结果文件。按运行 ID 命名: 续跑沿用原运行的文件名，同一进程内的多次运行互不覆盖
+func (cfg *Config) AIPartialFileFor(runID string) string {
+	return filepath.Join(cfg.Output.Path, fmt.Sprintf("DeepSeek_Fox_Partial_%s.json", runID))
 }
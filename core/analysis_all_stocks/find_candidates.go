@@ -1,10 +1,11 @@
 package core
 
 import (
+	"context"
 	"dragon-quant/config"
 	"dragon-quant/data_processor"
-	"dragon-quant/fetcher"
 	"dragon-quant/model"
+	"dragon-quant/warehouse"
 	"fmt"
 	"sync"
 )
@@ -13,7 +14,7 @@ type FindCandidatesResult struct {
 	Candidates map[string]*model.StockInfo
 }
 
-func FindCandidates(cfg *config.Config, scanHotPointSectorsResult ScanHotPointSectorsResult) FindCandidatesResult {
+func FindCandidates(ctx context.Context, cfg *config.Config, wh *warehouse.Warehouse, scanHotPointSectorsResult ScanHotPointSectorsResult) FindCandidatesResult {
 	fmt.Println("🚀 [Step 2] 启动竞价资金初筛 (Price/Flow/CallAuction)...")
 
 	candidates := make(map[string]*model.StockInfo)
@@ -21,11 +22,18 @@ func FindCandidates(cfg *config.Config, scanHotPointSectorsResult ScanHotPointSe
 	var wg sync.WaitGroup
 
 	for _, sec := range scanHotPointSectorsResult.AllSectors {
+		if ctx.Err() != nil {
+			break
+		}
 		wg.Add(1)
 		go func(s model.SectorInfo) {
 			defer wg.Done()
 			// 🔥 f19:开盘金额(竞价), f62:净流入, f7:振幅
-			stocks := fetcher.FetchSectorStocks(s.Code)
+			stocks, err := wh.SectorStocks(ctx, s)
+			if err != nil {
+				fmt.Printf("   ⚠️ [%s] 成分股拉取失败: %v\n", s.Name, err)
+				return
+			}
 
 			for _, stk := range stocks {
 				// Use the FilterBasic function
@@ -1,6 +1,7 @@
 package core
 
 import (
+	"context"
 	"dragon-quant/ai_reviewer/deepseek_reviewer"
 	"dragon-quant/config"
 	"dragon-quant/data_processor"
@@ -17,53 +18,140 @@ type FindWinnersResult struct {
 	Top3MdBuffer         strings.Builder
 	Top1MdBuffer         strings.Builder
 	WinnersMdBuffer      strings.Builder
+	Picks                []*deepseek_reviewer.SniperJSON // 🆕 各板块王者 (含止损/止盈，供盘中盯盘)
 }
 
-var findWinnersResult FindWinnersResult
+// SectorReviewResult Step 5-6 的产出 (风控 + 30m 精选 + 老狐狸板块王者)，可序列化用于断点续跑
+type SectorReviewResult struct {
+	RiskResults    []model.RiskResult              `json:"risk_results"`
+	SectorStatusMd string                          `json:"sector_status_md"`
+	Top3Md         string                          `json:"top3_md"`
+	Top1Md         string                          `json:"top1_md"`
+	Picks          []*deepseek_reviewer.SniperJSON `json:"picks"`
+	Finalists      []*model.StockInfo              `json:"finalists"` // 入围总决赛的板块王者
+	MarketContext  string                          `json:"market_context"`
+	Reviewed       bool                            `json:"reviewed"` // 是否经过 AI 复审 (未配置 Key 时跳过)
+}
+
+// GrandFinalResult Step 7 总决赛
+type GrandFinalResult struct {
+	WinnersMd string `json:"winners_md"`
+}
 
-func FindWinners(cfg *config.Config,
+// Result 合并两段产出为报告用的 FindWinnersResult
+func (r SectorReviewResult) Result(final GrandFinalResult) FindWinnersResult {
+	var res FindWinnersResult
+	res.RiskResults = r.RiskResults
+	res.Picks = r.Picks
+	res.SectorStatusMdBuffer.WriteString(r.SectorStatusMd)
+	res.Top3MdBuffer.WriteString(r.Top3Md)
+	res.Top1MdBuffer.WriteString(r.Top1Md)
+	res.WinnersMdBuffer.WriteString(final.WinnersMd)
+	return res
+}
+
+func FindWinners(ctx context.Context, cfg *config.Config,
 	scanHotPointSectorsResult ScanHotPointSectorsResult,
 	inferStockLeadersResult InferStockLeadersResult) FindWinnersResult {
 
-	sectorStocks := getStocksGroupBySector(inferStockLeadersResult)
+	review := ReviewSectors(ctx, cfg, scanHotPointSectorsResult, inferStockLeadersResult)
+	final, err := GrandFinal(ctx, cfg, review)
+	if err != nil {
+		fmt.Printf("❌ [Step 7] %v\n", err)
+	}
+	return review.Result(final)
+}
+
+// ReviewSectors Step 5-6: 二次风控 + 30m 结构精选 + 老狐狸板块复审
+func ReviewSectors(ctx context.Context, cfg *config.Config,
+	scanHotPointSectorsResult ScanHotPointSectorsResult,
+	inferStockLeadersResult InferStockLeadersResult) SectorReviewResult {
+
+	sectorStocks, riskResults := getStocksGroupBySector(inferStockLeadersResult)
+	review := SectorReviewResult{RiskResults: riskResults}
 
 	apiKey := cfg.DeepSeek.APIKey
 	if apiKey != "" {
 		fmt.Println("\n🧠 [Step 6] 呼叫 DeepSeek 老狐狸 (全量审视)...")
 
 		if len(sectorStocks) > 0 {
-			reviewer := deepseek_reviewer.NewReviewer(apiKey)
+			reviewer := newReviewer(cfg)
 
 			// 🆕 Fetch Market Context (Global)
 			fmt.Println("🌡️ [Step 6.0] 获取大盘 (000001) 7日30分钟走势作为全局背景...")
-			marketContext := fetcher.FetchMarket30mKline(7)
-			if marketContext == "" {
-				fmt.Println("⚠️ [Step 6.0] 获取大盘数据失败或为空！(AI 将缺失全局视野)")
+			marketContext, err := fetcher.FetchMarket30mKline(ctx, 7)
+			if err != nil {
+				fmt.Printf("⚠️ [Step 6.0] 获取大盘数据失败: %v (AI 将缺失全局视野)\n", err)
 			} else {
 				fmt.Printf("✅ [Step 6.0] 大盘数据获取成功 (长度: %d chars)\n", len(marketContext))
 			}
 
 			// Generate Markdown Report Base
-			initSectorStatus(cfg, scanHotPointSectorsResult)
-			foxInput := findTop3ForEachSector(cfg, reviewer, sectorStocks)
-			sectorResults := findWinnerForEachSector(cfg, reviewer, foxInput, marketContext)
-			findTheUltimateWinners(cfg, reviewer, sectorResults, foxInput, marketContext)
+			review.SectorStatusMd = initSectorStatus(cfg, scanHotPointSectorsResult)
+			foxInput, top3Md := findTop3ForEachSector(ctx, cfg, reviewer, sectorStocks)
+			sectorResults, top1Md, picks := findWinnerForEachSector(ctx, cfg, reviewer, foxInput, marketContext)
+
+			review.Reviewed = true
+			review.MarketContext = marketContext
+			review.Top3Md = top3Md
+			review.Top1Md = top1Md
+			review.Picks = picks
+			review.Finalists = collectFinalists(sectorResults, foxInput)
 		}
 	} else {
 		fmt.Println("\n⚠️ [Step 6] 未配置 DEEPSEEK_API_KEY，跳过 AI 点评。")
 	}
 
-	return findWinnersResult
+	return review
 }
 
-func getStocksGroupBySector(inferStockLeadersResult InferStockLeadersResult) map[string][]*model.StockInfo {
+// GrandFinal Step 7: 各板块王者的总决赛。AI 调用失败时返回错误，可从 final 阶段续跑。
+func GrandFinal(ctx context.Context, cfg *config.Config, review SectorReviewResult) (GrandFinalResult, error) {
+	if !review.Reviewed {
+		return GrandFinalResult{}, nil
+	}
+	return findTheUltimateWinners(ctx, cfg, newReviewer(cfg), review.Finalists, review.MarketContext)
+}
+
+func newReviewer(cfg *config.Config) *deepseek_reviewer.Reviewer {
+	reviewer := deepseek_reviewer.NewReviewer(cfg.DeepSeek.APIKey, cfg.DeepSeek.APIURL)
+	reviewer.Stream = cfg.DeepSeek.Stream
+	reviewer.PartialFile = cfg.AIPartialFile
+	return reviewer
+}
+
+// collectFinalists 板块王者对应的个股 (入围总决赛)
+func collectFinalists(sectorResults map[string]*deepseek_reviewer.SectorResult,
+	foxInput map[string][]*model.StockInfo) []*model.StockInfo {
+	var sectors []string
+	for name := range sectorResults {
+		sectors = append(sectors, name)
+	}
+	sort.Strings(sectors)
+
+	var finalists []*model.StockInfo
+	for _, name := range sectors {
+		r := sectorResults[name]
+		if r.FinalPick == nil {
+			continue
+		}
+		for _, s := range foxInput[r.SectorName] {
+			if s.Code == r.FinalPick.StockCode {
+				finalists = append(finalists, s)
+				break
+			}
+		}
+	}
+	return finalists
+}
+
+// getStocksGroupBySector 二次风控后按板块分组
+func getStocksGroupBySector(inferStockLeadersResult InferStockLeadersResult) (map[string][]*model.StockInfo, []model.RiskResult) {
 	// --- Step 5: 二次风控筛选 (老狐狸逻辑) ---
 	fmt.Println("\n🦊 [Step 5] 启动老狐狸二次风控筛选...")
 	riskConfig := data_processor.NewRiskConfig()
 	riskResults := data_processor.RiskScreen(inferStockLeadersResult.FinalPool, riskConfig)
 
-	findWinnersResult.RiskResults = riskResults
-
 	// 准备全量数据 - Group by Sector
 	sectorStocks := make(map[string][]*model.StockInfo)
 	for _, r := range riskResults {
@@ -74,11 +162,12 @@ func getStocksGroupBySector(inferStockLeadersResult InferStockLeadersResult) map
 		}
 		sectorStocks[sector] = append(sectorStocks[sector], r.Stock)
 	}
-	return sectorStocks
+	return sectorStocks, riskResults
 }
 
+// initSectorStatus 报告开头: 各板块主力意图
 func initSectorStatus(cfg *config.Config,
-	scanHotPointSectorsResult ScanHotPointSectorsResult) {
+	scanHotPointSectorsResult ScanHotPointSectorsResult) string {
 	var mdBuffer strings.Builder
 	mdBuffer.WriteString("# 🦊 DeepSeek 老狐狸板块博弈报告\n")
 	mdBuffer.WriteString(fmt.Sprintf("**生成时间**: %s\n\n", cfg.StartTsStr))
@@ -125,17 +214,18 @@ func initSectorStatus(cfg *config.Config,
 		mdBuffer.WriteString("---\n")
 	}
 
-	findWinnersResult.SectorStatusMdBuffer = mdBuffer
+	return mdBuffer.String()
 }
 
-func findTop3ForEachSector(cfg *config.Config,
+// findTop3ForEachSector 30m 结构精选，返回进入老狐狸复审的个股与报告片段
+func findTop3ForEachSector(ctx context.Context, cfg *config.Config,
 	reviewer *deepseek_reviewer.Reviewer,
-	sectorStocks map[string][]*model.StockInfo) map[string][]*model.StockInfo {
+	sectorStocks map[string][]*model.StockInfo) (map[string][]*model.StockInfo, string) {
 	var mdBuffer strings.Builder
 
 	// 🆕 Step 6.1: 30分钟结构 AI 专项审视 (Pre-Filter)
 	fmt.Println("\n🧠 [Step 6.1] 启动 30分钟结构大师 (筛选 Top 3)...")
-	res30m := reviewer.ReviewBySector30m(sectorStocks)
+	res30m := reviewer.ReviewBySector30m(ctx, sectorStocks)
 
 	// Filtered stocks for Old Fox (Only Top 3 from 30m)
 	foxInput := make(map[string][]*model.StockInfo)
@@ -185,19 +275,20 @@ func findTop3ForEachSector(cfg *config.Config,
 		fmt.Println("✅ 30分钟结构分析完成，MD已暂存。")
 	}
 
-	findWinnersResult.Top3MdBuffer = mdBuffer
-	return foxInput
+	return foxInput, mdBuffer.String()
 }
 
-func findWinnerForEachSector(cfg *config.Config,
+// findWinnerForEachSector 老狐狸板块复审，返回各板块结果、报告片段与板块王者
+func findWinnerForEachSector(ctx context.Context, cfg *config.Config,
 	reviewer *deepseek_reviewer.Reviewer,
 	foxInput map[string][]*model.StockInfo,
-	marketContext string) map[string]*deepseek_reviewer.SectorResult {
+	marketContext string) (map[string]*deepseek_reviewer.SectorResult, string, []*deepseek_reviewer.SniperJSON) {
 	var mdBuffer strings.Builder
+	var picks []*deepseek_reviewer.SniperJSON
 
 	// 🆕 Step 6.2: Old Fox Review (Only on 30m Top 3)
 	fmt.Printf("\n🦊 [Step 6.2] 老狐狸博弈复审 (入围 %d 个板块)...\n", len(foxInput))
-	sectorResults := reviewer.ReviewBySector(foxInput, marketContext)
+	sectorResults := reviewer.ReviewBySector(ctx, foxInput, marketContext)
 
 	mdBuffer.WriteString("\n# 🦊 老狐狸复审 & 板块王者 Top1\n")
 
@@ -224,6 +315,7 @@ func findWinnerForEachSector(cfg *config.Config,
 		mdBuffer.WriteString("\n### 👑 板块王者\n")
 		if res.FinalPick != nil {
 			fp := res.FinalPick
+			picks = append(picks, fp)
 			mdBuffer.WriteString(fmt.Sprintf("#### 🎯 唯一指定标的：【%s / %s】\n\n", fp.StockName, fp.StockCode))
 			mdBuffer.WriteString(fmt.Sprintf("**A. 嗜血逻辑**\n> %s\n\n", fp.Reason))
 			mdBuffer.WriteString(fmt.Sprintf("**🔥 量化王牌**: `%s`\n\n", fp.KeyMetric))
@@ -238,62 +330,45 @@ func findWinnerForEachSector(cfg *config.Config,
 		mdBuffer.WriteString("---\n")
 	}
 
-	findWinnersResult.Top1MdBuffer = mdBuffer
-
-	return sectorResults
+	return sectorResults, mdBuffer.String(), picks
 }
 
-func findTheUltimateWinners(cfg *config.Config,
+func findTheUltimateWinners(ctx context.Context, cfg *config.Config,
 	reviewer *deepseek_reviewer.Reviewer,
-	sectorResults map[string]*deepseek_reviewer.SectorResult,
-	foxInput map[string][]*model.StockInfo,
-	marketContext string) {
+	grandCandidates []*model.StockInfo,
+	marketContext string) (GrandFinalResult, error) {
 	var mdBuffer strings.Builder
 
 	// --- Step 7: Grand Final (Top 5) ---
 	fmt.Println("\n🏆 [Step 7] 启动总决赛 (Top 5 巅峰对决)...")
-	// ... (Rest of Step 7 remains, but using sectorResults which is filtered)
-
-	// 1. Collect Candidates
-	var grandCandidates []*model.StockInfo
-	for _, r := range sectorResults {
-		if r.FinalPick != nil {
-			for _, s := range foxInput[r.SectorName] {
-				if s.Code == r.FinalPick.StockCode {
-					grandCandidates = append(grandCandidates, s)
-					break
-				}
-			}
-		}
-	}
 
-	// ... (Grand Final Logic)
 	if len(grandCandidates) > 0 {
-		gfRes := reviewer.ReviewGrandFinals(grandCandidates, marketContext)
-		if gfRes != nil {
-			mdBuffer.WriteString("\n\n# 🏆 总决赛：五虎上将 (Grand Final Top 5)\n")
-			mdBuffer.WriteString(fmt.Sprintf("> **市场情绪**: %s\n\n", gfRes.MarketSentiment))
-
-			for _, t := range gfRes.Top5 {
-				icon := "🎖️"
-				if t.Rank == 1 {
-					icon = "👑 榜首 (The King)"
-				}
-				if t.Rank == 2 || t.Rank == 3 {
-					icon = "🛡️ 中军 (General)"
-				}
-				if t.Rank == 4 || t.Rank == 5 {
-					icon = "⚔️ 前锋 (Vanguard)"
-				}
+		gfRes := reviewer.ReviewGrandFinals(ctx, grandCandidates, marketContext)
+		if gfRes == nil {
+			return GrandFinalResult{}, fmt.Errorf("总决赛 AI 调用失败 (%d 位入围选手)", len(grandCandidates))
+		}
+		mdBuffer.WriteString("\n\n# 🏆 总决赛：五虎上将 (Grand Final Top 5)\n")
+		mdBuffer.WriteString(fmt.Sprintf("> **市场情绪**: %s\n\n", gfRes.MarketSentiment))
 
-				mdBuffer.WriteString(fmt.Sprintf("### %s: %s (%s)\n", icon, t.StockName, t.StockCode))
-				mdBuffer.WriteString(fmt.Sprintf("> %s\n\n", t.Reason))
+		for _, t := range gfRes.Top5 {
+			icon := "🎖️"
+			if t.Rank == 1 {
+				icon = "👑 榜首 (The King)"
+			}
+			if t.Rank == 2 || t.Rank == 3 {
+				icon = "🛡️ 中军 (General)"
 			}
+			if t.Rank == 4 || t.Rank == 5 {
+				icon = "⚔️ 前锋 (Vanguard)"
+			}
+
+			mdBuffer.WriteString(fmt.Sprintf("### %s: %s (%s)\n", icon, t.StockName, t.StockCode))
+			mdBuffer.WriteString(fmt.Sprintf("> %s\n\n", t.Reason))
 		}
 	} else {
 		fmt.Println("🤷‍♂️ 没有产生任何板块龙头，取消总决赛。")
 		mdBuffer.WriteString("\n\n# 🤷‍♂️ 总决赛取消\n> 原因: 没有产生任何符合条件的板块龙头。")
 	}
 
-	findWinnersResult.WinnersMdBuffer = mdBuffer
+	return GrandFinalResult{WinnersMd: mdBuffer.String()}, nil
 }
@@ -0,0 +1,88 @@
+package core
+
+import (
+	"context"
+	"dragon-quant/ai_reviewer/mock_llm"
+	"dragon-quant/config"
+	"dragon-quant/model"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// TestFindWinnersWithMockLLM 跑通 30m -> 老狐狸 -> 总决赛 全流程 (无需 API Key)
+func TestFindWinnersWithMockLLM(t *testing.T) {
+	srv := mock_llm.NewServer(mock_llm.Options{})
+	url, err := srv.Start("")
+	if err != nil {
+		t.Fatalf("Start mock failed: %v", err)
+	}
+	defer srv.Close()
+
+	cfg := &config.Config{
+		DeepSeek:   config.DeepSeekConfig{APIKey: "mock", APIURL: url},
+		StartTsStr: "2026-01-01T09-26-00",
+	}
+
+	pool := []*model.StockInfo{
+		{Code: "600001", Name: "甲股份", Tags: []string{"半导体"}, NetInflow: 2e8, NetInflow5Day: 1e8, VolRatio: 1.5, KLine30mStr: "[Bar-1: C=10.00, R=0.00%, V=100]"},
+		{Code: "000002", Name: "乙科技", Tags: []string{"半导体"}, NetInflow: 3e8, NetInflow5Day: 1e8, VolRatio: 1.5, KLine30mStr: "[Bar-1: C=20.00, R=1.00%, V=200]"},
+	}
+
+	res := FindWinners(context.Background(), cfg, ScanHotPointSectorsResult{}, InferStockLeadersResult{FinalPool: pool})
+
+	if len(res.RiskResults) != 2 {
+		t.Fatalf("Expected 2 risk results, got %d", len(res.RiskResults))
+	}
+	if !strings.Contains(res.Top3MdBuffer.String(), "甲股份") {
+		t.Errorf("Top3 report missing stock:\n%s", res.Top3MdBuffer.String())
+	}
+	if !strings.Contains(res.Top1MdBuffer.String(), "唯一指定标的") {
+		t.Errorf("Top1 report missing final pick:\n%s", res.Top1MdBuffer.String())
+	}
+	if !strings.Contains(res.WinnersMdBuffer.String(), "五虎上将") {
+		t.Errorf("Winners report missing grand final:\n%s", res.WinnersMdBuffer.String())
+	}
+}
+
+// TestFindWinnersReentrant 同一进程内并发/重复运行互不串台
+func TestFindWinnersReentrant(t *testing.T) {
+	srv := mock_llm.NewServer(mock_llm.Options{})
+	url, err := srv.Start("")
+	if err != nil {
+		t.Fatalf("Start mock failed: %v", err)
+	}
+	defer srv.Close()
+
+	cfg := &config.Config{
+		DeepSeek:   config.DeepSeekConfig{APIKey: "mock", APIURL: url},
+		StartTsStr: "2026-01-01T09-26-00",
+	}
+	pools := [][]*model.StockInfo{
+		{{Code: "600001", Name: "甲股份", Tags: []string{"半导体"}, NetInflow: 2e8, NetInflow5Day: 1e8, VolRatio: 1.5, KLine30mStr: "[Bar-1: C=10.00, R=0.00%, V=100]"}},
+		{{Code: "000002", Name: "乙科技", Tags: []string{"军工"}, NetInflow: 3e8, NetInflow5Day: 1e8, VolRatio: 1.5, KLine30mStr: "[Bar-1: C=20.00, R=1.00%, V=200]"}},
+	}
+
+	for round := 0; round < 2; round++ {
+		results := make([]FindWinnersResult, len(pools))
+		var wg sync.WaitGroup
+		for i := range pools {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				results[i] = FindWinners(context.Background(), cfg, ScanHotPointSectorsResult{}, InferStockLeadersResult{FinalPool: pools[i]})
+			}(i)
+		}
+		wg.Wait()
+
+		for i, res := range results {
+			own, other := pools[i][0], pools[1-i][0]
+			if len(res.Picks) != 1 || res.Picks[0].StockCode != own.Code {
+				t.Errorf("round %d run %d: picks = %+v", round, i, res.Picks)
+			}
+			if top1 := res.Top1MdBuffer.String(); !strings.Contains(top1, own.Name) || strings.Contains(top1, other.Name) {
+				t.Errorf("round %d run %d: report mixed:\n%s", round, i, top1)
+			}
+		}
+	}
+}
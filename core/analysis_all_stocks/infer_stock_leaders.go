@@ -1,10 +1,13 @@
 package core
 
 import (
+	"context"
+	"dragon-quant/calendar"
 	"dragon-quant/config"
 	"dragon-quant/data_processor"
 	"dragon-quant/fetcher"
 	"dragon-quant/model"
+	"dragon-quant/warehouse"
 	"fmt"
 	"sort"
 	"strings"
@@ -17,7 +20,7 @@ type InferStockLeadersResult struct {
 	Elapsed   time.Duration
 }
 
-func InferStockLeaders(cfg *config.Config, findCandidatesResult FindCandidatesResult) InferStockLeadersResult {
+func InferStockLeaders(ctx context.Context, cfg *config.Config, wh *warehouse.Warehouse, findCandidatesResult FindCandidatesResult) InferStockLeadersResult {
 	fmt.Println("🔬 [Step 3] 计算技术指标 & 推演龙头地位...")
 
 	var mu sync.Mutex
@@ -32,31 +35,42 @@ func InferStockLeaders(cfg *config.Config, findCandidatesResult FindCandidatesRe
 			defer techWg.Done()
 			sem <- struct{}{}
 			defer func() { <-sem }()
+			if ctx.Err() != nil {
+				return
+			}
 
 			// 1. 龙头地位推演 (基于板块标签)
 			data_processor.InferDragonStatus(s)
 
 			// 2. K线计算
-			klines := fetcher.FetchHistoryData(s.Code, 60)
-			if len(klines) < 30 {
+			// 不复权日K用于涨停判断，前复权日K用于均线等指标
+			rawKlines, factors, _ := wh.DailyRaw(ctx, s.Code, 60) // 失败已记入数据质量报告
+			if len(rawKlines) < 30 {
 				return
 			}
+			klines := data_processor.AdjustBars(rawKlines, factors, model.AdjustForward)
 
 			// 🆕 3. 深度数据 (竞价 f277 + 盘口 + 龙虎榜)
 			// 注意：fetchStockDetails 会更新 s 中的 CallAuctionAmt 等字段
-			fetcher.FetchStockDetails(s)
+			_ = fetcher.FetchStockDetails(ctx, s) // 失败时竞价/盘口字段留空，已记入数据质量报告
+			data_processor.AnalyzeOrderBook(s)    // 🆕 五档盘口: 不平衡度 + 封单
+			if f, ok := wh.AuctionFeatures(s.Code, calendar.Today()); ok {
+				s.Auction = &f // 🆕 -auction 采集的竞价时序
+			}
 
 			if s.ChangePct > 7.0 || s.CallAuctionAmt > 50000000 {
-				fetcher.FetchLHBData(s)
+				_ = fetcher.FetchLHBData(ctx, s) // 龙虎榜缺失不影响入选
+				wh.SaveLHB(s)
+				wh.AnnotateHotSeats(s) // 🆕 买入席位的历史跟风表现
 			}
 
 			// 🆕 计算开盘承接率 (Sustainability)
 			// 注意: Fetch5MinKline 使用 fields=f57(AvgAmt?) no, Amount.
-			kline5 := fetcher.Fetch5MinKline(s.Code)
+			kline5, _ := wh.Min5(ctx, s.Code)
 			s.OpenVolRatio = data_processor.CalculateSustainability(s.CallAuctionAmt, kline5)
 
 			// 🆕 30分钟级别主力意图 (从30m K线挖掘)
-			klines30m := fetcher.Fetch30MinKline(s.Code, 60)
+			klines30m, _ := wh.Min30(ctx, s.Code, 60)
 			s.Note30m = data_processor.Analyze30mStrategy(klines30m)
 
 			// 🆕 Format 30m K-lines for AI (Last 12 bars = 1.5 days)
@@ -82,7 +96,7 @@ func InferStockLeaders(cfg *config.Config, findCandidatesResult FindCandidatesRe
 
 			// 🆕 4. 深度K线挖掘 (VWAP + 记忆)
 			s.VWAP, s.ProfitDev = data_processor.CalculateVWAP(klines, 30, s.Price)
-			s.DragonHabit = data_processor.AnalyzeDragonHabit(klines)
+			s.DragonHabit = data_processor.AnalyzeDragonHabit(s.Code, s.Name, rawKlines, factors)
 
 			s.MA5, s.MA20 = data_processor.CalculateMA(klines)
 			s.DIF, s.DEA, s.Macd = data_processor.CalculateMACD(klines)
@@ -90,6 +104,7 @@ func InferStockLeaders(cfg *config.Config, findCandidatesResult FindCandidatesRe
 
 			// 3. 技术备注构造 + 4. 终极过滤
 			passed := data_processor.GenerateTechNotes(s)
+			wh.SaveSnapshot(s, cfg.StartTime)
 
 			if passed {
 				mu.Lock()
@@ -0,0 +1,205 @@
+package core
+
+import (
+	"context"
+	"dragon-quant/config"
+	"dragon-quant/warehouse"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// 阶段名 (-from 参数)
+const (
+	StageSectors    = "sectors"    // Step 1 扫描热点
+	StageCandidates = "candidates" // Step 2 竞价资金初筛
+	StageLeaders    = "leaders"    // Step 3 技术指标 + 龙头推演
+	StageReview     = "review"     // Step 5-6 风控 + 30m精选 + 老狐狸复审
+	StageFinal      = "final"      // Step 7 总决赛
+)
+
+// Run 一次全量扫描。各阶段结果挂在 Run 上，并以 JSON 落盘到 Dir 作为断点。
+type Run struct {
+	ID  string
+	Dir string
+	Cfg *config.Config
+	Wh  *warehouse.Warehouse
+
+	Sectors    ScanHotPointSectorsResult
+	Candidates FindCandidatesResult
+	Leaders    InferStockLeadersResult
+	Review     SectorReviewResult
+	Final      GrandFinalResult
+
+	// Budgets 各阶段的时间预算 (为 0 不限)。超出预算的阶段以已完成的部分结果继续，但不写断点
+	Budgets map[string]time.Duration
+
+	// OnStage 每个阶段完成 (或从断点恢复) 后回调，用于及时输出阶段性报告
+	OnStage func(stage string, r *Run)
+}
+
+// Stage 流水线的一个阶段。Output 返回本阶段结果在 Run 上的指针 (必须可 JSON 序列化)，
+// Run 只依赖之前阶段的 Output，因此可以从任一阶段的断点续跑。
+type Stage interface {
+	Name() string
+	Run(ctx context.Context, r *Run) error
+	Output(r *Run) interface{}
+}
+
+type stage struct {
+	name string
+	run  func(ctx context.Context, r *Run) error
+	out  func(r *Run) interface{}
+}
+
+func (s stage) Name() string                          { return s.name }
+func (s stage) Run(ctx context.Context, r *Run) error { return s.run(ctx, r) }
+func (s stage) Output(r *Run) interface{}             { return s.out(r) }
+
+// Stages 全量扫描的阶段顺序
+func Stages() []Stage {
+	return []Stage{
+		stage{StageSectors,
+			func(ctx context.Context, r *Run) error { r.Sectors = ScanHotPointSectors(ctx, r.Cfg); return nil },
+			func(r *Run) interface{} { return &r.Sectors }},
+		stage{StageCandidates,
+			func(ctx context.Context, r *Run) error {
+				r.Candidates = FindCandidates(ctx, r.Cfg, r.Wh, r.Sectors)
+				return nil
+			},
+			func(r *Run) interface{} { return &r.Candidates }},
+		stage{StageLeaders,
+			func(ctx context.Context, r *Run) error {
+				r.Leaders = InferStockLeaders(ctx, r.Cfg, r.Wh, r.Candidates)
+				return nil
+			},
+			func(r *Run) interface{} { return &r.Leaders }},
+		stage{StageReview,
+			func(ctx context.Context, r *Run) error {
+				if len(r.Leaders.FinalPool) > 0 {
+					r.Review = ReviewSectors(ctx, r.Cfg, r.Sectors, r.Leaders)
+				}
+				return nil
+			},
+			func(r *Run) interface{} { return &r.Review }},
+		stage{StageFinal,
+			func(ctx context.Context, r *Run) (err error) {
+				r.Final, err = GrandFinal(ctx, r.Cfg, r.Review)
+				return err
+			},
+			func(r *Run) interface{} { return &r.Final }},
+	}
+}
+
+// NewRun runID 为空时新建 (用本次启动时间)，否则指向已有运行的断点目录。
+// Run 持有 cfg 的副本，AI 阶段性结果文件由运行 ID 决定，不与其他运行共用
+func NewRun(cfg *config.Config, wh *warehouse.Warehouse, runID string) *Run {
+	if runID == "" {
+		runID = cfg.StartTsStr
+	}
+	c := *cfg
+	c.AIPartialFile = cfg.AIPartialFileFor(runID)
+	return &Run{ID: runID, Dir: cfg.RunDir(runID), Cfg: &c, Wh: wh, Budgets: cfg.Run.Budgets()}
+}
+
+// Execute 从 from 阶段开始执行 (为空则从头)。之前的阶段从断点加载，之后每个阶段完成即写断点。
+// 某阶段失败或 ctx 取消 (Ctrl-C / 整体截止时间) 时立即返回，已完成部分的结果仍保留在 Run 上。
+func (r *Run) Execute(ctx context.Context, from string) error {
+	stages := Stages()
+	start := 0
+	if from != "" {
+		start = -1
+		var names []string
+		for i, s := range stages {
+			names = append(names, s.Name())
+			if s.Name() == from {
+				start = i
+			}
+		}
+		if start < 0 {
+			return fmt.Errorf("unknown stage %q (want %s)", from, strings.Join(names, " / "))
+		}
+	}
+	if err := os.MkdirAll(r.Dir, 0755); err != nil {
+		return err
+	}
+
+	for i, s := range stages {
+		if i < start {
+			if err := r.load(s); err != nil {
+				return fmt.Errorf("resume %s: %w", s.Name(), err)
+			}
+			fmt.Printf("♻️ [Run %s] 阶段 %s 从断点恢复\n", r.ID, s.Name())
+		} else {
+			if err := ctx.Err(); err != nil {
+				return fmt.Errorf("stage %s: %w", s.Name(), err)
+			}
+			begin := time.Now()
+			stageCtx, cancel := ctx, context.CancelFunc(func() {})
+			if budget := r.Budgets[s.Name()]; budget > 0 {
+				stageCtx, cancel = context.WithTimeout(ctx, budget)
+			}
+			err := s.Run(stageCtx, r)
+			overBudget := errors.Is(stageCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil
+			cancel()
+			if err != nil {
+				return fmt.Errorf("stage %s: %w", s.Name(), err)
+			}
+			if err := ctx.Err(); err != nil {
+				// 被中断的阶段结果不完整: 留在 Run 上供输出部分报告，但不写断点
+				return fmt.Errorf("stage %s: %w", s.Name(), err)
+			}
+			if overBudget {
+				fmt.Printf("⏱️ [Run %s] 阶段 %s 超出预算 %s，以部分结果继续 (不写断点)\n", r.ID, s.Name(), r.Budgets[s.Name()])
+			} else {
+				if err := r.save(s); err != nil {
+					fmt.Printf("⚠️ [Run %s] 阶段 %s 断点写入失败: %v\n", r.ID, s.Name(), err)
+				}
+				fmt.Printf("💾 [Run %s] 阶段 %s 完成 (%s)\n", r.ID, s.Name(), time.Since(begin).Round(time.Millisecond))
+			}
+		}
+		if r.OnStage != nil {
+			r.OnStage(s.Name(), r)
+		}
+	}
+	return nil
+}
+
+// NextStage 第一个还没有断点的阶段 (续跑时 -from 的取值)；全部完成时为空
+func (r *Run) NextStage() string {
+	for _, s := range Stages() {
+		if _, err := os.Stat(r.checkpoint(s)); err != nil {
+			return s.Name()
+		}
+	}
+	return ""
+}
+
+// Result 报告用的 AI 鉴股结果 (总决赛未完成时只含复审部分)
+func (r *Run) Result() FindWinnersResult {
+	return r.Review.Result(r.Final)
+}
+
+func (r *Run) checkpoint(s Stage) string {
+	return filepath.Join(r.Dir, s.Name()+".json")
+}
+
+func (r *Run) save(s Stage) error {
+	data, err := json.MarshalIndent(s.Output(r), "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(r.checkpoint(s), data, 0644)
+}
+
+func (r *Run) load(s Stage) error {
+	data, err := os.ReadFile(r.checkpoint(s))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, s.Output(r))
+}
@@ -0,0 +1,163 @@
+package core
+
+import (
+	"context"
+	"dragon-quant/ai_reviewer/mock_llm"
+	"dragon-quant/config"
+	"dragon-quant/model"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestRunResumeFromCheckpoint 断点续跑: 前三个阶段从断点加载，总决赛失败后只续跑 final
+func TestRunResumeFromCheckpoint(t *testing.T) {
+	srv := mock_llm.NewServer(mock_llm.Options{})
+	url, err := srv.Start("")
+	if err != nil {
+		t.Fatalf("Start mock failed: %v", err)
+	}
+	defer srv.Close()
+
+	dir := t.TempDir()
+	cfg := &config.Config{
+		DeepSeek:   config.DeepSeekConfig{APIKey: "mock", APIURL: url},
+		StartTsStr: "2026-01-01T09-26-00",
+	}
+
+	// 模拟上一次运行已完成 leaders
+	prev := &Run{ID: "r1", Dir: dir, Cfg: cfg}
+	prev.Leaders.FinalPool = []*model.StockInfo{
+		{Code: "600001", Name: "甲股份", Tags: []string{"半导体"}, NetInflow: 2e8, NetInflow5Day: 1e8, VolRatio: 1.5, KLine30mStr: "[Bar-1: C=10.00, R=0.00%, V=100]"},
+		{Code: "000002", Name: "乙科技", Tags: []string{"半导体"}, NetInflow: 3e8, NetInflow5Day: 1e8, VolRatio: 1.5, KLine30mStr: "[Bar-1: C=20.00, R=1.00%, V=200]"},
+	}
+	for _, s := range Stages()[:3] {
+		if err := prev.save(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 总决赛时 AI 不可用: review 成功并落盘，final 报错
+	var stages []string
+	broken := *cfg
+	r := &Run{ID: "r1", Dir: dir, Cfg: &broken}
+	r.OnStage = func(s string, _ *Run) {
+		stages = append(stages, s)
+		if s == StageReview {
+			broken.DeepSeek.APIURL = "http://127.0.0.1:1/v1/chat/completions"
+		}
+	}
+	err = r.Execute(context.Background(), StageReview)
+	if err == nil || !strings.Contains(err.Error(), "stage final") {
+		t.Fatalf("err = %v, want final failure", err)
+	}
+	if strings.Join(stages, ",") != "sectors,candidates,leaders,review" {
+		t.Errorf("stages = %v", stages)
+	}
+	if len(r.Leaders.FinalPool) != 2 || !r.Review.Reviewed || len(r.Review.Finalists) == 0 {
+		t.Fatalf("review not completed: %+v", r.Review)
+	}
+	partial := r.Result()
+	if !strings.Contains(partial.Top1MdBuffer.String(), "唯一指定标的") {
+		t.Error("partial result should keep the sector picks")
+	}
+	if _, err := os.Stat(filepath.Join(dir, StageFinal+".json")); err == nil {
+		t.Error("failed stage must not write a checkpoint")
+	}
+
+	// 修复后只续跑 final
+	resumed := &Run{ID: "r1", Dir: dir, Cfg: cfg}
+	if err := resumed.Execute(context.Background(), StageFinal); err != nil {
+		t.Fatal(err)
+	}
+	if resumed.Review.Top1Md != r.Review.Top1Md {
+		t.Error("review should be loaded from checkpoint")
+	}
+	final := resumed.Result()
+	if !strings.Contains(final.WinnersMdBuffer.String(), "五虎上将") {
+		t.Errorf("grand final missing:\n%s", final.WinnersMdBuffer.String())
+	}
+
+	if err := resumed.Execute(context.Background(), "nope"); err == nil {
+		t.Error("unknown stage should fail")
+	}
+	if err := (&Run{Dir: t.TempDir(), Cfg: cfg}).Execute(context.Background(), StageFinal); err == nil {
+		t.Error("resume without checkpoints should fail")
+	}
+}
+
+// TestRunCancelAndBudget Ctrl-C 时停在当前阶段且不写断点；超出预算的阶段以部分结果继续
+func TestRunCancelAndBudget(t *testing.T) {
+	srv := mock_llm.NewServer(mock_llm.Options{})
+	url, err := srv.Start("")
+	if err != nil {
+		t.Fatalf("Start mock failed: %v", err)
+	}
+	defer srv.Close()
+
+	dir := t.TempDir()
+	cfg := &config.Config{
+		DeepSeek:   config.DeepSeekConfig{APIKey: "mock", APIURL: url},
+		StartTsStr: "2026-01-01T09-26-00",
+	}
+	prev := &Run{ID: "r1", Dir: dir, Cfg: cfg}
+	prev.Leaders.FinalPool = []*model.StockInfo{
+		{Code: "600001", Name: "甲股份", Tags: []string{"半导体"}, NetInflow: 2e8, NetInflow5Day: 1e8, VolRatio: 1.5, KLine30mStr: "[Bar-1: C=10.00, R=0.00%, V=100]"},
+	}
+	for _, s := range Stages()[:3] {
+		if err := prev.save(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &Run{ID: "r1", Dir: dir, Cfg: cfg}
+	r.OnStage = func(s string, _ *Run) {
+		if s == StageLeaders {
+			cancel()
+		}
+	}
+	err = r.Execute(ctx, StageReview)
+	if !errors.Is(err, context.Canceled) || !strings.Contains(err.Error(), "stage review") {
+		t.Fatalf("err = %v, want review cancelled", err)
+	}
+	if len(r.Leaders.FinalPool) != 1 {
+		t.Error("completed stages should stay on the run")
+	}
+	if _, err := os.Stat(filepath.Join(dir, StageReview+".json")); err == nil {
+		t.Error("cancelled stage must not write a checkpoint")
+	}
+
+	budgeted := &Run{ID: "r1", Dir: dir, Cfg: cfg, Budgets: map[string]time.Duration{StageReview: time.Nanosecond}}
+	if err := budgeted.Execute(context.Background(), StageReview); err != nil {
+		t.Fatalf("over-budget stage should not fail the run: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, StageReview+".json")); err == nil {
+		t.Error("over-budget stage must not write a checkpoint")
+	}
+	if _, err := os.Stat(filepath.Join(dir, StageFinal+".json")); err != nil {
+		t.Error("later stages should still run after a budget overrun")
+	}
+}
+
+func TestNewRunPartialFilePerRun(t *testing.T) {
+	cfg := &config.Config{StartTsStr: "2026-01-02T09-26-00"}
+	cfg.Output.Path = t.TempDir()
+	cfg.AIPartialFile = cfg.AIPartialFileFor(cfg.StartTsStr)
+
+	// 续跑沿用原运行的文件；新运行用自己的 ID，且不改动共享的 cfg
+	resumed := NewRun(cfg, nil, "2026-01-01T09-26-00")
+	fresh := NewRun(cfg, nil, "")
+	if !strings.HasSuffix(resumed.Cfg.AIPartialFile, "DeepSeek_Fox_Partial_2026-01-01T09-26-00.json") {
+		t.Errorf("resumed partial file = %s", resumed.Cfg.AIPartialFile)
+	}
+	if fresh.Cfg.AIPartialFile == resumed.Cfg.AIPartialFile || fresh.Cfg.AIPartialFile != cfg.AIPartialFile {
+		t.Errorf("fresh partial file = %s", fresh.Cfg.AIPartialFile)
+	}
+	if resumed.Cfg == cfg {
+		t.Error("NewRun should not share cfg with the caller")
+	}
+}
@@ -1,6 +1,7 @@
 package core
 
 import (
+	"context"
 	"dragon-quant/ai_reviewer/deepseek_reviewer"
 	"dragon-quant/config"
 	"dragon-quant/data_processor"
@@ -16,15 +17,21 @@ type ScanHotPointSectorsResult struct {
 	SectorNames        map[string]string
 }
 
-func ScanHotPointSectors(cfg *config.Config) ScanHotPointSectorsResult {
+func ScanHotPointSectors(ctx context.Context, cfg *config.Config) ScanHotPointSectorsResult {
 	sectorTrendResults := make(map[string]deepseek_reviewer.SectorTrendResult)
 	sectorNames := make(map[string]string)
 
 	// --- Step 1: 扫描热点 ---
 	fmt.Println("📡 [Step 1] 扫描全市场热点 (行业+概念)...")
 	var allSectors []model.SectorInfo
-	inds := fetcher.FetchTopSectors("m:90+t:2", data_processor.TopN, "行业")
-	concepts := fetcher.FetchTopSectors("m:90+t:3", data_processor.TopN, "概念")
+	inds, err := fetcher.FetchTopSectors(ctx, "m:90+t:2", data_processor.TopN, "行业")
+	if err != nil {
+		fmt.Printf("   ❌ 行业板块拉取失败: %v\n", err)
+	}
+	concepts, err := fetcher.FetchTopSectors(ctx, "m:90+t:3", data_processor.TopN, "概念")
+	if err != nil {
+		fmt.Printf("   ❌ 概念板块拉取失败: %v\n", err)
+	}
 	allSectors = append(allSectors, inds...)
 	allSectors = append(allSectors, concepts...)
 	fmt.Printf("   -> 锁定板块: %d 个\n", len(allSectors))
@@ -40,11 +47,18 @@ func ScanHotPointSectors(cfg *config.Config) ScanHotPointSectorsResult {
 
 		fmt.Printf("   -> 正在获取 %d 个板块的 K线数据...\n", len(allSectors))
 		for i := range allSectors {
+			if ctx.Err() != nil {
+				break
+			}
 			// Fetch History
 			// Use pointer to modify directly? No, range returns copy.
 			// Let's just modify the item and append to validSectors
 			s := allSectors[i]
-			s.History = fetcher.FetchSectorHistory(s.Code)
+			hist, err := fetcher.FetchSectorHistory(ctx, s.Code)
+			if err != nil {
+				continue // 已记入数据质量报告
+			}
+			s.History = hist
 
 			// Populate Name in Kline (User Request)
 			for k := range s.History {
@@ -57,8 +71,8 @@ func ScanHotPointSectors(cfg *config.Config) ScanHotPointSectorsResult {
 		}
 
 		// 2. Call AI Review
-		reviewer := deepseek_reviewer.NewReviewer(cfg.DeepSeek.APIKey)
-		aiResults := reviewer.ReviewSectorTrends(validSectors)
+		reviewer := deepseek_reviewer.NewReviewer(cfg.DeepSeek.APIKey, cfg.DeepSeek.APIURL)
+		aiResults := reviewer.ReviewSectorTrends(ctx, validSectors)
 		sectorTrendResults = aiResults // Save for later
 
 		// Save names
@@ -107,7 +121,10 @@ func ScanHotPointSectors(cfg *config.Config) ScanHotPointSectorsResult {
 
 	// 🆕 Fetch Market Sentiment
 	fmt.Println("🌡️ [Step 1.1] 探测市场情绪 (昨日涨停表现)...")
-	sentimentVal := fetcher.FetchSentimentIndex()
+	sentimentVal, err := fetcher.FetchSentimentIndex(ctx)
+	if err != nil {
+		fmt.Printf("   ⚠️ 情绪指数拉取失败，按 0 处理: %v\n", err)
+	}
 	sentimentStr := data_processor.AnalyzeSentiment(sentimentVal)
 	fmt.Printf("   -> 情绪指数: %.2f%% (%s)\n", sentimentVal, sentimentStr)
 
@@ -1,9 +1,12 @@
 package hold_kline
 
 import (
+	"dragon-quant/ai_reviewer/deepseek_reviewer"
 	"dragon-quant/config"
 	"fmt"
+	htmlpkg "html"
 	"os"
+	"strings"
 	"time"
 )
 
@@ -34,6 +37,20 @@ func GenerateHoldReport(cfg *config.Config, results []StockResult) {
         .review-box p { margin: 0; white-space: pre-wrap; }
         .footer { text-align: center; margin-top: 40px; color: #999; font-size: 12px; }
         .timestamp { font-size: 12px; color: #999; }
+        .stance { font-size: 14px; font-weight: bold; color: #fff; padding: 4px 12px; border-radius: 12px; }
+        .stance-add { background: #e74c3c; } .stance-hold { background: #e6a23c; } .stance-reduce { background: #409eff; } .stance-exit { background: #2c3e50; }
+        .levels { display: grid; grid-template-columns: repeat(3, 1fr); gap: 10px; margin-bottom: 12px; }
+        .level { background: #f5f7fa; border-radius: 4px; padding: 10px; text-align: center; }
+        .level .label { font-size: 12px; color: #7f8c8d; display: block; }
+        .level .value { font-size: 16px; font-weight: bold; font-family: monospace; }
+        .confidence { height: 6px; background: #ebeef5; border-radius: 3px; margin: 8px 0 12px; }
+        .confidence div { height: 6px; background: #67c23a; border-radius: 3px; }
+        .comment { font-size: 16px; font-weight: bold; color: #2c3e50; margin-bottom: 8px; }
+        .invalidation { font-size: 13px; color: #909399; }
+        details { margin-top: 12px; } summary { cursor: pointer; color: #e6a23c; }
+        .position { display: grid; grid-template-columns: repeat(4, 1fr); gap: 8px; background: #fafafa; border: 1px dashed #dcdfe6; border-radius: 4px; padding: 10px; margin-bottom: 12px; font-size: 13px; }
+        .position .label { font-size: 11px; color: #909399; display: block; }
+        .pnl-up { color: #e74c3c; font-weight: bold; } .pnl-down { color: #27ae60; font-weight: bold; }
     </style>
 </head>
 <body>
@@ -45,9 +62,12 @@ func GenerateHoldReport(cfg *config.Config, results []StockResult) {
 `
 
 	for _, r := range results {
-		// Convert Markdown in AIReview to HTML roughly (newlines)
-		// Or just stick it in pre-wrap
+		if r.Review != nil {
+			html += renderReviewCard(r)
+			continue
+		}
 
+		// JSON 解析失败: 降级展示原始输出
 		html += fmt.Sprintf(`
         <div class="card">
             <div class="header">
@@ -56,13 +76,14 @@ func GenerateHoldReport(cfg *config.Config, results []StockResult) {
                     <span class="stock-code">%s</span>
                 </div>
             </div>
+            %s
             <div class="review-box">
-                <p><strong>🧠 DeepSeek 分析:</strong></p>
+                <p><strong>🧠 DeepSeek 分析 (未结构化):</strong></p>
                 <br>
                 <p>%s</p>
             </div>
         </div>
-`, r.Name, r.Code, r.AIReview)
+`, r.Name, r.Code, renderPosition(r), htmlpkg.EscapeString(r.AIReview))
 	}
 
 	html += `
@@ -76,3 +97,88 @@ func GenerateHoldReport(cfg *config.Config, results []StockResult) {
 	f.WriteString(html)
 	fmt.Printf("\n✅ 报告已生成: %s\n", cfg.HoldKlineReportFile)
 }
+
+var stanceLabels = map[string]string{
+	deepseek_reviewer.StanceAdd:    "加仓",
+	deepseek_reviewer.StanceHold:   "持有",
+	deepseek_reviewer.StanceReduce: "减仓",
+	deepseek_reviewer.StanceExit:   "清仓",
+}
+
+func formatPrices(prices []float64) string {
+	if len(prices) == 0 {
+		return "-"
+	}
+	var parts []string
+	for _, p := range prices {
+		parts = append(parts, fmt.Sprintf("%.2f", p))
+	}
+	return strings.Join(parts, " / ")
+}
+
+func renderReviewCard(r StockResult) string {
+	rv := r.Review
+	prose := ""
+	if rv.Prose != "" {
+		prose = fmt.Sprintf(`
+            <details>
+                <summary>🧠 游资复盘全文</summary>
+                <div class="review-box"><p>%s</p></div>
+            </details>`, htmlpkg.EscapeString(rv.Prose))
+	}
+
+	return fmt.Sprintf(`
+        <div class="card">
+            <div class="header">
+                <div>
+                    <span class="stock-name">%s</span>
+                    <span class="stock-code">%s</span>
+                </div>
+                <span class="stance stance-%s">%s</span>
+            </div>
+            <div class="comment">%s</div>
+            %s
+            <div class="levels">
+                <div class="level"><span class="label">支撑</span><span class="value">%s</span></div>
+                <div class="level"><span class="label">压力</span><span class="value">%s</span></div>
+                <div class="level"><span class="label">止损</span><span class="value">%.2f</span></div>
+            </div>
+            <span class="label">置信度 %d%%</span>
+            <div class="confidence"><div style="width: %d%%"></div></div>
+            <div class="invalidation">❗ 失效条件: %s</div>%s
+        </div>
+`, r.Name, r.Code, rv.Stance, stanceLabels[rv.Stance],
+		htmlpkg.EscapeString(rv.Comment), renderPosition(r),
+		formatPrices(rv.Support), formatPrices(rv.Resistance), rv.StopLoss,
+		rv.Confidence, rv.Confidence,
+		htmlpkg.EscapeString(rv.Invalidation), prose)
+}
+
+// renderPosition 展示单个持仓的风险: 浮盈亏、成本 vs 30m VWAP、T+1 可卖、触及止损的潜在亏损
+func renderPosition(r StockResult) string {
+	st := r.Position
+	if st == nil {
+		return ""
+	}
+	pnlClass := "pnl-up"
+	if st.UnrealizedPnL < 0 {
+		pnlClass = "pnl-down"
+	}
+
+	stopRisk := "-"
+	if r.Review != nil && r.Review.StopLoss > 0 && st.MarketValue > 0 {
+		loss := (r.Review.StopLoss - st.LastPrice) * float64(st.Shares)
+		stopRisk = fmt.Sprintf("%+.0f 元 (%+.1f%%)", loss, loss/st.MarketValue*100)
+	}
+
+	return fmt.Sprintf(`<div class="position">
+                <div><span class="label">成本 / 现价</span>%.2f / %.2f</div>
+                <div><span class="label">浮动盈亏</span><span class="%s">%+.0f 元 (%+.2f%%)</span></div>
+                <div><span class="label">成本 vs 30m VWAP</span>%+.2f%%</div>
+                <div><span class="label">可卖 / 持有</span>%d / %d 股</div>
+                <div><span class="label">市值</span>%.0f 元</div>
+                <div><span class="label">触及止损</span>%s</div>
+            </div>`,
+		st.Cost, st.LastPrice, pnlClass, st.UnrealizedPnL, st.UnrealizedPct,
+		st.CostVsVWAP, st.Sellable, st.Shares, st.MarketValue, stopRisk)
+}
@@ -1,11 +1,15 @@
 package hold_kline
 
 import (
+	"context"
 	"dragon-quant/ai_reviewer/deepseek_reviewer"
+	"dragon-quant/broker_importer"
+	"dragon-quant/calendar"
 	"dragon-quant/config"
 	"dragon-quant/data_processor"
 	"dragon-quant/fetcher"
 	"dragon-quant/model"
+	"dragon-quant/warehouse"
 	"fmt"
 	"strings"
 	"sync"
@@ -13,7 +17,8 @@ import (
 )
 
 type HoldProcessor struct {
-	Reviewer *deepseek_reviewer.Reviewer
+	Reviewer  *deepseek_reviewer.Reviewer
+	Warehouse *warehouse.Warehouse // 可选: 配置后 1m 数据从仓库增量读取，所有持仓共用一个库
 }
 
 type StockResult struct {
@@ -21,13 +26,18 @@ type StockResult struct {
 	Name      string
 	Tags      []string
 	KLine30m  string // Kept for compatibility or debug
-	AIReview  string
+	AIReview  string // 原始输出 (JSON 解析失败时直接展示)
+	Review    *deepseek_reviewer.HoldReviewJSON
+	Partial   bool // AI 输出中途断开，AIReview 不完整
 	TechNotes string
+
+	// 持仓信息 (未配置成本/数量时为 nil)
+	Position *data_processor.PositionStats
 }
 
-func NewHoldProcessor(apiKey string) *HoldProcessor {
+func NewHoldProcessor(apiKey, apiURL string) *HoldProcessor {
 	return &HoldProcessor{
-		Reviewer: deepseek_reviewer.NewReviewer(apiKey),
+		Reviewer: deepseek_reviewer.NewReviewer(apiKey, apiURL),
 	}
 }
 
@@ -35,92 +45,140 @@ func (p *HoldProcessor) Close() {
 	// No global resources to close anymore
 }
 
-// Run performs a review for the specified number of days
-func (p *HoldProcessor) Run(cfg *config.Config, days int) {
+// Run performs a review for the specified number of days (ctx 取消后不再启动新的个股，已完成的照常出报告)
+func (p *HoldProcessor) Run(ctx context.Context, cfg *config.Config, days int) {
+
+	positions := cfg.HoldStocks
+	fmt.Printf("\n�️ [Custom Review] Starting for %d stocks (Days=%d)...\n", len(positions), days)
 
-	names := cfg.HoldStocks
-	fmt.Printf("\n�️ [Custom Review] Starting for %d stocks (Days=%d)...\n", len(names), days)
+	p.Reviewer.Stream = cfg.DeepSeek.Stream
 
-	p.runGeneric(cfg, days)
+	p.runGeneric(ctx, cfg, days)
 }
 
-func (p *HoldProcessor) runGeneric(cfg *config.Config, days int) {
+func (p *HoldProcessor) runGeneric(ctx context.Context, cfg *config.Config, days int) {
 	var results []StockResult
 	var mu sync.Mutex
 	var wg sync.WaitGroup
 
-	names := cfg.HoldStocks
+	positions := cfg.HoldStocks
 
 	// Semaphore to limit concurrency (DeepSeek API limits)
 	maxConcurrent := 5
 	sem := make(chan struct{}, maxConcurrent)
 
-	fmt.Printf("🚀 Launching %d goroutines (Max Parallel: %d)...\n", len(names), maxConcurrent)
+	fmt.Printf("🚀 Launching %d goroutines (Max Parallel: %d)...\n", len(positions), maxConcurrent)
+
+	// 流式模式: 每只持仓一行实时进度，逐只落盘阶段性结果
+	partial := deepseek_reviewer.NewPartialSaver(cfg.HoldKlinePartialFile)
+	progress := p.Reviewer.StartProgress()
+	logf := func(format string, a ...interface{}) {
+		if progress == nil {
+			fmt.Printf(format, a...)
+		}
+	}
 
-	for _, nameInput := range names {
+	for _, pos := range positions {
 		wg.Add(1)
+		row := progress.Row(pos.Keyword())
 
-		go func(nameIn string) {
+		go func(pos config.HoldPosition) {
+			nameIn := pos.Keyword()
 			defer wg.Done()
+			defer row.Done()
 
 			// Acquire token
+			row.SetStock("排队中")
 			sem <- struct{}{}
 			defer func() { <-sem }()
-
-			// --- Isolated Execution Context ---
-
-			// 0. Init Thread-Local DuckDB
-			// Critical for data isolation (kline_1m table)
-			duck, err := data_processor.NewDuckDB("")
-			if err != nil {
-				fmt.Printf("❌ [%s] DuckDB Init Failed: %v\n", nameIn, err)
+			if ctx.Err() != nil {
+				row.SetStock("⏹️ 已取消")
 				return
 			}
-			defer duck.Close()
 
-			klineProc := data_processor.NewKlineProcessor(duck)
+			// --- Isolated Execution Context ---
 
 			// 1. Resolve Code
 			// fmt.Printf("   -> Searching %s ... ", nameIn) // Avoid noisy interleaved logs
-			code, realName := fetcher.SearchStock(nameIn)
-			if code == "" {
-				fmt.Printf("❌ [%s] Not Found.\n", nameIn)
+			sec, err := fetcher.SearchStock(ctx, nameIn)
+			code, realName := sec.Key(), sec.Name // 上证指数等与个股同号的证券带市场前缀 (sh000001)
+			if err != nil {
+				if fetcher.KindOf(err) == fetcher.ErrEmpty {
+					logf("❌ [%s] Not Found.\n", nameIn)
+					row.SetStock("❌ 未找到代码")
+				} else {
+					logf("❌ [%s] Search failed: %v\n", nameIn, err)
+					row.SetStock("❌ 搜索接口失败")
+				}
 				return
 			}
 
 			// 2. Fetch 1m K-line (Retry 5 times)
+			row.SetStock("拉取 1m 数据")
 			var klines []model.KLineData
 			for retry := 0; retry < 5; retry++ {
-				klines = fetcher.Fetch1MinKline(code, days)
+				klines, _ = p.Warehouse.Min1(ctx, code, days)
 				if len(klines) > 0 {
 					break
 				}
 				if retry < 4 {
-					time.Sleep(time.Duration(retry+1) * 500 * time.Millisecond) // Backoff
-					fmt.Printf("🔄 [%s] Retry fetching data (%d/5)...\n", realName, retry+1)
+					if calendar.Sleep(ctx, time.Duration(retry+1)*500*time.Millisecond) != nil { // Backoff
+						break
+					}
+					logf("🔄 [%s] Retry fetching data (%d/5)...\n", realName, retry+1)
 				}
 			}
 
 			if len(klines) == 0 {
-				fmt.Printf("⚠️ [%s] No Data after 5 attempts. Skipping.\n", realName)
+				logf("⚠️ [%s] No Data after 5 attempts. Skipping.\n", realName)
+				row.SetStock("⚠️ 无数据")
 				return
 			}
-			fmt.Printf("✅ [%s] Got %d bars.\n", realName, len(klines))
+			logf("✅ [%s] Got %d bars.\n", realName, len(klines))
 
 			// 3. Load into DuckDB
-			err = klineProc.LoadData(klines)
-			if err != nil {
-				fmt.Printf("❌ [%s] DuckDB Load Error: %v\n", realName, err)
-				return
+			// 仓库模式: 直接查询共享库里该代码的 bars_1m；否则每个协程独立内存库 (kline_1m 隔离)
+			row.SetStock("DuckDB 挖掘")
+			var klineProc *data_processor.KlineProcessor
+			if p.Warehouse != nil {
+				klineProc, err = data_processor.NewKlineProcessorForCode(p.Warehouse.Duck, code, warehouse.Min1Window(days))
+				if err != nil {
+					logf("❌ [%s] Warehouse Error: %v\n", realName, err)
+					return
+				}
+			} else {
+				duck, err := data_processor.NewDuckDB("")
+				if err != nil {
+					logf("❌ [%s] DuckDB Init Failed: %v\n", realName, err)
+					return
+				}
+				defer duck.Close()
+
+				klineProc = data_processor.NewKlineProcessor(duck)
+				if err = klineProc.LoadData(klines); err != nil {
+					logf("❌ [%s] DuckDB Load Error: %v\n", realName, err)
+					return
+				}
 			}
 
 			// 4. Advanced Analysis (Aggregation + Anomaly)
 			events, err := klineProc.AnalyzeVolatility()
 			if err != nil {
-				fmt.Printf("❌ [%s] Analysis Error: %v\n", realName, err)
+				logf("❌ [%s] Analysis Error: %v\n", realName, err)
 				return
 			}
 
+			// 4.1 Position (成本/数量/T+1)
+			var posStats *data_processor.PositionStats
+			if pos.HasCost() {
+				stats, err := klineProc.PositionStats(pos.Cost, pos.Shares, SellableShares(pos, time.Now()))
+				if err != nil {
+					logf("⚠️ [%s] Position Stats Error: %v\n", realName, err)
+				} else {
+					posStats = &stats
+				}
+			}
+
 			// 5. Build Context Prompt
 			var sb strings.Builder
 			sb.WriteString(fmt.Sprintf("以下是 %s (%s) 基于“%d天 1分钟高频数据”聚合挖掘出的【关键异动时刻】：\n\n", realName, code, days))
@@ -137,6 +195,10 @@ func (p *HoldProcessor) runGeneric(cfg *config.Config, days int) {
 				sb.WriteString("\n")
 			}
 
+			if posStats != nil {
+				sb.WriteString(positionPrompt(pos, posStats))
+			}
+
 			contextStr := sb.String()
 
 			// 6. AI Analysis
@@ -157,53 +219,144 @@ func (p *HoldProcessor) runGeneric(cfg *config.Config, days int) {
 # Analysis Requirements:
 1. **主力身份侧写**: 是“解放南路”式的暴力拉升，还是“温州帮”式的出货？是“机构”在维护，还是“散户”在踩踏？
 2. **杀伐决断**:
-   - **刀口**: 哪里是风险释放的极致低点？
-   - **博弈**: 哪里是情绪一致的高潮点？
+   - **刀口**: 哪里是风险释放的极致低点？ -> 写入 support
+   - **博弈**: 哪里是情绪一致的高潮点？ -> 写入 resistance
 3. **操作指令 (Direct Command)**:
-   - 必须给出明确的买点。以及各种相应的指标的具体操作。我需要你给出操作锦囊。
-   - 附带一句话犀利点评 (Stylized: 简短、冷酷、一针见血)。
-
-请用游资的口吻，不要废话，直击灵魂。
-`, realName, code, days, contextStr)
+   - 明确给出 加仓/持有/减仓/清仓 的态度 (stance) 与止损价 (stop_loss)。
+   - 说明什么情况下这个判断作废 (invalidation)，并给出置信度 (confidence)。
+   - 附带一句话犀利点评 (comment)。
 
-			history := []deepseek_reviewer.Message{
-				{Role: "user", Content: prompt},
-			}
+%s`, realName, code, days, contextStr, deepseek_reviewer.HoldReviewPromptSuffix(cfg.HoldReviewProse))
 
 			// Debug: Print Prompt (Atomic Print to avoid mess)
 			// fmt.Printf("\n--- [Debug %s] Prompt ---\n%s\n", realName, contextStr)
 
-			fmt.Printf("🧠 [%s] Analyzing (%d Events)...\n", realName, len(events))
-			review := p.Reviewer.SendChat(history)
+			logf("🧠 [%s] Analyzing (%d Events)...\n", realName, len(events))
+			row.SetStock(fmt.Sprintf("AI 分析 (%d 异动)", len(events)))
+			review, raw, err := p.Reviewer.ReviewHold(ctx, prompt, row)
+			if err != nil {
+				logf("⚠️ [%s] AI 输出不完整: %v\n", realName, err)
+			}
 
 			// Debug: Log raw review length and preview
-			fmt.Printf("📝 [%s] DeepSeek Resp Len: %d. Preview: %s...\n",
-				realName, len(review), strings.ReplaceAll(review[:min(len(review), 50)], "\n", " "))
+			logf("📝 [%s] DeepSeek Resp Len: %d. Preview: %s...\n",
+				realName, len(raw), strings.ReplaceAll(raw[:min(len(raw), 50)], "\n", " "))
 
-			fmt.Printf("✅ [%s] Done. Appending Result.\n", realName)
+			logf("✅ [%s] Done. Appending Result.\n", realName)
 
 			// Collect Result safely
-			mu.Lock()
-			results = append(results, StockResult{
+			result := StockResult{
 				Code:     code,
 				Name:     realName,
 				KLine30m: contextStr,
-				AIReview: review,
-			})
+				AIReview: raw,
+				Review:   review,
+				Partial:  err != nil,
+				Position: posStats,
+			}
+			partial.Save(code, result)
+			row.SetStock("完成")
+
+			mu.Lock()
+			results = append(results, result)
 			mu.Unlock()
 
-		}(nameInput)
+		}(pos)
 	}
 
 	wg.Wait()
+	progress.Stop()
+
+	// 🆕 止损/压力位交给 -watch 盯盘
+	saveWatchTargets(ctx, p.Warehouse, results, len(positions))
 	// Generate HTML (Reuse existing generic generator)
 	fmt.Printf("📊 Generating Report for %d results...\n", len(results))
 	GenerateHoldReport(cfg, results)
 }
 
+// saveWatchTargets 用本次审视结果替换持仓盯盘列表；中途取消时结果不全，保留上一次的完整列表
+func saveWatchTargets(ctx context.Context, wh *warehouse.Warehouse, results []StockResult, total int) {
+	if ctx.Err() != nil {
+		fmt.Printf("⏹️ 审视被取消 (%d/%d)，盯盘列表保持不变\n", len(results), total)
+		return
+	}
+	if err := wh.ReplaceWatchTargets(model.WatchHold, holdTargets(results)); err != nil {
+		fmt.Printf("⚠️ 保存盯盘列表失败: %v\n", err)
+	}
+}
+
+// holdTargets 持仓审视结果转盯盘列表: 止损价 + 最近压力位作为止盈
+func holdTargets(results []StockResult) []model.WatchTarget {
+	var targets []model.WatchTarget
+	for _, r := range results {
+		t := model.WatchTarget{Code: r.Code, Name: r.Name, Source: model.WatchHold}
+		if r.Review != nil {
+			t.StopLoss = r.Review.StopLoss
+			if len(r.Review.Resistance) > 0 {
+				t.TargetPrice = r.Review.Resistance[0]
+			}
+		}
+		targets = append(targets, t)
+	}
+	return targets
+}
+
 func min(a, b int) int {
 	if a < b {
 		return a
 	}
 	return b
 }
+
+// SellableShares T+1 可卖数量: 优先用券商给出的可卖数量，其次用成交流水按笔扣除当日买入
+// (broker_importer.AggregateTrades)；两者都没有时按建仓日估算: 建仓日为今天 (或未来) 则不可卖
+func SellableShares(pos config.HoldPosition, now time.Time) int {
+	if pos.Sellable != nil {
+		return *pos.Sellable
+	}
+	if len(pos.Trades) > 0 {
+		for _, h := range broker_importer.AggregateTrades(pos.Trades, now) {
+			if h.Code == pos.Code || pos.Code == "" {
+				return h.Sellable
+			}
+		}
+		return 0
+	}
+	if pos.EntryDate == "" {
+		return pos.Shares
+	}
+	entry, err := time.ParseInLocation("2006-01-02", pos.EntryDate, now.Location())
+	if err != nil {
+		return pos.Shares
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	if !entry.Before(today) {
+		return 0
+	}
+	return pos.Shares
+}
+
+func positionPrompt(pos config.HoldPosition, st *data_processor.PositionStats) string {
+	var sb strings.Builder
+	sb.WriteString("# Position (我的持仓):\n")
+	sb.WriteString(fmt.Sprintf("- 成本: %.2f | 数量: %d 股 | 建仓: %s\n", st.Cost, st.Shares, pos.EntryDate))
+	sb.WriteString(fmt.Sprintf("- 现价: %.2f | 浮动盈亏: %+.0f 元 (%+.2f%%)\n", st.LastPrice, st.UnrealizedPnL, st.UnrealizedPct))
+	sb.WriteString(fmt.Sprintf("- 成本 vs 30m VWAP(%.2f): %+.2f%%\n", st.VWAP30m, st.CostVsVWAP))
+	sb.WriteString(fmt.Sprintf("- T+1 可卖: %d 股\n", st.Sellable))
+	if len(pos.Trades) > 0 {
+		sb.WriteString(fmt.Sprintf("- 已实现盈亏: %+.0f 元 | 近期成交:\n", pos.RealizedPnL))
+		start := 0
+		if len(pos.Trades) > 5 {
+			start = len(pos.Trades) - 5
+		}
+		for _, t := range pos.Trades[start:] {
+			side := "买入"
+			if t.Side == model.TradeSell {
+				side = "卖出"
+			}
+			sb.WriteString(fmt.Sprintf("  - %s %s %d 股 @ %.2f\n", t.Date, side, t.Quantity, t.Price))
+		}
+	}
+	sb.WriteString("请结合我的成本与可卖数量给出操作 (浮亏时优先考虑止损位, 今日买入部分不可卖)。\n\n")
+	return sb.String()
+}
@@ -0,0 +1,59 @@
+package hold_kline
+
+import (
+	"context"
+	"dragon-quant/config"
+	"dragon-quant/model"
+	"dragon-quant/warehouse"
+	"testing"
+	"time"
+)
+
+func TestSellableSharesPerLot(t *testing.T) {
+	now := time.Date(2026, 1, 9, 10, 0, 0, 0, time.Local)
+	trades := []model.Trade{
+		{Date: "2026-01-05", Code: "600519", Side: model.TradeBuy, Quantity: 900, Price: 10, Amount: 9000},
+		{Date: "2026-01-09", Code: "600519", Side: model.TradeBuy, Quantity: 100, Price: 11, Amount: 1100},
+	}
+	// 建仓日是今天也只扣除今天买入的那一笔
+	pos := config.HoldPosition{Code: "600519", Shares: 1000, EntryDate: "2026-01-09", Trades: trades}
+	if got := SellableShares(pos, now); got != 900 {
+		t.Errorf("sellable = %d, want 900", got)
+	}
+
+	broker := 500
+	pos.Sellable = &broker
+	if got := SellableShares(pos, now); got != 500 {
+		t.Errorf("broker sellable = %d, want 500", got)
+	}
+
+	// 没有流水与可卖数量时按建仓日估算: 今天建仓不可卖，之前建仓全部可卖
+	if got := SellableShares(config.HoldPosition{Code: "600519", Shares: 1000, EntryDate: "2026-01-09"}, now); got != 0 {
+		t.Errorf("config-only sellable (entry today) = %d, want 0", got)
+	}
+	if got := SellableShares(config.HoldPosition{Code: "600519", Shares: 1000, EntryDate: "2026-01-08"}, now); got != 1000 {
+		t.Errorf("config-only sellable (entry yesterday) = %d, want 1000", got)
+	}
+}
+
+func TestSaveWatchTargetsKeepsListOnCancel(t *testing.T) {
+	wh, err := warehouse.Open("")
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer wh.Close()
+
+	full := []StockResult{{Code: "600519", Name: "贵州茅台"}, {Code: "000001", Name: "平安银行"}}
+	saveWatchTargets(context.Background(), wh, full, 2)
+	if got := len(wh.WatchTargets()); got != 2 {
+		t.Fatalf("targets = %d, want 2", got)
+	}
+
+	// 取消后只完成了一只，不能覆盖掉另一只的止损
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	saveWatchTargets(ctx, wh, full[:1], 2)
+	if got := len(wh.WatchTargets()); got != 2 {
+		t.Errorf("cancelled run replaced targets: %d, want 2", got)
+	}
+}
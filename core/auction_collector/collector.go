@@ -0,0 +1,129 @@
+package auction_collector
+
+import (
+	"context"
+	"dragon-quant/calendar"
+	"dragon-quant/data_processor"
+	"dragon-quant/fetcher"
+	"dragon-quant/model"
+	"dragon-quant/warehouse"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Collector 在 9:15-9:25 集合竞价期间轮询候选股的虚拟撮合快照，入库并提取竞价特征
+type Collector struct {
+	Codes     []string
+	Interval  time.Duration
+	Warehouse *warehouse.Warehouse
+
+	// 以下可在测试中替换
+	Fetch func(ctx context.Context, code string) (model.AuctionTick, bool)
+	Now   func() time.Time
+	Sleep func(ctx context.Context, d time.Duration) error
+}
+
+// New 默认每 10 秒轮询一次
+func New(codes []string, wh *warehouse.Warehouse) *Collector {
+	return &Collector{
+		Codes:     codes,
+		Interval:  10 * time.Second,
+		Warehouse: wh,
+		Fetch:     fetcher.FetchAuctionTick,
+		Now:       calendar.Now,
+		Sleep:     calendar.Sleep,
+	}
+}
+
+// Run 阻塞至 9:25 撮合完成 (盘前启动则等待 9:15)，返回每只股票的竞价特征。
+// 9:25 后启动时只补采一次撮合结果；非交易日或已开盘直接返回。ctx 取消时返回已采集部分的特征。
+func (c *Collector) Run(ctx context.Context) map[string]model.AuctionFeatures {
+	cal := calendar.Default()
+	ticks := make(map[string][]model.AuctionTick)
+
+	for ctx.Err() == nil {
+		now := c.Now()
+		phase := cal.Phase(now)
+		switch phase {
+		case calendar.PreOpen:
+			wait := midnight(now).Add(calendar.OpeningAuctionStart).Sub(now)
+			fmt.Printf("⏳ [Auction] 距离集合竞价还有 %s...\n", wait.Round(time.Second))
+			c.Sleep(ctx, min(wait, time.Minute))
+			continue
+		case calendar.OpeningAuction:
+			c.poll(ctx, ticks)
+			c.Sleep(ctx, c.Interval)
+			continue
+		case calendar.OpeningPause:
+			// 9:25 撮合结果
+			c.poll(ctx, ticks)
+		default:
+			fmt.Printf("⚠️ [Auction] 当前时段 %s，不在集合竞价窗口\n", phase)
+		}
+		break
+	}
+
+	features := make(map[string]model.AuctionFeatures)
+	for code, ts := range ticks {
+		features[code] = data_processor.AnalyzeAuction(ts)
+	}
+	return features
+}
+
+// poll 并发拉取一轮快照并入库
+func (c *Collector) poll(ctx context.Context, ticks map[string][]model.AuctionTick) {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var batch []model.AuctionTick
+	sem := make(chan struct{}, 8)
+
+	for _, code := range c.Codes {
+		wg.Add(1)
+		go func(code string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			t, ok := c.Fetch(ctx, code)
+			if !ok {
+				return
+			}
+			mu.Lock()
+			// 行情时间未变 (接口未更新) 的快照不重复记录
+			if prev := ticks[code]; len(prev) == 0 || t.Time.After(prev[len(prev)-1].Time) {
+				ticks[code] = append(prev, t)
+				batch = append(batch, t)
+			}
+			mu.Unlock()
+		}(code)
+	}
+	wg.Wait()
+
+	if err := c.Warehouse.SaveAuctionTicks(batch); err != nil {
+		fmt.Printf("⚠️ [Auction] 入库失败: %v\n", err)
+	}
+	fmt.Printf("📡 [Auction] %s 采集 %d/%d\n", c.Now().Format("15:04:05"), len(batch), len(c.Codes))
+}
+
+func midnight(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// PrintReport 按 9:25 撮合金额降序打印竞价特征
+func PrintReport(features map[string]model.AuctionFeatures) {
+	codes := make([]string, 0, len(features))
+	for code := range features {
+		codes = append(codes, code)
+	}
+	sort.Slice(codes, func(i, j int) bool { return features[codes[i]].Amount > features[codes[j]].Amount })
+
+	fmt.Printf("\n%-8s %6s %8s %8s %8s %8s %8s %8s %10s %s\n",
+		"代码", "快照", "9:20前", "峰值", "9:25", "趋势", "撤单率", "不平衡", "金额(万)", "信号")
+	for _, code := range codes {
+		f := features[code]
+		fmt.Printf("%-8s %6d %7.2f%% %7.2f%% %7.2f%% %+7.2f %7.0f%% %+8.2f %10.0f %s\n",
+			code, f.Ticks, f.Pct920, f.PeakPct920, f.OpenPct, f.TrendPct, f.WithdrawPct*100, f.Imbalance, f.Amount/10000, f.Signal)
+	}
+}
@@ -0,0 +1,45 @@
+package auction_collector
+
+import (
+	"context"
+	"dragon-quant/model"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCollectorRunsThroughAuction(t *testing.T) {
+	var mu sync.Mutex
+	now, _ := time.Parse("2006-01-02 15:04:05", "2026-01-09 09:14:30")
+	c := &Collector{
+		Codes:    []string{"600001", "000002"},
+		Interval: time.Minute,
+		Now: func() time.Time {
+			mu.Lock()
+			defer mu.Unlock()
+			return now
+		},
+		Sleep: func(_ context.Context, d time.Duration) error {
+			mu.Lock()
+			now = now.Add(d)
+			mu.Unlock()
+			return nil
+		},
+	}
+	c.Fetch = func(_ context.Context, code string) (model.AuctionTick, bool) {
+		if code == "000002" {
+			return model.AuctionTick{}, false // 停牌
+		}
+		ts := c.Now()
+		return model.AuctionTick{Code: code, Time: ts, Price: 10.5, Volume: 100, PrevClose: 10}, true
+	}
+
+	features := c.Run(context.Background())
+	if _, ok := features["000002"]; ok {
+		t.Error("suspended code should have no features")
+	}
+	// 9:15:00 起每分钟一笔至 9:24:00 (10 笔)，9:25 后补采撮合结果
+	if f := features["600001"]; f.Ticks != 11 || f.OpenPct < 4.9 {
+		t.Errorf("features = %+v", f)
+	}
+}
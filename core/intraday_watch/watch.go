@@ -0,0 +1,259 @@
+package intraday_watch
+
+import (
+	"context"
+	"dragon-quant/ai_reviewer/deepseek_reviewer"
+	"dragon-quant/calendar"
+	"dragon-quant/data_processor"
+	"dragon-quant/fetcher"
+	"dragon-quant/model"
+	"dragon-quant/warehouse"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Rules 预警阈值
+type Rules struct {
+	VolSpike float64 // 1m 成交量超过前 30 分钟均量的倍数
+}
+
+// watchState 单只股票在两轮轮询之间需要记住的状态
+type watchState struct {
+	sealed    bool      // 上一轮是否封涨停
+	aboveVWAP *bool     // 上一轮是否在 30m 均价之上 (nil = 尚未观察)
+	lastSpike time.Time // 已预警过的最后一根放量K线
+	fired     map[string]bool
+}
+
+// Watcher 盘中盯盘: 定时刷新盘口与 1m K线，增量跑异动 SQL，按规则触发预警
+type Watcher struct {
+	Targets   []model.WatchTarget
+	Rules     Rules
+	Interval  time.Duration
+	Warehouse *warehouse.Warehouse // 1m K线落在仓库里增量检测 (未配置时用内存库)
+	Notify    func(model.Alert)
+
+	// 以下可在测试中替换
+	Quote func(ctx context.Context, code string) (model.OrderBook, bool)
+	Bars  func(ctx context.Context, code, date string) ([]model.KLineData, error)
+	Now   func() time.Time
+	Sleep func(ctx context.Context, d time.Duration) error
+
+	state  map[string]*watchState
+	alerts []model.Alert
+}
+
+// New 默认 30 秒一轮、5 倍放量
+func New(targets []model.WatchTarget, wh *warehouse.Warehouse) *Watcher {
+	return &Watcher{
+		Targets:   targets,
+		Rules:     Rules{VolSpike: 5},
+		Interval:  30 * time.Second,
+		Warehouse: wh,
+		Notify:    PrintAlert,
+		Quote:     fetcher.FetchOrderBook,
+		Bars:      fetcher.Fetch1MinKlineForDate,
+		Now:       calendar.Now,
+		Sleep:     calendar.Sleep,
+	}
+}
+
+// Run 阻塞到收盘 (或 ctx 取消)，返回当日全部预警。盘前/午休等待开盘，非交易日直接返回。
+func (w *Watcher) Run(ctx context.Context) []model.Alert {
+	if w.Warehouse == nil {
+		wh, err := warehouse.Open("")
+		if err != nil {
+			fmt.Printf("⚠️ [Watch] 打开内存仓库失败: %v\n", err)
+			return nil
+		}
+		defer wh.Close()
+		w.Warehouse = wh
+	}
+	w.state = make(map[string]*watchState)
+	cal := calendar.Default()
+
+	for {
+		if ctx.Err() != nil {
+			fmt.Printf("⏹️ [Watch] 已取消，结束盯盘 (共 %d 条预警)\n", len(w.alerts))
+			return w.alerts
+		}
+		now := w.Now()
+		switch phase := cal.Phase(now); phase {
+		case calendar.PreOpen, calendar.OpeningAuction, calendar.OpeningPause:
+			w.waitUntil(ctx, now, calendar.MorningOpen)
+		case calendar.LunchBreak:
+			w.waitUntil(ctx, now, calendar.AfternoonOpen)
+		case calendar.Morning, calendar.Afternoon, calendar.ClosingAuction:
+			w.poll(ctx, now)
+			w.Sleep(ctx, w.Interval)
+		default:
+			fmt.Printf("🔚 [Watch] %s，结束盯盘 (共 %d 条预警)\n", phase, len(w.alerts))
+			return w.alerts
+		}
+	}
+}
+
+func (w *Watcher) waitUntil(ctx context.Context, now time.Time, clock time.Duration) {
+	d := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	wait := d.Add(clock).Sub(now)
+	fmt.Printf("⏳ [Watch] 距离开盘还有 %s...\n", wait.Round(time.Second))
+	w.Sleep(ctx, min(wait, time.Minute))
+}
+
+// poll 一轮: 对每只股票刷新数据并检查规则
+func (w *Watcher) poll(ctx context.Context, now time.Time) {
+	for _, t := range w.Targets {
+		st := w.state[t.Code]
+		if st == nil {
+			st = &watchState{fired: make(map[string]bool)}
+			w.state[t.Code] = st
+		}
+		w.check(ctx, now, t, st)
+	}
+}
+
+func (w *Watcher) check(ctx context.Context, now time.Time, t model.WatchTarget, st *watchState) {
+	alert := func(rule string, price float64, format string, args ...interface{}) {
+		a := model.Alert{Time: now, Code: t.Code, Name: t.Name, Rule: rule, Price: price, Message: fmt.Sprintf(format, args...)}
+		w.alerts = append(w.alerts, a)
+		if w.Notify != nil {
+			w.Notify(a)
+		}
+	}
+
+	// 1. 1m K线入库 + 增量放量检测 + 30m 均价
+	date := now.Format("2006-01-02")
+	if bars, err := w.Bars(ctx, t.Code, date); err == nil && len(bars) > 0 {
+		if _, err := w.Warehouse.UpsertBars(t.Code, warehouse.TF1m, bars); err != nil {
+			fmt.Printf("⚠️ [Watch] %s 1m写入失败: %v\n", t.Code, err)
+		}
+	}
+	kp, err := data_processor.NewKlineProcessorForCode(w.Warehouse.Duck, t.Code, time.Time{})
+	if err != nil {
+		return
+	}
+	since := st.lastSpike
+	if since.IsZero() {
+		// 仓库按墙上时间存储，只看今天的K线
+		since = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	}
+	if spikes, err := kp.DetectVolumeSpikesSince(since, w.Rules.VolSpike); err == nil {
+		for _, e := range spikes {
+			alert(model.AlertVolSpike, e.Close, "%s 放量 %.1f 倍", e.Time.Format("15:04"), e.Volume/e.AvgVol)
+			st.lastSpike = e.Time
+		}
+	}
+	if stats, err := kp.PositionStats(0, 0, 0); err == nil && stats.VWAP30m > 0 {
+		above := stats.LastPrice >= stats.VWAP30m
+		if st.aboveVWAP != nil && *st.aboveVWAP && !above {
+			alert(model.AlertVWAPLoss, stats.LastPrice, "%.2f 跌破 30m 均价 %.2f", stats.LastPrice, stats.VWAP30m)
+		}
+		st.aboveVWAP = &above
+	}
+
+	// 2. 盘口: 炸板 / 止损 / 止盈
+	book, ok := w.Quote(ctx, t.Code)
+	if !ok || book.Price <= 0 {
+		return
+	}
+	s := &model.StockInfo{Code: t.Code, Name: t.Name, OrderBook: &book}
+	data_processor.AnalyzeOrderBook(s)
+	sealed := s.SealAmt > 0
+	if st.sealed && !sealed {
+		alert(model.AlertLimitBreak, book.Price, "涨停打开，现价 %.2f", book.Price)
+	}
+	st.sealed = sealed
+
+	if t.StopLoss > 0 && book.Price <= t.StopLoss && !st.fired[model.AlertStopLoss] {
+		st.fired[model.AlertStopLoss] = true
+		alert(model.AlertStopLoss, book.Price, "现价 %.2f ≤ 止损 %.2f", book.Price, t.StopLoss)
+	}
+	if t.TargetPrice > 0 && book.Price >= t.TargetPrice && !st.fired[model.AlertTarget] {
+		st.fired[model.AlertTarget] = true
+		alert(model.AlertTarget, book.Price, "现价 %.2f ≥ 止盈 %.2f", book.Price, t.TargetPrice)
+	}
+}
+
+// PrintAlert 默认预警输出
+func PrintAlert(a model.Alert) {
+	fmt.Printf("🚨 [Watch] %s %s %s 【%s】 %s\n", a.Time.Format("15:04:05"), a.Code, a.Name, a.Rule, a.Message)
+}
+
+var priceRe = regexp.MustCompile(`\d+(\.\d+)?`)
+
+// relativeUnits 数字后跟这些单位时是相对描述 ("5%"、"20日线"、"30分钟")，不是价位
+var relativeUnits = []string{"%", "％", "日", "天", "周", "分钟", "根", "均线"}
+
+// ParsePriceLevel 从 AI 给出的价位描述 (如 "跌破 10.52 元") 中取第一个价位数字。
+// 跳过 "5%"、"20日线"、"MA5" 这类相对描述 ("跌破MA5 (9.80元)" 取 9.80)，没有价位时返回 0
+func ParsePriceLevel(text string) float64 {
+	for _, loc := range priceRe.FindAllStringIndex(text, -1) {
+		before := strings.ToUpper(strings.TrimSpace(text[:loc[0]]))
+		rest := strings.TrimSpace(text[loc[1]:])
+		if strings.HasSuffix(before, "MA") || hasAnyPrefix(rest, relativeUnits) {
+			continue
+		}
+		v, _ := strconv.ParseFloat(text[loc[0]:loc[1]], 64)
+		return v
+	}
+	return 0
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// PoolTargets FinalPool 转盯盘列表，板块王者带上 AI 给出的止损/止盈价
+func PoolTargets(pool []*model.StockInfo, picks []*deepseek_reviewer.SniperJSON) []model.WatchTarget {
+	levels := make(map[string]*deepseek_reviewer.SniperJSON)
+	for _, p := range picks {
+		levels[p.StockCode] = p
+	}
+	var targets []model.WatchTarget
+	for _, s := range pool {
+		t := model.WatchTarget{Code: s.Code, Name: s.Name, Source: model.WatchPool}
+		if p, ok := levels[s.Code]; ok {
+			t.StopLoss = ParsePriceLevel(p.Strategy.StopLoss)
+			t.TargetPrice = ParsePriceLevel(p.Strategy.TargetPrice)
+		}
+		targets = append(targets, t)
+	}
+	return targets
+}
+
+// Merge 合并多组盯盘对象，同一代码保留先出现的 (价位为 0 时用后面的补齐)
+func Merge(groups ...[]model.WatchTarget) []model.WatchTarget {
+	index := make(map[string]int)
+	var out []model.WatchTarget
+	for _, g := range groups {
+		for _, t := range g {
+			if t.Code == "" {
+				continue
+			}
+			i, ok := index[t.Code]
+			if !ok {
+				index[t.Code] = len(out)
+				out = append(out, t)
+				continue
+			}
+			if out[i].StopLoss == 0 {
+				out[i].StopLoss = t.StopLoss
+			}
+			if out[i].TargetPrice == 0 {
+				out[i].TargetPrice = t.TargetPrice
+			}
+			if out[i].Name == "" {
+				out[i].Name = t.Name
+			}
+		}
+	}
+	return out
+}
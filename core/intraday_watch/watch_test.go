@@ -0,0 +1,110 @@
+package intraday_watch
+
+import (
+	"context"
+	"dragon-quant/ai_reviewer/deepseek_reviewer"
+	"dragon-quant/model"
+	"testing"
+	"time"
+)
+
+func TestWatcherRules(t *testing.T) {
+	now, _ := time.Parse("2006-01-02 15:04", "2026-01-09 09:29")
+	clock := func(hm string) time.Time {
+		c, _ := time.Parse("2006-01-02 15:04", "2026-01-09 "+hm)
+		return c
+	}
+
+	w := New([]model.WatchTarget{{Code: "600001", Name: "测试", StopLoss: 9.5}}, nil)
+	w.Interval = 5 * time.Minute
+	w.Notify = nil
+	w.Now = func() time.Time { return now }
+	w.Sleep = func(_ context.Context, d time.Duration) error { now = now.Add(d); return nil }
+
+	// 9:30 封涨停 → 9:31 开板 → 10:30 跌破止损
+	w.Quote = func(_ context.Context, code string) (model.OrderBook, bool) {
+		b := model.OrderBook{PrevClose: 10, LimitUp: 11}
+		switch {
+		case now.Before(clock("09:31")):
+			b.Price = 11
+			b.Bids[0] = model.BookLevel{Price: 11, Vol: 50000}
+		case now.Before(clock("10:30")):
+			b.Price = 10.8
+			b.Bids[0] = model.BookLevel{Price: 10.79, Vol: 100}
+			b.Asks[0] = model.BookLevel{Price: 10.8, Vol: 300}
+		default:
+			b.Price = 9.4
+			b.Bids[0] = model.BookLevel{Price: 9.39, Vol: 100}
+			b.Asks[0] = model.BookLevel{Price: 9.4, Vol: 300}
+		}
+		return b, true
+	}
+	// 1m: 10:00 放量 10 倍；10:06 起价格从 10 掉到 9 (跌破 10:00 这根放量形成的 30m 均价)
+	w.Bars = func(_ context.Context, code, date string) ([]model.KLineData, error) {
+		var bars []model.KLineData
+		for m := clock("09:31"); !m.After(now) && !m.After(clock("15:00")); m = m.Add(time.Minute) {
+			if m.After(clock("11:30")) && m.Before(clock("13:01")) {
+				continue
+			}
+			vol, price := 100.0, 10.0
+			if m.Equal(clock("10:00")) {
+				vol = 1000
+			}
+			if !m.Before(clock("10:06")) {
+				price = 9
+			}
+			bars = append(bars, model.KLineData{Date: m.Format("2006-01-02 15:04"), Close: price, Amount: vol})
+		}
+		return bars, nil
+	}
+
+	counts := make(map[string]int)
+	for _, a := range w.Run(context.Background()) {
+		counts[a.Rule]++
+	}
+	want := map[string]int{
+		model.AlertLimitBreak: 1,
+		model.AlertVolSpike:   1,
+		model.AlertVWAPLoss:   1,
+		model.AlertStopLoss:   1,
+	}
+	for rule, n := range want {
+		if counts[rule] != n {
+			t.Errorf("%s fired %d times, want %d (all: %v)", rule, counts[rule], n, counts)
+		}
+	}
+	if len(counts) != len(want) {
+		t.Errorf("unexpected alerts: %v", counts)
+	}
+}
+
+func TestPoolTargetsParseLevels(t *testing.T) {
+	pick := &deepseek_reviewer.SniperJSON{StockCode: "600001"}
+	pick.Strategy.StopLoss = "跌破 10.52 元无脑砍"
+	pick.Strategy.TargetPrice = "冲高 5% 止盈"
+	targets := PoolTargets([]*model.StockInfo{{Code: "600001", Name: "A"}, {Code: "000002", Name: "B"}},
+		[]*deepseek_reviewer.SniperJSON{pick})
+	if len(targets) != 2 || targets[0].StopLoss != 10.52 || targets[0].TargetPrice != 0 || targets[1].StopLoss != 0 {
+		t.Errorf("targets = %+v", targets)
+	}
+}
+
+func TestParsePriceLevel(t *testing.T) {
+	cases := map[string]float64{
+		"跌破 10.52 元无脑砍":   10.52,
+		"止损 9.80元":        9.8,
+		"跌破MA5":           0,
+		"跌破 ma10 离场":      0,
+		"跌破20日线":          0,
+		"跌破MA5 (9.80元)":   9.8,
+		"跌破20日线即 12.3 止损": 12.3,
+		"冲高 5% 止盈":        0,
+		"30分钟级别破位":        0,
+		"目标 15 元":         15,
+	}
+	for text, want := range cases {
+		if got := ParsePriceLevel(text); got != want {
+			t.Errorf("ParsePriceLevel(%q) = %v, want %v", text, got, want)
+		}
+	}
+}
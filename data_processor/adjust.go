@@ -0,0 +1,88 @@
+package data_processor
+
+import (
+	"dragon-quant/model"
+	"math"
+)
+
+// --- 复权换算: 仓库存不复权K线 + 后复权因子，指标用复权价，涨跌停用不复权价 ---
+
+// FactorAt date 当天适用的后复权因子 (date 可带时间)。早于第一条时取第一条，没有因子时为 1
+func FactorAt(factors []model.AdjFactor, date string) float64 {
+	if len(factors) == 0 {
+		return 1
+	}
+	if len(date) > 10 {
+		date = date[:10]
+	}
+	f := factors[0].Factor
+	for _, a := range factors {
+		if a.Date > date {
+			break
+		}
+		f = a.Factor
+	}
+	return f
+}
+
+// AdjustBars 把不复权K线换算为 adj 口径: 开高低收按因子缩放 (前复权以最新因子为基准)，
+// Change 按换算后的收盘价重算，成交量 / 成交额不变。没有因子或 adj 为不复权时原样返回
+func AdjustBars(raw []model.KLineData, factors []model.AdjFactor, adj model.Adjust) []model.KLineData {
+	if len(factors) == 0 || adj == model.AdjustNone || adj == "" {
+		return raw
+	}
+	base := 1.0
+	if adj == model.AdjustForward {
+		base = factors[len(factors)-1].Factor
+	}
+	out := make([]model.KLineData, len(raw))
+	for i, k := range raw {
+		r := FactorAt(factors, k.Date) / base
+		k.Open, k.High, k.Low = round3(k.Open*r), round3(k.High*r), round3(k.Low*r)
+		k.Close = round3(k.Close * r)
+		k.Change = 0
+		if i > 0 {
+			k.Change = round3(k.Close - out[i-1].Close)
+		}
+		out[i] = k
+	}
+	return out
+}
+
+func round3(v float64) float64 {
+	return math.Round(v*1000) / 1000
+}
+
+// ExRights 除权除息事件 (因子变化的日期)
+func ExRights(factors []model.AdjFactor) []model.ExRight {
+	var events []model.ExRight
+	for i := 1; i < len(factors); i++ {
+		if prev := factors[i-1].Factor; prev > 0 && factors[i].Factor != prev {
+			events = append(events, model.ExRight{Date: factors[i].Date, Ratio: factors[i].Factor / prev})
+		}
+	}
+	return events
+}
+
+// RefPrevClose 第 i 根日K的除权参考价 (交易所计算涨跌停的前收盘): 前一根不复权收盘 ÷ 两日因子之比
+func RefPrevClose(raw []model.KLineData, factors []model.AdjFactor, i int) float64 {
+	if i <= 0 || i >= len(raw) {
+		return 0
+	}
+	prev := raw[i-1].Close
+	if f := FactorAt(factors, raw[i].Date); f > 0 {
+		prev = prev * FactorAt(factors, raw[i-1].Date) / f
+	}
+	return prev
+}
+
+// LimitUpDays 按不复权收盘价判断每根日K是否收在涨停价 (以除权参考价按板块涨跌幅计算)
+func LimitUpDays(code, name string, raw []model.KLineData, factors []model.AdjFactor) []bool {
+	out := make([]bool, len(raw))
+	for i := 1; i < len(raw); i++ {
+		if limit := LimitUpPrice(code, name, RefPrevClose(raw, factors, i)); limit > 0 {
+			out[i] = raw[i].Close >= limit-0.001
+		}
+	}
+	return out
+}
@@ -1,6 +1,7 @@
 package data_processor
 
 import (
+	"dragon-quant/calendar"
 	"dragon-quant/model"
 	"strings"
 )
@@ -31,16 +32,9 @@ func Analyze30mStrategy(klines []model.KLineData) string {
 		notes = append(notes, "MA20压制")
 	}
 
-	// 2. Momentum (Opening 30m of today)
-	// Assuming the last 8 bars are today (4 hours / 30 mins = 8 bars).
-	// This is a rough heuristic. A better way uses timestamps, but KLineData here only has Close/Change/Amount.
-	// We will look at the *relative* volume of the "Dragon Head" (8th bar from end, if n >= 8)
-	// But without timestamps, we can't be sure which bar is 09:30.
-	// Let's assume the user runs this AFTER market close or during market.
-	// If standard full day = 8 bars.
-
-	// Better heuristic: Check recent volume spike.
-	// "Dragon Head" = Huge volume on a rising bar.
+	// 2. Momentum (Opening 30m of the latest session)
+	// 按K线时间戳切出最近一个交易日 (30m K线以结束时间标记: 10:00 为开盘第一根, 15:00 为最后一根)
+	session := sessionBars(klines)
 
 	recentAvgVol := 0.0
 	for i := n - 5; i < n; i++ {
@@ -52,17 +46,41 @@ func Analyze30mStrategy(klines []model.KLineData) string {
 		notes = append(notes, "放量抢筹")
 	}
 
+	// "Dragon Head": 开盘第一根放量上攻 (对比上一交易日的平均 30m 成交额)
+	if len(session) > 0 && len(session) < n && barClock(session[0]) == "10:00" {
+		prev := klines[:n-len(session)]
+		prevAvg := 0.0
+		cnt := 0
+		for i := len(prev) - 1; i >= 0 && cnt < 8; i-- {
+			prevAvg += prev[i].Amount
+			cnt++
+		}
+		prevAvg /= float64(cnt)
+		if open := session[0]; open.Amount > prevAvg*2.0 && open.Change > 0 {
+			notes = append(notes, "开盘抢筹")
+		}
+	}
+
+	// 数据不是最近一个交易日 (停牌或拉取失败)
+	if day := barDay(current); day != "" {
+		cal := calendar.Default()
+		if latest := cal.LatestSession(calendar.Now()).Format("2006-01-02"); day < latest {
+			notes = append(notes, "非最新交易日")
+		}
+	}
+
 	// 3. Tail Effect (Last 30m)
-	// If we are at 14:30-15:00, this is the last bar.
-	// Check if "Grab" or "Dump".
+	// 只有最后一根是 15:00 收盘K线时才判断尾盘 (无时间戳时沿用旧假设: 最后一根即尾盘)
 	// Price rose > 1% in last 30m AND Volume > Avg*1.5
-	if current.Change/current.Close > 0.01 && current.Amount > recentAvgVol*1.5 {
-		notes = append(notes, "尾盘抢筹")
-	} else if current.Change/current.Close < -0.01 && current.Amount > recentAvgVol*1.5 {
-		notes = append(notes, "尾盘出逃")
-	} else if current.Close > ma20 && current.Change > 0 {
-		// MA20 support + positive close
-		notes = append(notes, "尾盘企稳")
+	if clock := barClock(current); clock == "" || clock == "15:00" {
+		if current.Change/current.Close > 0.01 && current.Amount > recentAvgVol*1.5 {
+			notes = append(notes, "尾盘抢筹")
+		} else if current.Change/current.Close < -0.01 && current.Amount > recentAvgVol*1.5 {
+			notes = append(notes, "尾盘出逃")
+		} else if current.Close > ma20 && current.Change > 0 {
+			// MA20 support + positive close
+			notes = append(notes, "尾盘企稳")
+		}
 	}
 
 	// 4. Intraday Pattern (N-Shape?)
@@ -82,3 +100,32 @@ func Analyze30mStrategy(klines []model.KLineData) string {
 	}
 	return strings.Join(notes, "/")
 }
+
+// sessionBars 返回与最后一根K线同一交易日的全部K线 (无时间戳时返回 nil)
+func sessionBars(klines []model.KLineData) []model.KLineData {
+	day := barDay(klines[len(klines)-1])
+	if day == "" {
+		return nil
+	}
+	i := len(klines) - 1
+	for i > 0 && barDay(klines[i-1]) == day {
+		i--
+	}
+	return klines[i:]
+}
+
+// barDay 返回 "2006-01-02 15:04" 中的日期部分
+func barDay(k model.KLineData) string {
+	if len(k.Date) < 10 {
+		return ""
+	}
+	return k.Date[:10]
+}
+
+// barClock 返回 "2006-01-02 15:04" 中的时刻部分
+func barClock(k model.KLineData) string {
+	if len(k.Date) < 16 {
+		return ""
+	}
+	return k.Date[11:16]
+}
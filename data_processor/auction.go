@@ -0,0 +1,86 @@
+package data_processor
+
+import (
+	"dragon-quant/calendar"
+	"dragon-quant/model"
+	"sort"
+	"time"
+)
+
+// AnalyzeAuction 从集合竞价时序中提取特征。
+// 9:15-9:20 可撤单，主力常在此挂大单拉高再撤 (撤单诱多)；9:20 后的挂单不可撤，价格走势才是真实意图。
+func AnalyzeAuction(ticks []model.AuctionTick) model.AuctionFeatures {
+	var f model.AuctionFeatures
+	if len(ticks) == 0 {
+		return f
+	}
+	ticks = append([]model.AuctionTick(nil), ticks...)
+	sort.Slice(ticks, func(i, j int) bool { return ticks[i].Time.Before(ticks[j].Time) })
+	f.Ticks = len(ticks)
+
+	pct := func(t model.AuctionTick) float64 {
+		if t.PrevClose <= 0 {
+			return 0
+		}
+		return (t.Price/t.PrevClose - 1) * 100
+	}
+	clock := func(t time.Time) time.Duration {
+		return t.Sub(time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location()))
+	}
+
+	// 9:20 前 (可撤单) 与 9:20 后 (不可撤) 分段
+	var pre, post []model.AuctionTick
+	for _, t := range ticks {
+		if clock(t.Time) < calendar.CancelDeadline {
+			pre = append(pre, t)
+		} else {
+			post = append(post, t)
+		}
+	}
+
+	final := ticks[len(ticks)-1]
+	f.OpenPct = pct(final)
+	f.Amount = final.Amount
+	if total := final.UnmatchedBuy + final.UnmatchedSell; total > 0 {
+		f.Imbalance = float64(final.UnmatchedBuy-final.UnmatchedSell) / float64(total)
+	}
+
+	// 9:20 基准: 9:20 后第一笔，没有则用 9:20 前最后一笔
+	base := final
+	if len(post) > 0 {
+		base = post[0]
+	} else if len(pre) > 0 {
+		base = pre[len(pre)-1]
+	}
+	f.TrendPct = f.OpenPct - pct(base)
+	if base.Volume > 0 {
+		f.VolGrowth = float64(final.Volume) / float64(base.Volume)
+	}
+
+	if len(pre) > 0 {
+		f.Pct920 = pct(pre[len(pre)-1])
+		f.PeakPct920 = pct(pre[0])
+		var peakBuy int64
+		for _, t := range pre {
+			f.PeakPct920 = max(f.PeakPct920, pct(t))
+			peakBuy = max(peakBuy, t.UnmatchedBuy)
+		}
+		if peakBuy > 0 && len(post) > 0 {
+			f.WithdrawPct = max(1-float64(post[0].UnmatchedBuy)/float64(peakBuy), 0)
+		}
+	}
+
+	switch {
+	case f.WithdrawPct >= 0.5 && f.PeakPct920-f.OpenPct >= 2:
+		f.Signal = "撤单诱多"
+	case f.TrendPct >= 1 && f.VolGrowth >= 1.5 && f.Imbalance > 0:
+		f.Signal = "抢筹"
+	case f.TrendPct >= 1:
+		f.Signal = "走强"
+	case f.TrendPct <= -1:
+		f.Signal = "走弱"
+	default:
+		f.Signal = "平稳"
+	}
+	return f
+}
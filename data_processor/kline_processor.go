@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"dragon-quant/model"
 	"fmt"
+	"strings"
 	"time"
 )
 
@@ -18,11 +19,36 @@ type AnomalyEvent struct {
 }
 
 type KlineProcessor struct {
-	duck *DuckDB
+	duck   *DuckDB
+	source string // 查询的数据源 (默认 kline_1m 临时表)
 }
 
 func NewKlineProcessor(d *DuckDB) *KlineProcessor {
-	return &KlineProcessor{duck: d}
+	return &KlineProcessor{duck: d, source: "kline_1m"}
+}
+
+// 🆕 NewKlineProcessorForCode 直接查询仓库 (warehouse) 中 bars_1m 表里指定代码 (纯代码或 sh000001 这类带前缀写法) 的数据，无需 LoadData。
+// since 非零时只取该时刻 (墙上时间) 之后的K线 (见 warehouse.Min1Window)；零值表示不限 (盯盘增量检测需要前一日的滚动均量)。
+func NewKlineProcessorForCode(d *DuckDB, code string, since time.Time) (*KlineProcessor, error) {
+	for _, c := range code {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') {
+			return nil, fmt.Errorf("invalid stock code %q", code)
+		}
+	}
+	where := fmt.Sprintf("code = '%s'", code)
+	if !since.IsZero() {
+		where += fmt.Sprintf(" AND time >= TIMESTAMP '%s'", since.Format("2006-01-02 15:04:05"))
+	}
+	source := fmt.Sprintf("(SELECT time, close, amount AS volume FROM bars_1m WHERE %s) AS kline_1m", where)
+	return &KlineProcessor{duck: d, source: source}, nil
+}
+
+// sql 把查询中的 kline_1m 替换为实际数据源
+func (p *KlineProcessor) sql(query string) string {
+	if p.source == "" || p.source == "kline_1m" {
+		return query
+	}
+	return strings.ReplaceAll(query, "FROM kline_1m", "FROM "+p.source)
 }
 
 // LoadData loads 1m K-line data into DuckDB kline_1m table
@@ -104,7 +130,7 @@ func (p *KlineProcessor) DetectAnomalies() ([]AnomalyEvent, error) {
 	LIMIT 5;
 	`
 
-	rows, err := p.duck.DB.Query(query)
+	rows, err := p.duck.DB.Query(p.sql(query))
 	if err != nil {
 		return nil, fmt.Errorf("anomaly query failed: %w", err)
 	}
@@ -131,6 +157,40 @@ func (p *KlineProcessor) DetectAnomalies() ([]AnomalyEvent, error) {
 	return events, nil
 }
 
+// 🆕 DetectVolumeSpikesSince 增量检测: 返回 since 之后成交量超过前 30 分钟均量 volMult 倍的K线 (时间升序)。
+// 滚动均量仍基于全部历史计算，盯盘模式每轮只取新增部分。
+func (p *KlineProcessor) DetectVolumeSpikesSince(since time.Time, volMult float64) ([]AnomalyEvent, error) {
+	query := `
+	WITH stats AS (
+		SELECT
+			time, close, volume,
+			AVG(volume) OVER (ORDER BY time ROWS BETWEEN 30 PRECEDING AND 1 PRECEDING) as roll_avg_vol,
+			STDDEV(close) OVER (ORDER BY time ROWS BETWEEN 30 PRECEDING AND 1 PRECEDING) as roll_std_price
+		FROM kline_1m
+	)
+	SELECT time, close, volume, roll_avg_vol, COALESCE(roll_std_price, 0)
+	FROM stats
+	WHERE time > ? AND roll_avg_vol > 0 AND volume > ? * roll_avg_vol
+	ORDER BY time;
+	`
+	rows, err := p.duck.DB.Query(p.sql(query), since, volMult)
+	if err != nil {
+		return nil, fmt.Errorf("volume spike query failed: %w", err)
+	}
+	defer rows.Close()
+
+	var events []AnomalyEvent
+	for rows.Next() {
+		var e AnomalyEvent
+		if err := rows.Scan(&e.Time, &e.Close, &e.Volume, &e.AvgVol, &e.StdDev); err != nil {
+			return nil, err
+		}
+		e.Reason = fmt.Sprintf("VolSpike(x%.1f)", e.Volume/e.AvgVol)
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
 // GetContextWindow returns data in [time - window, time + window]
 func (p *KlineProcessor) GetContextWindow(eventTime time.Time, windowMinutes int) ([]model.KLineData, error) {
 	// Calculate range
@@ -143,7 +203,7 @@ func (p *KlineProcessor) GetContextWindow(eventTime time.Time, windowMinutes int
 		WHERE time >= ? AND time <= ?
 		ORDER BY time ASC
 	`
-	rows, err := p.duck.DB.Query(query, start, end)
+	rows, err := p.duck.DB.Query(p.sql(query), start, end)
 	if err != nil {
 		return nil, err
 	}
@@ -232,7 +292,7 @@ func (p *KlineProcessor) AnalyzeVolatility() ([]AnalysisEvent, error) {
 	LIMIT 10; -- Focus on top events
 	`
 
-	rows, err := p.duck.DB.Query(query)
+	rows, err := p.duck.DB.Query(p.sql(query))
 	if err != nil {
 		return nil, fmt.Errorf("analyze query failed: %w", err)
 	}
@@ -0,0 +1,47 @@
+package data_processor
+
+import (
+	"dragon-quant/model"
+	"math"
+)
+
+// LimitUpPrice 按板块规则推算涨停价 (接口未返回 f51 时使用):
+// 主板 10%，ST 5%，创业板/科创板 20%，北交所 30%；四舍五入到分。指数 / 板块没有涨停价，返回 0
+func LimitUpPrice(code, name string, prevClose float64) float64 {
+	pct := model.LimitPct(code, name)
+	if pct <= 0 {
+		return 0
+	}
+	return math.Round(prevClose*(1+pct)*100) / 100
+}
+
+// AnalyzeOrderBook 根据五档盘口计算不平衡度与涨停封单 (需先 FetchStockDetails)
+func AnalyzeOrderBook(s *model.StockInfo) {
+	b := s.OrderBook
+	if b == nil {
+		return
+	}
+
+	var bidVol, askVol int64
+	for i := range b.Bids {
+		bidVol += b.Bids[i].Vol
+		askVol += b.Asks[i].Vol
+	}
+	s.BookImbalance = 0
+	if total := bidVol + askVol; total > 0 {
+		s.BookImbalance = float64(bidVol-askVol) / float64(total)
+	}
+
+	// 封板: 买一挂在涨停价且卖盘为空
+	limitUp := b.LimitUp
+	if limitUp <= 0 && b.PrevClose > 0 {
+		limitUp = LimitUpPrice(s.Code, s.Name, b.PrevClose)
+	}
+	s.SealAmt, s.SealRatio = 0, 0
+	if limitUp > 0 && math.Abs(b.Bids[0].Price-limitUp) < 0.005 && askVol == 0 {
+		s.SealAmt = float64(b.Bids[0].Vol) * 100 * limitUp
+		if b.FloatShares > 0 {
+			s.SealRatio = float64(b.Bids[0].Vol) * 100 / b.FloatShares * 100
+		}
+	}
+}
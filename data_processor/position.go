@@ -0,0 +1,53 @@
+package data_processor
+
+import (
+	"fmt"
+)
+
+// PositionStats 持仓在最新 1m 数据上的盈亏与成本位置
+type PositionStats struct {
+	LastPrice     float64 `json:"last_price"`
+	VWAP30m       float64 `json:"vwap_30m"`       // 最近一根 30m K线的成交均价
+	Cost          float64 `json:"cost"`           // 成本价
+	Shares        int     `json:"shares"`         // 持股数量
+	Sellable      int     `json:"sellable"`       // T+1 可卖数量
+	MarketValue   float64 `json:"market_value"`   // 市值
+	UnrealizedPnL float64 `json:"unrealized_pnl"` // 浮动盈亏 (元)
+	UnrealizedPct float64 `json:"unrealized_pct"` // 浮动盈亏 (%)
+	CostVsVWAP    float64 `json:"cost_vs_vwap"`   // (成本-VWAP)/VWAP (%)，>0 表示成本高于主力均价
+}
+
+// PositionStats 基于 kline_1m 计算最新价与最近 30m VWAP，并结合成本/数量得出盈亏
+func (p *KlineProcessor) PositionStats(cost float64, shares, sellable int) (PositionStats, error) {
+	query := `
+	WITH last_bucket AS (
+		SELECT to_timestamp(floor(epoch(MAX(time))/1800)*1800) AS bucket_time FROM kline_1m
+	)
+	SELECT
+		(SELECT close FROM kline_1m ORDER BY time DESC LIMIT 1) AS last_price,
+		(SELECT CASE WHEN SUM(volume) > 0 THEN SUM(close * volume) / SUM(volume) ELSE AVG(close) END
+		 FROM kline_1m, last_bucket
+		 WHERE to_timestamp(floor(epoch(time)/1800)*1800) = last_bucket.bucket_time) AS vwap_30m
+	`
+
+	stats := PositionStats{Cost: cost, Shares: shares, Sellable: sellable}
+	var last, vwap *float64
+	if err := p.duck.DB.QueryRow(p.sql(query)).Scan(&last, &vwap); err != nil {
+		return stats, fmt.Errorf("position query failed: %w", err)
+	}
+	if last == nil || vwap == nil {
+		return stats, fmt.Errorf("position query failed: no kline data")
+	}
+
+	stats.LastPrice = *last
+	stats.VWAP30m = *vwap
+	stats.MarketValue = stats.LastPrice * float64(shares)
+	if cost > 0 {
+		stats.UnrealizedPnL = (stats.LastPrice - cost) * float64(shares)
+		stats.UnrealizedPct = (stats.LastPrice - cost) / cost * 100
+	}
+	if stats.VWAP30m > 0 && cost > 0 {
+		stats.CostVsVWAP = (cost - stats.VWAP30m) / stats.VWAP30m * 100
+	}
+	return stats, nil
+}
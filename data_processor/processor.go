@@ -144,34 +144,31 @@ func CalculateRSI(data []model.KLineData, period int) float64 {
 	return 100.0 - (100.0 / (1.0 + avgGain/avgLoss))
 }
 
-func AnalyzeDragonHabit(data []model.KLineData) string {
-	// 回溯过去 30 天，找到所有涨停 (>9.5%) 的次日表现
+// AnalyzeDragonHabit 过去 30 天涨停的次日表现。涨停按不复权价与除权参考价判断 (20cm / 30cm / ST 各按其涨幅)，
+// 次日强弱同样相对除权参考价，除权日不会被误判为大跌
+func AnalyzeDragonHabit(code, name string, raw []model.KLineData, factors []model.AdjFactor) string {
 	limitUps := 0
 	continued := 0 // 持续连板
 	lowOpen := 0   // 低开
 
-	n := len(data)
+	n := len(raw)
 	if n < 2 {
 		return "无记忆"
 	}
+	sealed := LimitUpDays(code, name, raw, factors)
 
-	// 不包括今天 (data[n-1] is today/latest)
-	for i := n - 30; i < n-1; i++ {
-		if i < 0 {
+	// 不包括今天 (raw[n-1] is today/latest)
+	for i := max(n-30, 0); i < n-1; i++ {
+		if !sealed[i] {
 			continue
 		}
-		if data[i].Change/data[i].Close*100 > 9.5 || (i > 0 && data[i].Change > 0 && (data[i].Close-data[i-1].Close)/data[i-1].Close > 0.095) {
-			// Found a Limit Up
-			limitUps++
-			nextDay := data[i+1]
-			// Check next day open/close (approximation: using KLine data we only have Close/Change.
-			// History API k-line usually has Open/High/Low but here structs only have Close/Change.
-			// Let's infer strength from Close Change.
-			if nextDay.Change > 0 {
-				continued++
-			} else {
-				lowOpen++
-			}
+		// Found a Limit Up
+		limitUps++
+		// 只有收盘价: 次日收盘高于除权参考价视为继续走强
+		if raw[i+1].Close > RefPrevClose(raw, factors, i+1) {
+			continued++
+		} else {
+			lowOpen++
 		}
 	}
 
@@ -224,12 +221,28 @@ func GenerateTechNotes(s *model.StockInfo) bool {
 	if s.CallAuctionAmt > 50000000 {
 		notes = append(notes, "竞价爆量")
 	}
+	if s.SealAmt > 0 {
+		notes = append(notes, fmt.Sprintf("封单%.1f亿(%.2f%%流通)", s.SealAmt/1e8, s.SealRatio))
+	} else if s.BookImbalance <= -0.6 {
+		notes = append(notes, "盘口卖压")
+	} else if s.BookImbalance >= 0.6 {
+		notes = append(notes, "盘口托单")
+	}
+	if s.Auction != nil && s.Auction.Signal != "" && s.Auction.Signal != "平稳" {
+		notes = append(notes, "竞价"+s.Auction.Signal)
+	}
 
 	// 龙虎榜加持
 	if s.LHBNet > 10000000 {
 		notes = append(notes, "🐉机构大买")
 		s.DragonTag += "/龙虎榜"
 	}
+	for _, seat := range s.HotSeats {
+		if seat.Alias != "" && seat.Kind != "机构" && seat.Kind != "北向" {
+			notes = append(notes, "🎯"+seat.Alias)
+			break
+		}
+	}
 
 	// 股性加持
 	if strings.Contains(s.DragonHabit, "连板王") {
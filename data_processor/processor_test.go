@@ -2,6 +2,7 @@ package data_processor
 
 import (
 	"dragon-quant/model"
+	"strings"
 	"testing"
 	"time"
 )
@@ -92,3 +93,163 @@ func TestKlineProcessor(t *testing.T) {
 		t.Error("Spike not found in context window data")
 	}
 }
+
+func TestPositionStats(t *testing.T) {
+	duck, err := NewDuckDB("")
+	if err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer duck.Close()
+
+	proc := NewKlineProcessor(duck)
+
+	// 10:00-10:59: 前 30 分钟价格 10 元, 后 30 分钟 12 元 (量相同)
+	var klines []model.KLineData
+	startTime, _ := time.Parse("2006-01-02 15:04", "2026-01-01 10:00")
+	for i := 0; i < 60; i++ {
+		price := 10.0
+		if i >= 30 {
+			price = 12.0
+		}
+		klines = append(klines, model.KLineData{
+			Date:   startTime.Add(time.Duration(i) * time.Minute).Format("2006-01-02 15:04"),
+			Close:  price,
+			Amount: 100,
+		})
+	}
+	if err := proc.LoadData(klines); err != nil {
+		t.Fatalf("LoadData failed: %v", err)
+	}
+
+	stats, err := proc.PositionStats(11.0, 1000, 600)
+	if err != nil {
+		t.Fatalf("PositionStats failed: %v", err)
+	}
+	if stats.LastPrice != 12.0 || stats.VWAP30m != 12.0 {
+		t.Errorf("Unexpected price/vwap: %.2f / %.2f", stats.LastPrice, stats.VWAP30m)
+	}
+	if stats.UnrealizedPnL != 1000 || stats.Sellable != 600 {
+		t.Errorf("Unexpected pnl/sellable: %.2f / %d", stats.UnrealizedPnL, stats.Sellable)
+	}
+	if stats.CostVsVWAP >= 0 {
+		t.Errorf("Expected cost below VWAP, got %.2f%%", stats.CostVsVWAP)
+	}
+}
+
+func TestAnalyze30mStrategySession(t *testing.T) {
+	clocks := []string{"10:00", "10:30", "11:00", "11:30", "13:30", "14:00", "14:30", "15:00"}
+	var klines []model.KLineData
+	price := 10.0
+	for _, day := range []string{"2026-01-07", "2026-01-08", "2026-01-09"} {
+		for i, c := range clocks {
+			amt := 1e7
+			change := 0.01
+			if day == "2026-01-09" && i == 0 {
+				amt = 5e7 // 开盘第一根放量上攻
+				change = 0.2
+			}
+			price += change
+			klines = append(klines, model.KLineData{Date: day + " " + c, Close: price, Change: change, Amount: amt})
+		}
+	}
+
+	// 截到 01-09 午盘: 有开盘抢筹，不应出现尾盘判断
+	note := Analyze30mStrategy(klines[:20])
+	if !strings.Contains(note, "开盘抢筹") {
+		t.Errorf("expected 开盘抢筹, got %q", note)
+	}
+	if strings.Contains(note, "尾盘") {
+		t.Errorf("intraday bars should not produce tail notes, got %q", note)
+	}
+
+	// 完整交易日: 最后一根是 15:00，尾盘判断生效
+	if note := Analyze30mStrategy(klines); !strings.Contains(note, "尾盘") {
+		t.Errorf("expected tail note on 15:00 bar, got %q", note)
+	}
+}
+
+func TestAnalyzeAuction(t *testing.T) {
+	tick := func(clock string, price float64, vol, buy, sell int64) model.AuctionTick {
+		ts, _ := time.Parse("2006-01-02 15:04:05", "2026-01-09 "+clock)
+		return model.AuctionTick{Time: ts, Price: price, Volume: vol, UnmatchedBuy: buy, UnmatchedSell: sell, PrevClose: 10}
+	}
+
+	// 9:15-9:20 挂大买单拉到 +8%，9:20 后买单撤光，价格回落到 +1%
+	fake := []model.AuctionTick{
+		tick("09:15:10", 10.5, 100, 5000, 0),
+		tick("09:18:00", 10.8, 300, 20000, 0),
+		tick("09:20:10", 10.3, 500, 2000, 1000),
+		tick("09:25:00", 10.1, 800, 0, 3000),
+	}
+	f := AnalyzeAuction(fake)
+	if f.Signal != "撤单诱多" || f.WithdrawPct < 0.85 {
+		t.Errorf("fake pump: signal=%s withdraw=%.2f", f.Signal, f.WithdrawPct)
+	}
+	if f.PeakPct920 < 7.9 || f.OpenPct > 1.1 {
+		t.Errorf("peak=%.2f open=%.2f", f.PeakPct920, f.OpenPct)
+	}
+
+	// 9:20 后价格与匹配量持续走高，买盘未匹配
+	strong := []model.AuctionTick{
+		tick("09:16:00", 10.2, 100, 1000, 500),
+		tick("09:20:05", 10.3, 400, 2000, 500),
+		tick("09:25:00", 10.6, 1200, 5000, 0),
+	}
+	if f := AnalyzeAuction(strong); f.Signal != "抢筹" || f.Imbalance != 1 {
+		t.Errorf("strong: signal=%s imbalance=%.2f", f.Signal, f.Imbalance)
+	}
+}
+
+func TestAnalyzeOrderBook(t *testing.T) {
+	// 涨停封死: 买一 12 万手挂在涨停价，流通 5 亿股
+	book := &model.OrderBook{PrevClose: 10, FloatShares: 5e8}
+	book.Bids[0] = model.BookLevel{Price: 11.0, Vol: 120000}
+	book.Bids[1] = model.BookLevel{Price: 10.99, Vol: 3500}
+	s := &model.StockInfo{Code: "002001", OrderBook: book}
+	AnalyzeOrderBook(s)
+	if s.SealAmt != 120000*100*11.0 || s.SealRatio != 2.4 || s.BookImbalance != 1 {
+		t.Errorf("seal=%.0f ratio=%.2f imbalance=%.2f", s.SealAmt, s.SealRatio, s.BookImbalance)
+	}
+
+	// 创业板 20% 涨停，买一未到涨停价: 无封单，卖压重
+	book = &model.OrderBook{PrevClose: 10}
+	book.Bids[0] = model.BookLevel{Price: 11.5, Vol: 100}
+	book.Asks[0] = model.BookLevel{Price: 11.51, Vol: 900}
+	s = &model.StockInfo{Code: "300001", OrderBook: book}
+	AnalyzeOrderBook(s)
+	if s.SealAmt != 0 || s.BookImbalance != -0.8 {
+		t.Errorf("seal=%.0f imbalance=%.2f", s.SealAmt, s.BookImbalance)
+	}
+	if p := LimitUpPrice("300001", "", 10); p != 12 {
+		t.Errorf("LimitUpPrice = %.2f", p)
+	}
+}
+
+func TestAdjustAndLimitUp(t *testing.T) {
+	raw := []model.KLineData{
+		{Date: "2026-06-01", Close: 10.00}, {Date: "2026-06-02", Close: 12.00}, {Date: "2026-06-03", Close: 14.40},
+		{Date: "2026-06-04", Close: 13.80}, // 除息日: 参考价 14.40/1.05 = 13.71，实际收涨
+		{Date: "2026-06-05", Close: 16.56}, {Date: "2026-06-08", Close: 16.00},
+	}
+	factors := []model.AdjFactor{{Date: "2026-06-01", Factor: 1}, {Date: "2026-06-04", Factor: 1.05}, {Date: "2026-06-08", Factor: 1.05}}
+
+	qfq := AdjustBars(raw, factors, model.AdjustForward)
+	if qfq[0].Close != 9.524 || qfq[3].Close != 13.80 || qfq[3].Change != 0.086 || raw[0].Close != 10 {
+		t.Errorf("qfq = %+v", qfq)
+	}
+	if hfq := AdjustBars(raw, factors, model.AdjustBackward); hfq[0].Close != 10 || hfq[5].Close != 16.8 {
+		t.Errorf("hfq = %+v", hfq)
+	}
+	if ev := ExRights(factors); len(ev) != 1 || ev[0].Date != "2026-06-04" || ev[0].Ratio != 1.05 {
+		t.Errorf("ex-rights = %+v", ev)
+	}
+
+	// 创业板 20%: 06-02、06-03、06-05 涨停；除息日不算下跌
+	sealed := LimitUpDays("300001", "特锐德", raw, factors)
+	if !sealed[1] || !sealed[2] || sealed[3] || !sealed[4] || sealed[5] {
+		t.Errorf("limit-up days = %v", sealed)
+	}
+	if got := AnalyzeDragonHabit("300001", "特锐德", raw, factors); got != "中性(2/3)" {
+		t.Errorf("habit = %q", got)
+	}
+}
@@ -31,6 +31,14 @@ func NewRiskConfig() model.RiskConfig {
 			"首板基因",
 		},
 		MinBoardCount: 1, // At least once
+
+		MinSeatSamples:  5,
+		GoodSeatWinRate: 0.6,
+		BadSeatWinRate:  0.35,
+
+		MinSealRatio:     0.5,
+		StrongSealRatio:  2.0,
+		MinBookImbalance: -0.6,
 	}
 }
 
@@ -94,6 +102,24 @@ func RiskScreen(stocks []*model.StockInfo, config model.RiskConfig) []model.Risk
 			}
 		}
 
+		// 🆕 一日游/低胜率席位接盘风险
+		if seat, ok := findSeat(stock.HotSeats, config.MinSeatSamples, func(p model.SeatFollow) bool {
+			return p.WinRate <= config.BadSeatWinRate || p.Style == "一日游"
+		}); ok {
+			riskScore += 2
+			reasons = append(reasons, fmt.Sprintf("席位砸盘风险:%s(胜率%.0f%%/%s)", seatLabel(seat), seat.WinRate*100, seat.Style))
+		}
+
+		// 🆕 盘口: 封单薄弱 / 卖压过重
+		if stock.SealAmt > 0 && stock.SealRatio < config.MinSealRatio {
+			riskScore += 1
+			reasons = append(reasons, fmt.Sprintf("封单薄弱(%.2f%%流通)", stock.SealRatio))
+		}
+		if stock.OrderBook != nil && stock.SealAmt == 0 && stock.BookImbalance <= config.MinBookImbalance {
+			riskScore += 1
+			reasons = append(reasons, fmt.Sprintf("盘口卖压(%.2f)", stock.BookImbalance))
+		}
+
 		// ==== 2. 加分项 (Bonus) ====
 		bonus := 0
 
@@ -117,6 +143,20 @@ func RiskScreen(stocks []*model.StockInfo, config model.RiskConfig) []model.Risk
 			reasons = append(reasons, fmt.Sprintf("加分:今日流入%.1f亿", stock.NetInflow/100000000))
 		}
 
+		// 🆕 高胜率席位买入
+		if seat, ok := findSeat(stock.HotSeats, config.MinSeatSamples, func(p model.SeatFollow) bool {
+			return p.WinRate >= config.GoodSeatWinRate && p.Style != "一日游"
+		}); ok {
+			bonus++
+			reasons = append(reasons, fmt.Sprintf("加分:席位%s(胜率%.0f%%,次日%+.1f%%)", seatLabel(seat), seat.WinRate*100, seat.AvgNextRet))
+		}
+
+		// 🆕 强封单
+		if stock.SealAmt > 0 && stock.SealRatio >= config.StrongSealRatio {
+			bonus++
+			reasons = append(reasons, fmt.Sprintf("加分:封单%.1f亿(%.2f%%流通)", stock.SealAmt/1e8, stock.SealRatio))
+		}
+
 		// 3. 最终评分
 		finalScore := riskScore - bonus
 		if finalScore < 1 {
@@ -153,3 +193,20 @@ func RiskScreen(stocks []*model.StockInfo, config model.RiskConfig) []model.Risk
 
 	return results
 }
+
+// findSeat 返回第一个样本充足且满足条件的买入席位
+func findSeat(seats []model.SeatFollow, minSamples int, match func(model.SeatFollow) bool) (model.SeatFollow, bool) {
+	for _, seat := range seats {
+		if seat.Samples >= minSamples && seat.Samples > 0 && match(seat) {
+			return seat, true
+		}
+	}
+	return model.SeatFollow{}, false
+}
+
+func seatLabel(seat model.SeatFollow) string {
+	if seat.Alias != "" {
+		return seat.Alias
+	}
+	return seat.Name
+}
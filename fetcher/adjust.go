@@ -0,0 +1,77 @@
+package fetcher
+
+import (
+	"context"
+	"dragon-quant/model"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// --- 复权: 东财 K 线 fqt=0 不复权 / 1 前复权 / 2 后复权 ---
+// 仓库只存不复权K线 (前复权价会在每次除权后整体变化，增量入库会前后错位)，
+// 另存后复权因子，读取时再换算。
+
+// fqt 东财 K 线接口的复权参数
+func fqt(adj model.Adjust) int {
+	switch adj {
+	case model.AdjustForward:
+		return 1
+	case model.AdjustBackward:
+		return 2
+	}
+	return 0
+}
+
+// unsupportedAdjust 数据源不提供该复权口径 (按空数据处理，不记入数据质量报告，由数据源链切换)
+func unsupportedAdjust(op, code string, adj model.Adjust) error {
+	return &FetchError{Op: op, Target: code, Kind: ErrEmpty, Err: fmt.Errorf("adjust %s not supported", adj)}
+}
+
+// FetchAdjFactors 最近 limit 个交易日的后复权因子 (只保留变化点和最后一天)。
+// 同时拉取不复权与后复权日K，因子 = 后复权收盘 / 不复权收盘
+func FetchAdjFactors(ctx context.Context, code string, limit int) ([]model.AdjFactor, error) {
+	raw, err := FetchDailyAdj(ctx, code, limit, model.AdjustNone)
+	if err != nil {
+		return nil, err
+	}
+	hfq, err := FetchDailyAdj(ctx, code, limit, model.AdjustBackward)
+	if err != nil {
+		return nil, err
+	}
+	factors := adjFactors(raw, hfq)
+	if len(factors) == 0 {
+		return nil, fail(ctx, "FetchAdjFactors", code, ErrMismatch, errors.New("raw and adjusted bars share no dates"))
+	}
+	return factors, nil
+}
+
+// adjFactors 按日期对齐两组日K求因子。两边收盘价都四舍五入到分，
+// 相对误差小于 0.01/价格 的因子变化视为舍入噪声而非除权
+func adjFactors(raw, hfq []model.KLineData) []model.AdjFactor {
+	adjusted := make(map[string]float64, len(hfq))
+	for _, k := range hfq {
+		adjusted[k.Date] = k.Close
+	}
+	var factors []model.AdjFactor
+	var lastDate string
+	for _, k := range raw {
+		h, ok := adjusted[k.Date]
+		if !ok || k.Close <= 0 || h <= 0 {
+			continue
+		}
+		f := h / k.Close
+		lastDate = k.Date
+		if n := len(factors); n > 0 {
+			prev := factors[n-1].Factor
+			if math.Abs(f-prev)/prev <= 0.01/k.Close+0.01/h {
+				continue
+			}
+		}
+		factors = append(factors, model.AdjFactor{Date: k.Date, Factor: math.Round(f*1e6) / 1e6})
+	}
+	if n := len(factors); n > 0 && factors[n-1].Date != lastDate {
+		factors = append(factors, model.AdjFactor{Date: lastDate, Factor: factors[n-1].Factor})
+	}
+	return factors
+}
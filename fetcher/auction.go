@@ -0,0 +1,38 @@
+package fetcher
+
+import (
+	"context"
+	"dragon-quant/model"
+)
+
+// FetchAuctionTick 集合竞价期间的虚拟撮合快照。
+// 竞价时买一/卖一 (及买二/卖二) 挂在虚拟匹配价上，该价位的挂单量超出匹配量的部分即未匹配量。
+func FetchAuctionTick(ctx context.Context, code string) (model.AuctionTick, bool) {
+	// f47 匹配量, f48 匹配金额
+	q, err := fetchQuote(ctx, "FetchAuctionTick", code, bookFields+",f47,f48")
+	if err != nil || q.num("f43") <= 0 {
+		return model.AuctionTick{}, false
+	}
+	book := q.orderBook()
+	volume := int64(q.num("f47"))
+	atPrice := func(levels []model.BookLevel) int64 {
+		var v int64
+		for _, l := range levels {
+			if l.Price == book.Price {
+				v += l.Vol
+			}
+		}
+		return max(v-volume, 0)
+	}
+
+	return model.AuctionTick{
+		Code:          code,
+		Time:          book.Time,
+		Price:         book.Price,
+		Volume:        volume,
+		Amount:        q.num("f48"),
+		UnmatchedBuy:  atPrice(book.Bids[:2]),
+		UnmatchedSell: atPrice(book.Asks[:2]),
+		PrevClose:     book.PrevClose,
+	}, true
+}
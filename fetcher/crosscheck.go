@@ -0,0 +1,160 @@
+package fetcher
+
+import (
+	"context"
+	"dragon-quant/model"
+	"fmt"
+	"math"
+	"sync"
+)
+
+// --- 交叉核对: 同一只股票从各数据源拉行情，比较现价、成交量与最新日 K 收盘价 ---
+// 复权只改变历史 K 线，最新一根在各口径下一致，所以日 K 只比较同一天的最新一根。
+
+// CrossCheckOptions 容差 (百分比)，零值取默认
+type CrossCheckOptions struct {
+	PriceTolPct  float64 // 现价 / 收盘价差异，默认 0.5
+	VolumeTolPct float64 // 成交量差异，默认 5 (各源快照时间不同，盘中成交量天然有出入)
+}
+
+// Discrepancy 某字段在两个数据源之间的差异
+type Discrepancy struct {
+	Field    string // price / volume / close
+	Base     string // 基准数据源 (链上第一个拉取成功的)
+	Other    string
+	BaseVal  float64
+	OtherVal float64
+	DiffPct  float64
+}
+
+func (d Discrepancy) String() string {
+	return fmt.Sprintf("%s: %s %.2f vs %s %.2f (差 %.2f%%)", d.Field, d.Base, d.BaseVal, d.Other, d.OtherVal, d.DiffPct)
+}
+
+// CrossResult 一只股票的多源核对结果
+type CrossResult struct {
+	Code   string
+	Quotes []model.Quote              // 各源行情 (按链顺序，失败的不含)
+	Bars   map[string]model.KLineData // 数据源 -> 最新日 K
+	Errors map[string]error           // 数据源 -> 拉取失败原因
+	Diffs  []Discrepancy
+}
+
+// CrossCheck 并发拉取 c 中每个数据源的行情与最新日 K 并两两对比基准源。
+// 不一致之处以 ErrMismatch 记入数据质量报告
+func CrossCheck(ctx context.Context, c Chain, code string, opts CrossCheckOptions) CrossResult {
+	if opts.PriceTolPct <= 0 {
+		opts.PriceTolPct = 0.5
+	}
+	if opts.VolumeTolPct <= 0 {
+		opts.VolumeTolPct = 5
+	}
+
+	type fetched struct {
+		quote    model.Quote
+		bar      model.KLineData
+		quoteErr error
+		barErr   error
+	}
+	out := make([]fetched, len(c))
+	var wg sync.WaitGroup
+	for i, src := range c {
+		wg.Add(1)
+		go func(i int, src Source) {
+			defer wg.Done()
+			out[i].quote, out[i].quoteErr = src.Quote(ctx, code)
+			bars, err := src.Daily(ctx, code, 1, model.AdjustNone) // 新浪只有不复权，统一按不复权比对
+			if err == nil && len(bars) > 0 {
+				out[i].bar = bars[len(bars)-1]
+			} else if err == nil {
+				err = errEmpty
+			}
+			out[i].barErr = err
+		}(i, src)
+	}
+	wg.Wait()
+
+	res := CrossResult{Code: code, Bars: make(map[string]model.KLineData), Errors: make(map[string]error)}
+	var baseQuote, baseBar string
+	var base model.Quote
+	var baseK model.KLineData
+	for i, src := range c {
+		name, f := src.Name(), out[i]
+		if f.quoteErr != nil {
+			res.Errors[name] = f.quoteErr
+		} else {
+			res.Quotes = append(res.Quotes, f.quote)
+			if baseQuote == "" {
+				baseQuote, base = name, f.quote
+			} else {
+				res.compare("price", baseQuote, name, base.Price, f.quote.Price, opts.PriceTolPct)
+				res.compare("volume", baseQuote, name, base.Volume, f.quote.Volume, opts.VolumeTolPct)
+			}
+		}
+		if f.barErr != nil {
+			if res.Errors[name] == nil {
+				res.Errors[name] = f.barErr
+			}
+			continue
+		}
+		res.Bars[name] = f.bar
+		if baseBar == "" {
+			baseBar, baseK = name, f.bar
+		} else if f.bar.Date == baseK.Date {
+			res.compare("close", baseBar, name, baseK.Close, f.bar.Close, opts.PriceTolPct)
+		}
+	}
+
+	for _, d := range res.Diffs {
+		fail(ctx, "CrossCheck", code, ErrMismatch, fmt.Errorf("%s", d))
+	}
+	return res
+}
+
+// compare 差异超过 tolPct 时记一条 Discrepancy (基准值为 0 时不比较)
+func (r *CrossResult) compare(field, base, other string, baseVal, otherVal, tolPct float64) {
+	if baseVal == 0 {
+		return
+	}
+	diff := math.Abs(otherVal-baseVal) / math.Abs(baseVal) * 100
+	if diff > tolPct {
+		r.Diffs = append(r.Diffs, Discrepancy{Field: field, Base: base, Other: other, BaseVal: baseVal, OtherVal: otherVal, DiffPct: diff})
+	}
+}
+
+// PrintCrossCheck 打印各源的现价 / 成交量 / 最新日 K 以及不一致之处
+func PrintCrossCheck(c Chain, results []CrossResult) {
+	mismatched := 0
+	for _, r := range results {
+		fmt.Printf("\n🔍 [交叉核对] %s\n", r.Code)
+		quotes := make(map[string]model.Quote, len(r.Quotes))
+		for _, q := range r.Quotes {
+			quotes[q.Source] = q
+		}
+		for _, src := range c {
+			name := src.Name()
+			line := fmt.Sprintf("   %-10s", name)
+			if q, ok := quotes[name]; ok {
+				line += fmt.Sprintf(" 现价 %9.2f  成交量 %12.0f 手  %s", q.Price, q.Volume, q.Time.Format("15:04:05"))
+			}
+			if k, ok := r.Bars[name]; ok {
+				line += fmt.Sprintf("  日K %s 收 %.2f", k.Date, k.Close)
+			}
+			if err := r.Errors[name]; err != nil {
+				line += fmt.Sprintf("  ❌ %s", KindOf(err))
+			}
+			fmt.Println(line)
+		}
+		for _, d := range r.Diffs {
+			fmt.Printf("   ❗ %s\n", d)
+		}
+		if len(r.Diffs) > 0 {
+			mismatched++
+		}
+	}
+	if mismatched > 0 {
+		fmt.Printf("\n❗ [交叉核对] %d/%d 只股票各数据源不一致\n", mismatched, len(results))
+	} else {
+		fmt.Printf("\n✅ [交叉核对] %d 只股票各数据源一致\n", len(results))
+	}
+}
@@ -0,0 +1,68 @@
+package fetcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrKind 拉取失败的类别
+type ErrKind string
+
+const (
+	ErrNetwork  ErrKind = "network"  // 连接/超时等网络错误
+	ErrHTTP     ErrKind = "http"     // 非 2xx 状态码
+	ErrSchema   ErrKind = "schema"   // 响应结构变化，无法解析
+	ErrEmpty    ErrKind = "empty"    // 响应合法但没有数据 (停牌/代码错误/接口异常)
+	ErrMismatch ErrKind = "mismatch" // 多个数据源的数据互相矛盾 (交叉核对)
+)
+
+// StatusError 非 2xx 响应
+type StatusError struct {
+	Code int
+}
+
+func (e *StatusError) Error() string { return fmt.Sprintf("HTTP %d", e.Code) }
+
+// FetchError 带类别的拉取错误。Op 为接口 (如 FetchHistoryData)，Target 为代码/板块
+type FetchError struct {
+	Op     string
+	Target string
+	Kind   ErrKind
+	Err    error
+}
+
+func (e *FetchError) Error() string {
+	return fmt.Sprintf("%s %s: %s: %v", e.Op, e.Target, e.Kind, e.Err)
+}
+
+func (e *FetchError) Unwrap() error { return e.Err }
+
+// KindOf 返回 err 的类别，非 FetchError 时为空
+func KindOf(err error) ErrKind {
+	var fe *FetchError
+	if errors.As(err, &fe) {
+		return fe.Kind
+	}
+	return ""
+}
+
+// fail 构造 FetchError 并记入 ctx 上的数据质量报告 (运行被中断导致的失败不算数据问题，不记录)。
+// kind 为空时按 err 自动归类 (网络 / HTTP)
+func fail(ctx context.Context, op, target string, kind ErrKind, err error) error {
+	if kind == "" {
+		kind = ErrNetwork
+		var se *StatusError
+		if errors.As(err, &se) {
+			kind = ErrHTTP
+		}
+	}
+	fe := &FetchError{Op: op, Target: target, Kind: kind, Err: err}
+	if ctx.Err() == nil {
+		QualityFrom(ctx).Record(fe)
+	}
+	return fe
+}
+
+// errEmpty 空响应
+var errEmpty = errors.New("empty payload")
@@ -0,0 +1,62 @@
+package fetcher
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestFetchErrorKinds(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+			w.Write([]byte(`{"data":{"klines":["2026-01-08,0,100","2026-01-09,10.5,200"]}}`))
+		case "/empty":
+			w.Write([]byte(`{"rc":0,"data":null}`))
+		case "/schema":
+			w.Write([]byte(`{"data":{"klines":["2026-01-09,-,200"]}}`))
+		case "/html":
+			w.Write([]byte(`<html>blocked</html>`))
+		default:
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer srv.Close()
+
+	q := NewQualityReport()
+	ctx := WithQuality(context.Background(), q)
+
+	bars, err := fetchKLines(ctx, "FetchHistoryData", "600001", time.Second, srv.URL+"/ok", false)
+	if err != nil || len(bars) != 2 || bars[1].Change != 10.5 || bars[1].Amount != 200 {
+		t.Fatalf("bars=%+v err=%v", bars, err)
+	}
+
+	cases := map[string]ErrKind{"/empty": ErrEmpty, "/schema": ErrSchema, "/html": ErrSchema, "/forbidden": ErrHTTP}
+	for path, want := range cases {
+		_, err := fetchKLines(ctx, "FetchHistoryData", path, time.Second, srv.URL+path, false)
+		if KindOf(err) != want {
+			t.Errorf("%s: kind = %q (%v), want %q", path, KindOf(err), err, want)
+		}
+	}
+	if _, err := fetchList(ctx, "FetchSectorStocks", "BK0477", srv.URL+"/empty"); KindOf(err) != ErrEmpty {
+		t.Errorf("empty list: %v", err)
+	}
+	// 网络错误 (连接被拒)
+	if _, err := fetchKLines(ctx, "Fetch30MinKline", "600002", time.Second, "http://127.0.0.1:1/x", false); KindOf(err) != ErrNetwork {
+		t.Errorf("network: %v", err)
+	}
+
+	if q.Len() != 6 {
+		t.Errorf("quality failures = %+v", q.Failures())
+	}
+
+	// 被取消的运行不计入数据质量
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	fetchKLines(cancelled, "FetchHistoryData", "600003", time.Second, srv.URL+"/ok", false)
+	if q.Len() != 6 {
+		t.Error("cancelled fetch should not be recorded")
+	}
+}
@@ -1,129 +1,93 @@
 package fetcher
 
 import (
+	"context"
+	"dragon-quant/calendar"
 	"dragon-quant/model"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io/ioutil"
-	"math"
-	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
 	"time"
 )
 
-func FetchSectorStocks(code string) []model.StockInfo {
+func FetchSectorStocks(ctx context.Context, code string) ([]model.StockInfo, error) {
 	cleanCode := strings.ReplaceAll(code, "BK", "")
 	// 🔥 f19:竞价金额, f62:净流入, f7:振幅
-	url := fmt.Sprintf("http://push2.eastmoney.com/api/qt/clist/get?pn=1&pz=500&po=1&np=1&fltt=2&invt=2&fid=f3&fs=b:BK%s&fields=f12,f14,f2,f3,f8,f10,f62,f7,f19,f267,f164", cleanCode)
-	items := FetchRaw(url)
+	url := fmt.Sprintf("http://push2.eastmoney.com/api/qt/clist/get?pn=1&pz=500&po=1&np=1&fltt=2&invt=2&fid=f3&fs=b:BK%s&fields=%s", cleanCode, sectorStockFields)
+	items, err := fetchList(ctx, "FetchSectorStocks", code, url)
+	if err != nil {
+		return nil, err
+	}
+	return parseSectorStocks(ctx, code, items)
+}
+
+// parseSectorStocks 逐行解码并校验成分股，丢弃不合理的行
+func parseSectorStocks(ctx context.Context, code string, items []json.RawMessage) ([]model.StockInfo, error) {
+	v := newValidator("FetchSectorStocks", code)
 	var list []model.StockInfo
 	for _, item := range items {
 		var s model.StockInfo
-		json.Unmarshal(item, &s)
-		list = append(list, s)
+		missing, err := decodeRow(item, sectorStockFields, &s)
+		if err != nil {
+			v.reject(item, err)
+			continue
+		}
+		if v.check(item, checkStock(s, missing)) {
+			list = append(list, s)
+		}
 	}
-	return list
+	return list, v.done(ctx)
 }
 
 // 🆕 FetchSectorHistory fetches the daily K-line history for a sector index.
-func FetchSectorHistory(code string) []model.KLineData {
-	// EastMoney Block ID format: "BK0xxx" -> "90.BK0xxx"
-	// For industry like "BK0477", use "90.BK0477"
-	// For concept like "BK0984", use "90.BK0984"
-	// Note: Usually "90." works for blocks.
-	secID := "90." + code
+func FetchSectorHistory(ctx context.Context, code string) ([]model.KLineData, error) {
+	// EastMoney Block ID format: "BK0xxx" -> "90.BK0xxx" (行业 / 概念板块同为 90.)
+	secID := Resolve(code).SecID()
 
 	// klt=101: Daily
 	// lmt=15: Get last 15 days (enough for trend analysis)
 	// Change f6 -> f57 (Amount)
 	url := fmt.Sprintf("http://push2his.eastmoney.com/api/qt/stock/kline/get?secid=%s&fields1=f1&fields2=f51,f53,f57&klt=101&fqt=1&end=20500000&lmt=15", secID)
 
-	// fmt.Printf("DEBUG: FetchSectorHistory URL: %s\n", url)
-
-	client := http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Get(url)
+	klines, err := fetchKLines(ctx, "FetchSectorHistory", code, 10*time.Second, url, false)
 	if err != nil {
-		fmt.Printf("❌ FetchSectorHistory Net Error: %v\n", err)
-		return nil
+		return nil, err
 	}
-	defer resp.Body.Close()
-
-	body, _ := ioutil.ReadAll(resp.Body)
-	// fmt.Printf("DEBUG: FetchSectorHistory Body: %s\n", string(body)[:min(len(body), 200)])
-
-	var kResp model.KLineResponse
-	json.Unmarshal(body, &kResp)
-
-	var klines []model.KLineData
-	lastClose := 0.0
-	for i, line := range kResp.Data.Klines {
-		// fmt.Printf("DEBUG Line: %s\n", line)
-		parts := strings.Split(line, ",")
-		if len(parts) >= 2 {
-			p, _ := strconv.ParseFloat(parts[1], 64)
-			amt := 0.0
-			if len(parts) >= 3 {
-				amt, _ = strconv.ParseFloat(parts[2], 64)
-			}
-			change := 0.0
-			if i > 0 {
-				change = (p - lastClose) / lastClose * 100 // Convert to PctChange for easier AI reading
-			}
-			lastClose = p
-			klines = append(klines, model.KLineData{Close: p, Change: change, Amount: amt})
+	// Convert to PctChange for easier AI reading (前一根收盘为 0 时记 0，避免除零)
+	for i := range klines {
+		klines[i].Change = 0
+		if i > 0 && klines[i-1].Close > 0 {
+			klines[i].Change = (klines[i].Close - klines[i-1].Close) / klines[i-1].Close * 100
 		}
 	}
-	return klines
+	return klines, nil
 }
 
-func FetchHistoryData(code string, limit int) []model.KLineData {
-	secID := "0." + code
-	if strings.HasPrefix(code, "6") {
-		secID = "1." + code
-	}
+// FetchHistoryData 前复权日K (用于均线 / MACD 等指标)
+func FetchHistoryData(ctx context.Context, code string, limit int) ([]model.KLineData, error) {
+	return FetchDailyAdj(ctx, code, limit, model.AdjustForward)
+}
+
+// 🆕 FetchDailyAdj 指定复权口径的日K
+func FetchDailyAdj(ctx context.Context, code string, limit int, adj model.Adjust) ([]model.KLineData, error) {
+	secID := Resolve(code).SecID()
 	// klt=101: 日线
 	// fields2=f51,f53,f6 (Date, Close, Amount)
-	url := fmt.Sprintf("http://push2his.eastmoney.com/api/qt/stock/kline/get?secid=%s&fields1=f1&fields2=f51,f53,f6&klt=101&fqt=1&end=20500000&lmt=%d", secID, limit)
-
-	client := http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Get(url)
-	if err != nil {
-		return nil
-	}
-	defer resp.Body.Close()
-
-	body, _ := ioutil.ReadAll(resp.Body)
-	var kResp model.KLineResponse
-	json.Unmarshal(body, &kResp)
-
-	var klines []model.KLineData
-	lastClose := 0.0
-	for i, line := range kResp.Data.Klines {
-		parts := strings.Split(line, ",")
-		if len(parts) >= 2 {
-			p, _ := strconv.ParseFloat(parts[1], 64)
-			amt := 0.0
-			if len(parts) >= 3 {
-				amt, _ = strconv.ParseFloat(parts[2], 64)
-			}
-			change := 0.0
-			if i > 0 {
-				change = p - lastClose
-			}
-			lastClose = p
-			klines = append(klines, model.KLineData{Close: p, Change: change, Amount: amt, Date: parts[0]})
-		}
-	}
-	return klines
+	url := fmt.Sprintf("http://push2his.eastmoney.com/api/qt/stock/kline/get?secid=%s&fields1=f1&fields2=f51,f53,f6&klt=101&fqt=%d&end=20500000&lmt=%d", secID, fqt(adj), limit)
+	return fetchKLines(ctx, "FetchHistoryData", code, 10*time.Second, url, false)
 }
 
 // 🆕 获取市场情绪 (昨日涨停表现)
-func FetchSentimentIndex() float64 {
+func FetchSentimentIndex(ctx context.Context) (float64, error) {
 	// BK0815: 昨日涨停
 	url := "http://push2.eastmoney.com/api/qt/clist/get?pn=1&pz=500&po=1&np=1&fltt=2&invt=2&fid=f3&fs=b:BK0815&fields=f3"
-	items := FetchRaw(url)
+	items, err := fetchList(ctx, "FetchSentimentIndex", "BK0815", url)
+	if err != nil {
+		return 0, err
+	}
 	totalChange := 0.0
 	count := 0
 	for _, item := range items {
@@ -136,184 +100,165 @@ func FetchSentimentIndex() float64 {
 		}
 	}
 	if count == 0 {
-		return 0
+		return 0, fail(ctx, "FetchSentimentIndex", "BK0815", ErrSchema, fmt.Errorf("%d items, none decodable", len(items)))
 	}
-	return totalChange / float64(count)
+	return totalChange / float64(count), nil
 }
 
 // 🆕 获取5分钟K线数据 (用于计算开盘承接率)
-func Fetch5MinKline(code string) []model.KLineData {
-	secID := "0." + code
-	if strings.HasPrefix(code, "6") {
-		secID = "1." + code
-	}
+func Fetch5MinKline(ctx context.Context, code string) ([]model.KLineData, error) {
+	return Fetch5MinKlineN(ctx, code, 10)
+}
+
+// 🆕 获取最近 limit 根 5分钟K线 (成交额)
+func Fetch5MinKlineN(ctx context.Context, code string, limit int) ([]model.KLineData, error) {
+	secID := Resolve(code).SecID()
 	// klt=5: 5分钟
 	// fields2=f51,f57 (Date, Amount)
-	url := fmt.Sprintf("http://push2his.eastmoney.com/api/qt/stock/kline/get?secid=%s&fields1=f1&fields2=f51,f57&klt=5&fqt=1&end=20500000&lmt=10", secID)
+	url := fmt.Sprintf("http://push2his.eastmoney.com/api/qt/stock/kline/get?secid=%s&fields1=f1&fields2=f51,f57&klt=5&fqt=0&end=20500000&lmt=%d", secID, limit)
+	return fetchKLines(ctx, "Fetch5MinKline", code, 3*time.Second, url, true)
+}
 
-	client := http.Client{Timeout: 3 * time.Second}
-	resp, err := client.Get(url)
-	if err != nil {
-		return nil
-	}
-	defer resp.Body.Close()
+// 🆕 获取30分钟K线数据 (前复权)
+func Fetch30MinKline(ctx context.Context, code string, limit int) ([]model.KLineData, error) {
+	return Fetch30MinKlineAdj(ctx, code, limit, model.AdjustForward)
+}
 
-	body, _ := ioutil.ReadAll(resp.Body)
-	var kResp model.KLineResponse
-	json.Unmarshal(body, &kResp)
+// 🆕 Fetch30MinKlineAdj 指定复权口径的30分钟K线
+func Fetch30MinKlineAdj(ctx context.Context, code string, limit int, adj model.Adjust) ([]model.KLineData, error) {
+	secID := Resolve(code).SecID()
+	// klt=30: 30分钟
+	// fields2=f51,f53,f57 (Date, Close, Amount)
+	url := fmt.Sprintf("http://push2his.eastmoney.com/api/qt/stock/kline/get?secid=%s&fields1=f1&fields2=f51,f53,f57&klt=30&fqt=%d&end=20500000&lmt=%d", secID, fqt(adj), limit)
+	return fetchKLines(ctx, "Fetch30MinKline", code, 3*time.Second, url, false)
+}
 
-	var klines []model.KLineData
-	for _, line := range kResp.Data.Klines {
-		parts := strings.Split(line, ",")
-		if len(parts) >= 2 {
-			// fields2=f51,f57 -> part[0]=Date, part[1]=Amount
-			amt, _ := strconv.ParseFloat(parts[1], 64)
-			klines = append(klines, model.KLineData{Close: 0, Change: 0, Amount: amt})
+// 🆕 获取1分钟K线数据 (指定天数)。部分交易日失败时返回其余日期的数据，err 汇总失败的日期
+func Fetch1MinKline(ctx context.Context, code string, days int) ([]model.KLineData, error) {
+	// 1. 最近 days 个交易日 (交易日历，已开盘的今天也算)
+	cal := calendar.Default()
+	tradingDays := cal.RecentTradingDays(calendar.Now(), days)
+
+	var allMinKlines []model.KLineData
+	var errs []error
+
+	// 2. Loop over each day to get 1-min data (chronological)
+	for _, date := range calendar.Dates(tradingDays) {
+		if ctx.Err() != nil {
+			errs = append(errs, ctx.Err())
+			break
 		}
+		bars, err := Fetch1MinKlineForDate(ctx, code, date)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		allMinKlines = append(allMinKlines, bars...)
 	}
-	return klines
+	return allMinKlines, errors.Join(errs...)
 }
 
-// 🆕 获取30分钟K线数据
-func Fetch30MinKline(code string, limit int) []model.KLineData {
-	secID := "0." + code
-	if strings.HasPrefix(code, "6") {
-		secID = "1." + code
-	}
-	// klt=30: 30分钟
-	// fields2=f51,f53,f57 (Date, Close, Amount)
-	url := fmt.Sprintf("http://push2his.eastmoney.com/api/qt/stock/kline/get?secid=%s&fields1=f1&fields2=f51,f53,f57&klt=30&fqt=1&end=20500000&lmt=%d", secID, limit)
+// Fetch1MinKlineForDate 获取单个交易日的 1分钟K线 (date: "2006-01-02")，不复权 (与实时盘口价一致)
+func Fetch1MinKlineForDate(ctx context.Context, code string, date string) ([]model.KLineData, error) {
+	secID := Resolve(code).SecID()
 
-	client := http.Client{Timeout: 3 * time.Second}
-	resp, err := client.Get(url)
+	// day.Date format is usually "2006-01-02"
+	dateStr := strings.ReplaceAll(date, "-", "") // "20060102"
+
+	// lmt=240 for one day. Usually lmt=240 + end=Date gives that day's data.
+	url := fmt.Sprintf("http://push2his.eastmoney.com/api/qt/stock/kline/get?secid=%s&fields1=f1&fields2=f51,f53,f57&klt=1&fqt=0&end=%s&lmt=240", secID, dateStr)
+	return fetchKLines(ctx, "Fetch1MinKline", code+"@"+date, 10*time.Second, url, false)
+}
+
+// fetchKLines 拉取并解析 K线接口。每行 "日期,收盘,成交额" (amountOnly 时为 "日期,成交额")；
+// Change 为相对上一根的收盘价差。网络/状态码/结构错误与空数据都返回 FetchError。
+func fetchKLines(ctx context.Context, op, target string, timeout time.Duration, url string, amountOnly bool) ([]model.KLineData, error) {
+	body, err := getBody(ctx, timeout, url)
 	if err != nil {
-		return nil
+		return nil, fail(ctx, op, target, "", err)
 	}
-	defer resp.Body.Close()
-
-	body, _ := ioutil.ReadAll(resp.Body)
 	var kResp model.KLineResponse
-	json.Unmarshal(body, &kResp)
+	if err := json.Unmarshal(body, &kResp); err != nil {
+		return nil, fail(ctx, op, target, ErrSchema, err)
+	}
+	if len(kResp.Data.Klines) == 0 {
+		return nil, fail(ctx, op, target, ErrEmpty, errEmpty)
+	}
 
-	var klines []model.KLineData
-	lastClose := 0.0
+	klines := make([]model.KLineData, 0, len(kResp.Data.Klines))
 	for i, line := range kResp.Data.Klines {
 		parts := strings.Split(line, ",")
-		if len(parts) >= 2 {
-			p, _ := strconv.ParseFloat(parts[1], 64)
-			amt := 0.0
-			if len(parts) >= 3 {
-				amt, _ = strconv.ParseFloat(parts[2], 64)
+		if len(parts) < 2 {
+			return nil, fail(ctx, op, target, ErrSchema, fmt.Errorf("bar %d: %q", i, line))
+		}
+		nums := make([]float64, len(parts)-1)
+		for j, p := range parts[1:] {
+			v, err := strconv.ParseFloat(p, 64)
+			if err != nil {
+				return nil, fail(ctx, op, target, ErrSchema, fmt.Errorf("bar %d: %q", i, line))
+			}
+			nums[j] = v
+		}
+		k := model.KLineData{Date: parts[0]}
+		if amountOnly {
+			// fields2=f51,f57 -> part[0]=Date, part[1]=Amount
+			k.Amount = nums[0]
+		} else {
+			k.Close = nums[0]
+			if len(nums) >= 2 {
+				k.Amount = nums[1]
 			}
-			change := 0.0
 			if i > 0 {
-				change = p - lastClose
+				k.Change = k.Close - klines[i-1].Close
 			}
-			lastClose = p
-			klines = append(klines, model.KLineData{Close: p, Change: change, Amount: amt})
 		}
+		klines = append(klines, k)
 	}
-	return klines
+	return klines, nil
 }
 
-// 🆕 获取1分钟K线数据 (指定天数)
-func Fetch1MinKline(code string, days int) []model.KLineData {
-	// 1. Get Trading Days (Daily K-line)
-	// We need 'days' trading days.
-	dailyKlines := FetchHistoryData(code, days)
-	if len(dailyKlines) == 0 {
-		return nil
-	}
-
-	secID := "0." + code
-	if strings.HasPrefix(code, "6") {
-		secID = "1." + code
+func FetchTopSectors(ctx context.Context, fs string, limit int, typeName string) ([]model.SectorInfo, error) {
+	// Add f62 (NetInflow), f164 (5-Day NetInflow)
+	url := fmt.Sprintf("http://push2.eastmoney.com/api/qt/clist/get?pn=1&pz=%d&po=1&np=1&fltt=2&invt=2&fid=f3&fs=%s&fields=%s", limit, fs, topSectorFields)
+	items, err := fetchList(ctx, "FetchTopSectors", typeName, url)
+	if err != nil {
+		return nil, err
 	}
-
-	var allMinKlines []model.KLineData
-	client := http.Client{Timeout: 10 * time.Second}
-
-	// 2. Loop over each day to get 1-min data
-	for _, day := range dailyKlines {
-		// day.Date format is usually "2006-01-02"
-		dateStr := strings.ReplaceAll(day.Date, "-", "") // "20060102"
-
-		// lmt=240 for one day
-		url := fmt.Sprintf("http://push2his.eastmoney.com/api/qt/stock/kline/get?secid=%s&fields1=f1&fields2=f51,f53,f57&klt=1&fqt=1&end=%s&lmt=240", secID, dateStr)
-
-		resp, err := client.Get(url)
+	v := newValidator("FetchTopSectors", typeName)
+	var list []model.SectorInfo
+	for _, item := range items {
+		var s model.SectorInfo
+		missing, err := decodeRow(item, topSectorFields, &s)
 		if err != nil {
-			fmt.Printf("Error 1m: %v\n", err)
+			v.reject(item, err)
 			continue
 		}
-
-		body, _ := ioutil.ReadAll(resp.Body)
-		resp.Body.Close()
-
-		var kResp model.KLineResponse
-		json.Unmarshal(body, &kResp)
-
-		lastClose := 0.0 // Actually we might not need to recalculate change carefully if we just want data
-		// But let's keep consistency
-
-		for i, line := range kResp.Data.Klines {
-			parts := strings.Split(line, ",")
-			if len(parts) >= 2 {
-				p, _ := strconv.ParseFloat(parts[1], 64)
-				amt := 0.0
-				if len(parts) >= 3 {
-					amt, _ = strconv.ParseFloat(parts[2], 64)
-				}
-				change := 0.0
-				// For change, we really need prev close of *fetching session*?
-				// Simplification: just diff with previous bar in this chunk
-				if i > 0 {
-					change = p - lastClose
-				}
-				lastClose = p
-
-				// Optional: Filter to ensure we only keep bars for THIS day?
-				// Usually lmt=240 + end=Date gives that day's data.
-				// Just append.
-				allMinKlines = append(allMinKlines, model.KLineData{Close: p, Change: change, Amount: amt, Date: parts[0]})
-			}
+		if v.check(item, checkSector(s, missing)) {
+			s.Type = typeName
+			list = append(list, s)
 		}
 	}
-	// Note: They might be in chronological order if dailyKlines is chronological.
-	return allMinKlines
+	return list, v.done(ctx)
 }
 
-func FetchTopSectors(fs string, limit int, typeName string) []model.SectorInfo {
-	// Add f62 (NetInflow), f164 (5-Day NetInflow)
-	url := fmt.Sprintf("http://push2.eastmoney.com/api/qt/clist/get?pn=1&pz=%d&po=1&np=1&fltt=2&invt=2&fid=f3&fs=%s&fields=f12,f14,f62,f164", limit, fs)
-	items := FetchRaw(url)
-	var list []model.SectorInfo
-	for _, item := range items {
-		var s model.SectorInfo
-		json.Unmarshal(item, &s)
-		s.Type = typeName
-		list = append(list, s)
-	}
-	return list
+// FetchRaw 拉取 clist 列表接口的 data.diff
+func FetchRaw(ctx context.Context, url string) ([]json.RawMessage, error) {
+	return fetchList(ctx, "FetchRaw", url, url)
 }
 
-func FetchRaw(url string) []json.RawMessage {
-	// Debug: Print URL and Response
-	// fmt.Printf("Fetching: %s\n", url)
-	client := http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Get(url)
+func fetchList(ctx context.Context, op, target, url string) ([]json.RawMessage, error) {
+	body, err := getBody(ctx, 10*time.Second, url)
 	if err != nil {
-		fmt.Printf("❌ FetchRaw Error: %v\n", err)
-		return nil
+		return nil, fail(ctx, op, target, "", err)
 	}
-	defer resp.Body.Close()
-	body, _ := ioutil.ReadAll(resp.Body)
-
-	// fmt.Printf("Raw Body (Top 100): %s\n", string(body)[:min(len(body), 100)])
-
 	var wrap model.ListResponse
-	json.Unmarshal(body, &wrap)
-	return wrap.Data.Diff
+	if err := json.Unmarshal(body, &wrap); err != nil {
+		return nil, fail(ctx, op, target, ErrSchema, err)
+	}
+	if len(wrap.Data.Diff) == 0 {
+		return nil, fail(ctx, op, target, ErrEmpty, errEmpty)
+	}
+	return wrap.Data.Diff, nil
 }
 
 func min(a, b int) int {
@@ -323,135 +268,50 @@ func min(a, b int) int {
 	return b
 }
 
-// 🆕 获取个股详情 (竞价 f277 + 盘口)
-func FetchStockDetails(s *model.StockInfo) {
-	secID := "0." + s.Code
-	if strings.HasPrefix(s.Code, "6") {
-		secID = "1." + s.Code
-	}
-	// f277: 竞价金额/开盘金额
-	// f19: 买一价, f20: 买一量, f17: 卖一价, f18: 卖一量 (注意：这里用的是详细接口，f19定义可能与列表接口不同，但Debug中f277是关键)
-	url := fmt.Sprintf("http://push2.eastmoney.com/api/qt/stock/get?secid=%s&fields=f19,f20,f17,f18,f277", secID)
-
-	client := http.Client{Timeout: 3 * time.Second}
-	resp, err := client.Get(url)
+// 🆕 获取个股详情 (竞价 f277 + 五档盘口 + 涨跌停价)
+func FetchStockDetails(ctx context.Context, s *model.StockInfo) error {
+	q, err := fetchQuote(ctx, "FetchStockDetails", s.Code, bookFields+",f277")
 	if err != nil {
-		return
-	}
-	defer resp.Body.Close()
-	body, _ := ioutil.ReadAll(resp.Body)
-
-	// Quick dirty parse because struct is complex
-	// Response: {"data":{"f19":..., "f277":...}}
-	var wrapper struct {
-		Data struct {
-			Buy1Price float64 `json:"f19"`
-			Buy1Vol   int     `json:"f20"`
-			Sell1Vol  int     `json:"f18"`
-			CallAmt   float64 `json:"f277"`
-		} `json:"data"`
-	}
-	if err := json.Unmarshal(body, &wrapper); err == nil {
-		s.CallAuctionAmt = wrapper.Data.CallAmt
-		s.Buy1Price = wrapper.Data.Buy1Price
-		s.Buy1Vol = wrapper.Data.Buy1Vol
-		s.Sell1Vol = wrapper.Data.Sell1Vol
+		return err
 	}
+	book := q.orderBook()
+	s.CallAuctionAmt = q.num("f277")
+	s.OrderBook = &book
+	s.Buy1Price = book.Bids[0].Price
+	s.Buy1Vol = int(book.Bids[0].Vol)
+	s.Sell1Vol = int(book.Asks[0].Vol)
+	return nil
 }
 
-// 🆕 获取龙虎榜数据
-func FetchLHBData(s *model.StockInfo) {
-	// 尝试获取最新一期的龙虎榜
-	// 逻辑：尝试今天，如果今天是周末或未出榜，可能拿不到，这里简单尝试最近日期
-	// 实际工程中应该遍历最近几日。这里为了演示，硬编码尝试 "2026-01-09" (根据Debug结果) 以及 Today
-	dates := []string{time.Now().Format("2006-01-02"), "2026-01-09"}
-
-	for _, d := range dates {
-		url := fmt.Sprintf("https://datacenter-web.eastmoney.com/api/data/v1/get?reportName=RPT_DAILYBILLBOARD_DETAILS&columns=ALL&filter=(SECURITY_CODE%%3D%%22%s%%22)(TRADE_DATE%%3D%%27%s%%27)", s.Code, d)
-
-		client := http.Client{Timeout: 3 * time.Second}
-		resp, err := client.Get(url)
-		if err != nil {
-			continue
-		}
-		defer resp.Body.Close()
-		body, _ := ioutil.ReadAll(resp.Body)
-
-		if strings.Contains(string(body), "\"result\":null") {
-			continue
-		}
-
-		// 解析
-		var lhbResp struct {
-			Result struct {
-				Data []struct {
-					Explain string  `json:"EXPLAIN"`           // "买一主买"
-					NetAmt  float64 `json:"BILLBOARD_NET_AMT"` // 净买入
-					BuyAmt  float64 `json:"BILLBOARD_BUY_AMT"`
-					SellAmt float64 `json:"BILLBOARD_SELL_AMT"`
-				} `json:"data"`
-			} `json:"result"`
-		}
-
-		if err := json.Unmarshal(body, &lhbResp); err == nil && len(lhbResp.Result.Data) > 0 {
-			info := lhbResp.Result.Data[0]
-			s.LHBNet = info.NetAmt
-
-			netStr := fmt.Sprintf("%.1f万", info.NetAmt/10000)
-			if math.Abs(info.NetAmt) > 100000000 {
-				netStr = fmt.Sprintf("%.1f亿", info.NetAmt/100000000)
-			}
-
-			s.LHBInfo = fmt.Sprintf("%s 净:%s", info.Explain, netStr)
-			return // Found latest
-		}
-	}
-}
-
-// 🆕 根据名称搜索股票代码
-func SearchStock(keyword string) (string, string) {
+// 🆕 根据名称搜索证券 (股票 / 基金 / 指数)。市场按接口返回的 QuoteID / MarketType 确定并登记到 Resolve；
+// 调用方用 sec.Key() 继续拉取行情，不会混淆 上证指数 (sh000001) 与 平安银行 (000001)。找不到时返回 ErrEmpty
+func SearchStock(ctx context.Context, keyword string) (model.Security, error) {
 	escaped := url.QueryEscape(keyword)
-	url := fmt.Sprintf("http://searchapi.eastmoney.com/api/suggest/get?input=%s&type=14&token=D43BF722C8E33BDC906FB84D85E326E8", escaped)
+	url := fmt.Sprintf("%s?input=%s&type=14&token=D43BF722C8E33BDC906FB84D85E326E8", searchAPI, escaped)
 
-	// Retry logic: 3 attempts
-	for i := 0; i < 3; i++ {
-		client := http.Client{Timeout: 10 * time.Second} // Increased timeout to 10s
-		resp, err := client.Get(url)
+	// Retry logic: 3 attempts (网络重试由共享 HTTP 层负责，这里只重试偶发的空结果)
+	for i := 0; ; i++ {
+		body, err := getBody(ctx, 10*time.Second, url) // Increased timeout to 10s
 		if err != nil {
-			if i == 2 {
-				fmt.Printf("SearchStock error (attempt %d): %v\n", i+1, err)
-			}
-			time.Sleep(500 * time.Millisecond)
-			continue
+			return model.Security{}, fail(ctx, "SearchStock", keyword, "", err)
 		}
 
-		body, _ := ioutil.ReadAll(resp.Body)
-		resp.Body.Close()
-
 		var searchResp struct {
 			QuotationCodeTable struct {
-				Data []struct {
-					Code string `json:"Code"`
-					Name string `json:"Name"`
-					Mkt  string `json:"MarketType"`
-				} `json:"Data"`
+				Data []searchMatch `json:"Data"`
 			} `json:"QuotationCodeTable"`
 		}
-
-		if err := json.Unmarshal(body, &searchResp); err == nil {
-			if len(searchResp.QuotationCodeTable.Data) > 0 {
-				match := searchResp.QuotationCodeTable.Data[0]
-				return match.Code, match.Name
-			}
+		if err := json.Unmarshal(body, &searchResp); err != nil {
+			return model.Security{}, fail(ctx, "SearchStock", keyword, ErrSchema, err)
 		}
-		// If unmarshal fail or empty data, maybe retry?
-		// For now assume empty data means not found.
-		if len(searchResp.QuotationCodeTable.Data) == 0 && i < 2 {
-			// Maybe sporadic empty? Retry.
-			time.Sleep(200 * time.Millisecond)
-			continue
+		if len(searchResp.QuotationCodeTable.Data) > 0 {
+			sec := searchResp.QuotationCodeTable.Data[0].security()
+			RegisterSecurity(sec)
+			return sec, nil
+		}
+		// Maybe sporadic empty? Retry.
+		if i == 2 || calendar.Sleep(ctx, 200*time.Millisecond) != nil {
+			return model.Security{}, fail(ctx, "SearchStock", keyword, ErrEmpty, errEmpty)
 		}
-		break
 	}
-	return "", ""
 }
@@ -1,6 +1,7 @@
 package fetcher
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
 	"testing"
@@ -22,7 +23,7 @@ func TestFetch1MinKline(t *testing.T) {
 	days := 7 // Fetch 7 days
 
 	fmt.Printf("Fetching 1-min kline for %s, days=%d...\n", code, days)
-	klines := Fetch1MinKline(code, days)
+	klines, _ := Fetch1MinKline(context.Background(), code, days)
 
 	if len(klines) == 0 {
 		t.Errorf("Fetched 0 klines for %s", code)
@@ -0,0 +1,307 @@
+package fetcher
+
+import (
+	"context"
+	"dragon-quant/calendar"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// --- 共享 HTTP 层: 连接复用 + 按 host 限速 + 瞬时错误重试 + 请求统计 ---
+// InferStockLeaders 20 个协程、每只股票 4-6 个请求，全部走同一个 Client，避免被东财限流封 IP。
+
+// HTTPOptions 共享 HTTP 层配置，零值字段取 DefaultHTTPOptions 中的默认值
+type HTTPOptions struct {
+	QPS             float64       // 每个 host 每秒请求数上限
+	Burst           int           // 令牌桶容量 (允许的瞬时并发)
+	MaxConnsPerHost int           // 每个 host 的最大连接数 (keep-alive 复用)
+	MaxRetries      *int          // 网络错误 / 429 / 5xx 的重试次数 (nil = 默认值，0 或负数 = 不重试)
+	Backoff         time.Duration // 首次重试等待，之后每次翻倍
+	UserAgent       string
+	Referer         string
+}
+
+// DefaultHTTPOptions 东财接口的默认参数
+func DefaultHTTPOptions() HTTPOptions {
+	return HTTPOptions{
+		QPS:             8,
+		Burst:           4,
+		MaxConnsPerHost: 16,
+		MaxRetries:      Retries(2),
+		Backoff:         300 * time.Millisecond,
+		UserAgent:       "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0 Safari/537.36",
+		Referer:         "https://quote.eastmoney.com/",
+	}
+}
+
+// Retries 构造 HTTPOptions.MaxRetries (区分"未设置"与显式的 0)
+func Retries(n int) *int {
+	return &n
+}
+
+// HostStats 单个 host 的请求统计
+type HostStats struct {
+	Host     string
+	Requests int           // 实际发出的请求数 (含重试)
+	Retries  int           // 其中的重试次数
+	Errors   int           // 重试耗尽后仍失败的调用数
+	Total    time.Duration // 累计耗时 (不含限速排队)
+	Max      time.Duration // 最慢一次请求
+	Waited   time.Duration // 累计限速排队时间
+}
+
+// Avg 平均单次请求耗时
+func (s HostStats) Avg() time.Duration {
+	if s.Requests == 0 {
+		return 0
+	}
+	return s.Total / time.Duration(s.Requests)
+}
+
+// Client 共享 HTTP 客户端，可并发使用
+type Client struct {
+	opts    HTTPOptions
+	retries int
+	http    *http.Client
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	stats   map[string]*HostStats
+}
+
+// NewClient 按 opts 创建客户端 (零值字段取默认值)
+func NewClient(opts HTTPOptions) *Client {
+	def := DefaultHTTPOptions()
+	if opts.QPS <= 0 {
+		opts.QPS = def.QPS
+	}
+	if opts.Burst <= 0 {
+		opts.Burst = def.Burst
+	}
+	if opts.MaxConnsPerHost <= 0 {
+		opts.MaxConnsPerHost = def.MaxConnsPerHost
+	}
+	if opts.MaxRetries == nil {
+		opts.MaxRetries = def.MaxRetries
+	}
+	retries := *opts.MaxRetries
+	if retries < 0 {
+		retries = 0
+	}
+	if opts.Backoff <= 0 {
+		opts.Backoff = def.Backoff
+	}
+	if opts.UserAgent == "" {
+		opts.UserAgent = def.UserAgent
+	}
+	if opts.Referer == "" {
+		opts.Referer = def.Referer
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConns = 100
+	transport.MaxIdleConnsPerHost = opts.MaxConnsPerHost
+	transport.MaxConnsPerHost = opts.MaxConnsPerHost
+	transport.IdleConnTimeout = 90 * time.Second
+
+	return &Client{
+		opts:    opts,
+		retries: retries,
+		http:    &http.Client{Transport: transport},
+		buckets: make(map[string]*bucket),
+		stats:   make(map[string]*HostStats),
+	}
+}
+
+var (
+	clientMu      sync.RWMutex
+	defaultClient = NewClient(DefaultHTTPOptions())
+)
+
+// SetHTTPClient 替换所有 fetcher 使用的共享客户端 (启动时按 config 设置)
+func SetHTTPClient(c *Client) {
+	clientMu.Lock()
+	defaultClient = c
+	clientMu.Unlock()
+}
+
+// HTTPClient 当前共享客户端
+func HTTPClient() *Client {
+	clientMu.RLock()
+	defer clientMu.RUnlock()
+	return defaultClient
+}
+
+// getBody 用共享客户端 GET 并读完响应体。timeout 为单次尝试的超时。
+func getBody(ctx context.Context, timeout time.Duration, url string) ([]byte, error) {
+	return HTTPClient().Get(ctx, timeout, url)
+}
+
+// Get 限速后发起 GET，网络错误 / 429 / 5xx 按指数退避重试 (429 优先用 Retry-After)；
+// ctx 取消立即返回。非 2xx 响应返回错误。
+func (c *Client) Get(ctx context.Context, timeout time.Duration, rawURL string) ([]byte, error) {
+	return c.GetWithReferer(ctx, timeout, rawURL, "")
+}
+
+// GetWithReferer 同 Get，但使用指定的 Referer (为空时用默认值)。新浪行情不带新浪的 Referer 会返回 403
+func (c *Client) GetWithReferer(ctx context.Context, timeout time.Duration, rawURL, referer string) ([]byte, error) {
+	if referer == "" {
+		referer = c.opts.Referer
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	host := u.Host
+
+	var lastErr error
+	for attempt := 0; attempt <= c.retries; attempt++ {
+		if attempt > 0 {
+			c.record(host, func(s *HostStats) { s.Retries++ })
+		}
+		wait := c.reserve(host)
+		if wait > 0 {
+			c.record(host, func(s *HostStats) { s.Waited += wait })
+			if err := calendar.Sleep(ctx, wait); err != nil {
+				return nil, err
+			}
+		}
+
+		body, retryAfter, err := c.do(ctx, timeout, rawURL, referer)
+		if err == nil {
+			return body, nil
+		}
+		lastErr = err
+		if ctx.Err() != nil || retryAfter < 0 {
+			break
+		}
+		if attempt == c.retries {
+			break
+		}
+		backoff := c.opts.Backoff << attempt
+		if retryAfter > 0 {
+			backoff = retryAfter
+		}
+		if err := calendar.Sleep(ctx, backoff); err != nil {
+			lastErr = err
+			break
+		}
+	}
+	c.record(host, func(s *HostStats) { s.Errors++ })
+	return nil, lastErr
+}
+
+// do 单次请求。retryAfter < 0 表示不可重试的错误 (4xx)，> 0 为服务端要求的等待时间
+func (c *Client) do(ctx context.Context, timeout time.Duration, rawURL, referer string) (body []byte, retryAfter time.Duration, err error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, -1, err
+	}
+	req.Header.Set("User-Agent", c.opts.UserAgent)
+	req.Header.Set("Referer", referer)
+
+	start := time.Now()
+	resp, err := c.http.Do(req)
+	if err == nil {
+		body, err = io.ReadAll(resp.Body)
+		resp.Body.Close()
+	}
+	elapsed := time.Since(start)
+	c.record(req.URL.Host, func(s *HostStats) {
+		s.Requests++
+		s.Total += elapsed
+		s.Max = max(s.Max, elapsed)
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		if sec, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && sec > 0 {
+			retryAfter = time.Duration(sec) * time.Second
+		}
+		return nil, retryAfter, &StatusError{Code: resp.StatusCode}
+	case resp.StatusCode >= 500:
+		return nil, 0, &StatusError{Code: resp.StatusCode}
+	case resp.StatusCode >= 300:
+		return nil, -1, &StatusError{Code: resp.StatusCode}
+	}
+	return body, 0, nil
+}
+
+// bucket 单个 host 的令牌桶。tokens 可以为负: 表示已排队的请求数
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// reserve 取一个令牌，返回需要等待的时间
+func (c *Client) reserve(host string) time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	b := c.buckets[host]
+	if b == nil {
+		b = &bucket{tokens: float64(c.opts.Burst), last: now}
+		c.buckets[host] = b
+	}
+	b.tokens = math.Min(float64(c.opts.Burst), b.tokens+now.Sub(b.last).Seconds()*c.opts.QPS)
+	b.last = now
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / c.opts.QPS * float64(time.Second))
+}
+
+func (c *Client) record(host string, f func(s *HostStats)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s := c.stats[host]
+	if s == nil {
+		s = &HostStats{Host: host}
+		c.stats[host] = s
+	}
+	f(s)
+}
+
+// Stats 各 host 的请求统计 (按请求数降序)
+func (c *Client) Stats() []HostStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make([]HostStats, 0, len(c.stats))
+	for _, s := range c.stats {
+		out = append(out, *s)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Requests != out[j].Requests {
+			return out[i].Requests > out[j].Requests
+		}
+		return out[i].Host < out[j].Host
+	})
+	return out
+}
+
+// PrintHTTPStats 打印共享客户端的请求统计 (没有请求时不输出)
+func PrintHTTPStats() {
+	stats := HTTPClient().Stats()
+	if len(stats) == 0 {
+		return
+	}
+	fmt.Printf("\n📶 [HTTP] %-28s %6s %5s %5s %8s %8s %8s\n", "host", "请求", "重试", "失败", "平均", "最慢", "排队")
+	for _, s := range stats {
+		fmt.Printf("   %-35s %6d %5d %5d %8s %8s %8s\n", s.Host, s.Requests, s.Retries, s.Errors,
+			s.Avg().Round(time.Millisecond), s.Max.Round(time.Millisecond), s.Waited.Round(time.Millisecond))
+	}
+}
@@ -0,0 +1,103 @@
+package fetcher
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClientRetriesAndHeaders(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Referer") != "https://quote.eastmoney.com/" || !strings.Contains(r.Header.Get("User-Agent"), "Mozilla") {
+			t.Errorf("headers = %v", r.Header)
+		}
+		switch atomic.AddInt32(&calls, 1) {
+		case 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case 2:
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			w.Write([]byte(`{"ok":1}`))
+		}
+	}))
+	defer srv.Close()
+
+	c := NewClient(HTTPOptions{Backoff: time.Millisecond, QPS: 1000})
+	body, err := c.Get(context.Background(), time.Second, srv.URL+"/x")
+	if err != nil || string(body) != `{"ok":1}` {
+		t.Fatalf("body=%q err=%v", body, err)
+	}
+	// 4xx 不重试
+	c2 := NewClient(HTTPOptions{Backoff: time.Millisecond, QPS: 1000})
+	notFound := httptest.NewServer(http.NotFoundHandler())
+	defer notFound.Close()
+	if _, err := c2.Get(context.Background(), time.Second, notFound.URL); err == nil {
+		t.Error("404 should fail")
+	}
+
+	stats := c.Stats()
+	if len(stats) != 1 || stats[0].Requests != 3 || stats[0].Retries != 2 || stats[0].Errors != 0 {
+		t.Errorf("stats = %+v", stats)
+	}
+	if s := c2.Stats(); len(s) != 1 || s[0].Requests != 1 || s[0].Errors != 1 {
+		t.Errorf("404 stats = %+v", s)
+	}
+}
+
+func TestClientExplicitZeroRetries(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	// 显式 0 = 不重试 (不再被当成"未设置"改为默认的 2 次)
+	c := NewClient(HTTPOptions{MaxRetries: Retries(0), Backoff: time.Millisecond, QPS: 1000})
+	if _, err := c.Get(context.Background(), time.Second, srv.URL); err == nil {
+		t.Fatal("503 should fail")
+	}
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Errorf("requests = %d, want 1", n)
+	}
+}
+
+func TestClientRateLimitPerHost(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	// 50 QPS, 容量 1: 6 个并发请求至少排队 5 个间隔 (100ms)
+	c := NewClient(HTTPOptions{QPS: 50, Burst: 1})
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := c.Get(context.Background(), time.Second, srv.URL); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("6 requests at 50 QPS took %s", elapsed)
+	}
+	if s := c.Stats(); s[0].Requests != 6 || s[0].Waited == 0 {
+		t.Errorf("stats = %+v", s)
+	}
+
+	// 取消的 ctx 不再排队
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	c.reserve(strings.TrimPrefix(srv.URL, "http://")) // 清空令牌
+	if _, err := c.Get(ctx, time.Second, srv.URL); err == nil {
+		t.Error("cancelled request should fail")
+	}
+}
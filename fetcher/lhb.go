@@ -0,0 +1,280 @@
+package fetcher
+
+import (
+	"context"
+	"dragon-quant/calendar"
+	"dragon-quant/model"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// lhbAPI 东财数据中心 (测试中替换为本地服务)
+var lhbAPI = "https://datacenter-web.eastmoney.com/api/data/v1/get"
+
+// LHBLookbackDays FetchLHBData 回看的交易日数
+var LHBLookbackDays = 5
+
+const (
+	lhbReportSummary = "RPT_DAILYBILLBOARD_DETAILS"
+	lhbReportBuy     = "RPT_BILLBOARD_DAILYDETAILSBUY"
+	lhbReportSell    = "RPT_BILLBOARD_DAILYDETAILSSELL"
+)
+
+// lhbRow 汇总与席位明细共用的字段
+type lhbRow struct {
+	TradeDate string  `json:"TRADE_DATE"` // "2026-01-09 00:00:00"
+	Code      string  `json:"SECURITY_CODE"`
+	Name      string  `json:"SECURITY_NAME_ABBR"`
+	Explain   string  `json:"EXPLAIN"`     // "买一主买"
+	Reason    string  `json:"EXPLANATION"` // 上榜原因
+	NetAmt    float64 `json:"BILLBOARD_NET_AMT"`
+	BuyAmt    float64 `json:"BILLBOARD_BUY_AMT"`
+	SellAmt   float64 `json:"BILLBOARD_SELL_AMT"`
+
+	DeptName string  `json:"OPERATEDEPT_NAME"`
+	Buy      float64 `json:"BUY"`
+	Sell     float64 `json:"SELL"`
+	Net      float64 `json:"NET"`
+}
+
+// lhbQuery 查询数据中心报表 (自动翻页)。没有上榜记录时接口返回 result=null，不算错误
+func lhbQuery(ctx context.Context, report, filter string) ([]lhbRow, error) {
+	var rows []lhbRow
+	for page := 1; page <= 20; page++ {
+		u := fmt.Sprintf("%s?reportName=%s&columns=ALL&pageSize=500&pageNumber=%d&filter=%s",
+			lhbAPI, report, page, url.QueryEscape(filter))
+		body, err := getBody(ctx, 5*time.Second, u)
+		if err != nil {
+			return rows, fail(ctx, "FetchLHB", report+" "+filter, "", err)
+		}
+
+		var res struct {
+			Result *struct {
+				Pages int      `json:"pages"`
+				Data  []lhbRow `json:"data"`
+			} `json:"result"`
+		}
+		if err := json.Unmarshal(body, &res); err != nil {
+			return rows, fail(ctx, "FetchLHB", report+" "+filter, ErrSchema, err)
+		}
+		if res.Result == nil {
+			break
+		}
+		rows = append(rows, res.Result.Data...)
+		if page >= res.Result.Pages {
+			break
+		}
+	}
+	return rows, nil
+}
+
+// FetchLHB 回看最近 days 个已收盘交易日，返回该股全部上榜记录 (新→旧)，含买入/卖出席位明细与席位标签
+func FetchLHB(ctx context.Context, code string, days int) ([]model.LHBRecord, error) {
+	if days <= 0 {
+		days = 1
+	}
+	cal := calendar.Default()
+	latest := cal.LatestClosedSession(calendar.Now())
+	start := latest
+	for i := 1; i < days; i++ {
+		start = cal.PrevTradingDay(start)
+	}
+
+	// 1. 汇总
+	filter := fmt.Sprintf(`(SECURITY_CODE="%s")(TRADE_DATE>='%s')(TRADE_DATE<='%s')`,
+		code, start.Format("2006-01-02"), latest.Format("2006-01-02"))
+	summary, err := lhbQuery(ctx, lhbReportSummary, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. 每个上榜日拉买入/卖出前五席位
+	var buys, sells []lhbRow
+	seen := make(map[string]bool)
+	for _, r := range summary {
+		date := lhbDate(r.TradeDate)
+		if seen[date] {
+			continue
+		}
+		seen[date] = true
+		dayFilter := fmt.Sprintf(`(SECURITY_CODE="%s")(TRADE_DATE='%s')`, code, date)
+		b, err := lhbQuery(ctx, lhbReportBuy, dayFilter)
+		if err != nil {
+			return nil, err
+		}
+		sl, err := lhbQuery(ctx, lhbReportSell, dayFilter)
+		if err != nil {
+			return nil, err
+		}
+		buys = append(buys, b...)
+		sells = append(sells, sl...)
+	}
+	return buildLHBRecords(summary, buys, sells), nil
+}
+
+// FetchLHBByDate 返回某交易日 (2006-01-02) 全市场的龙虎榜记录与席位明细，用于建立席位库
+func FetchLHBByDate(ctx context.Context, date string) ([]model.LHBRecord, error) {
+	filter := fmt.Sprintf(`(TRADE_DATE='%s')`, date)
+	var parts [3][]lhbRow
+	for i, report := range []string{lhbReportSummary, lhbReportBuy, lhbReportSell} {
+		rows, err := lhbQuery(ctx, report, filter)
+		if err != nil {
+			return nil, err
+		}
+		parts[i] = rows
+	}
+	return buildLHBRecords(parts[0], parts[1], parts[2]), nil
+}
+
+// buildLHBRecords 按 (日期, 代码) 合并汇总行 (同一天多个上榜原因合并为一条) 并挂上席位，日期新→旧
+func buildLHBRecords(summary, buys, sells []lhbRow) []model.LHBRecord {
+	byKey := make(map[string]*model.LHBRecord)
+	var keys []string
+	for _, r := range summary {
+		date := lhbDate(r.TradeDate)
+		key := date + "|" + r.Code
+		rec, ok := byKey[key]
+		if !ok {
+			rec = &model.LHBRecord{
+				Date: date, Code: r.Code, Name: r.Name, Explain: r.Explain,
+				NetAmt: r.NetAmt, BuyAmt: r.BuyAmt, SellAmt: r.SellAmt,
+			}
+			byKey[key] = rec
+			keys = append(keys, key)
+		}
+		if r.Reason != "" && !strings.Contains(rec.Reason, r.Reason) {
+			if rec.Reason != "" {
+				rec.Reason += "; "
+			}
+			rec.Reason += r.Reason
+		}
+	}
+
+	group := func(rows []lhbRow) map[string][]lhbRow {
+		m := make(map[string][]lhbRow)
+		for _, r := range rows {
+			key := lhbDate(r.TradeDate) + "|" + r.Code
+			m[key] = append(m[key], r)
+		}
+		return m
+	}
+	buyBy, sellBy := group(buys), group(sells)
+
+	sort.Sort(sort.Reverse(sort.StringSlice(keys)))
+	dict := DefaultSeatDict()
+	var records []model.LHBRecord
+	for _, key := range keys {
+		rec := byKey[key]
+		rec.Seats = append(lhbSeats(buyBy[key], model.SeatBuy, dict), lhbSeats(sellBy[key], model.SeatSell, dict)...)
+		records = append(records, *rec)
+	}
+	return records
+}
+
+// lhbSeats 席位去重并打标签，按该侧金额降序。
+// 同一营业部会因多个上榜原因重复出现 (金额相同)；"机构专用" 可能是多家机构，金额不同则分别保留。
+func lhbSeats(rows []lhbRow, side string, dict *SeatDict) []model.LHBSeat {
+	seen := make(map[string]bool)
+	var seats []model.LHBSeat
+	for _, r := range rows {
+		if r.DeptName == "" {
+			continue
+		}
+		key := fmt.Sprintf("%s|%.0f|%.0f", r.DeptName, r.Buy, r.Sell)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		kind, alias := dict.Tag(r.DeptName)
+		seats = append(seats, model.LHBSeat{
+			Name: r.DeptName, Side: side,
+			BuyAmt: r.Buy, SellAmt: r.Sell, NetAmt: r.Buy - r.Sell,
+			Kind: kind, Alias: alias,
+		})
+	}
+	sort.SliceStable(seats, func(i, j int) bool {
+		if side == model.SeatSell {
+			return seats[i].SellAmt > seats[j].SellAmt
+		}
+		return seats[i].BuyAmt > seats[j].BuyAmt
+	})
+	return seats
+}
+
+func lhbDate(s string) string {
+	if len(s) >= 10 {
+		return s[:10]
+	}
+	return s
+}
+
+// FetchLHBData 最近 LHBLookbackDays 个交易日内最新一次上榜的摘要与席位明细写入 s
+func FetchLHBData(ctx context.Context, s *model.StockInfo) error {
+	records, err := FetchLHB(ctx, s.Code, LHBLookbackDays)
+	if err != nil || len(records) == 0 {
+		return err
+	}
+	rec := records[0]
+	s.LHBNet = rec.NetAmt
+	s.LHBDate = rec.Date
+	s.LHBSeats = rec.Seats
+
+	netStr := fmt.Sprintf("%.1f万", rec.NetAmt/10000)
+	if math.Abs(rec.NetAmt) > 100000000 {
+		netStr = fmt.Sprintf("%.1f亿", rec.NetAmt/100000000)
+	}
+
+	s.LHBInfo = fmt.Sprintf("%s 净:%s", rec.Explain, netStr)
+	if latest := calendar.Default().LatestClosedSession(calendar.Now()).Format("2006-01-02"); rec.Date != latest && len(rec.Date) == 10 {
+		s.LHBInfo = rec.Date[5:] + " " + s.LHBInfo
+	}
+	if tags := SeatSummary(rec.Seats); tags != "" {
+		s.LHBInfo += " " + tags
+	}
+	return nil
+}
+
+// SeatSummary 汇总已识别的席位，如 "买:机构x2/章盟主 卖:北向"
+func SeatSummary(seats []model.LHBSeat) string {
+	side := func(want string) string {
+		counts := make(map[string]int)
+		var order []string
+		for _, st := range seats {
+			if st.Side != want || st.Kind == "" {
+				continue
+			}
+			label := st.Kind
+			if st.Kind != SeatKindInstitution && st.Kind != SeatKindNorthbound && st.Alias != "" {
+				label = st.Alias
+			}
+			if counts[label] == 0 {
+				order = append(order, label)
+			}
+			counts[label]++
+		}
+		var parts []string
+		for _, l := range order {
+			if counts[l] > 1 {
+				parts = append(parts, fmt.Sprintf("%sx%d", l, counts[l]))
+			} else {
+				parts = append(parts, l)
+			}
+		}
+		return strings.Join(parts, "/")
+	}
+
+	var out []string
+	if b := side(model.SeatBuy); b != "" {
+		out = append(out, "买:"+b)
+	}
+	if s := side(model.SeatSell); s != "" {
+		out = append(out, "卖:"+s)
+	}
+	return strings.Join(out, " ")
+}
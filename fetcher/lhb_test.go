@@ -0,0 +1,100 @@
+package fetcher
+
+import (
+	"context"
+	"dragon-quant/model"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+// lhbFixtureServer 按 reportName 返回 testdata 中的样本
+func lhbFixtureServer(t *testing.T) *httptest.Server {
+	files := map[string]string{
+		lhbReportSummary: "testdata/lhb_summary.json",
+		lhbReportBuy:     "testdata/lhb_buy.json",
+		lhbReportSell:    "testdata/lhb_sell.json",
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, ok := files[r.URL.Query().Get("reportName")]
+		if !ok {
+			w.Write([]byte(`{"result":null}`))
+			return
+		}
+		// 席位明细只在 01-09 有样本
+		if path != files[lhbReportSummary] && !strings.Contains(r.URL.Query().Get("filter"), "2026-01-09") {
+			w.Write([]byte(`{"result":null}`))
+			return
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("read fixture: %v", err)
+		}
+		w.Write(data)
+	}))
+}
+
+func TestFetchLHBSeats(t *testing.T) {
+	srv := lhbFixtureServer(t)
+	defer srv.Close()
+	old := lhbAPI
+	lhbAPI = srv.URL
+	defer func() { lhbAPI = old }()
+
+	records, err := FetchLHB(context.Background(), "600123", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records (merged by date), got %d", len(records))
+	}
+	rec := records[0]
+	if rec.Date != "2026-01-09" || !strings.Contains(rec.Reason, "; ") {
+		t.Errorf("latest record should merge reasons: %+v", rec)
+	}
+
+	var buy, sell []model.LHBSeat
+	for _, s := range rec.Seats {
+		if s.Side == model.SeatBuy {
+			buy = append(buy, s)
+		} else {
+			sell = append(sell, s)
+		}
+	}
+	if len(buy) != 4 {
+		t.Fatalf("expected 4 buy seats (duplicate removed, two institutions kept), got %d: %+v", len(buy), buy)
+	}
+	if buy[0].Alias != "章盟主" || buy[0].Kind != SeatKindHotMoney {
+		t.Errorf("top buy seat should be 章盟主: %+v", buy[0])
+	}
+	if len(sell) != 2 || sell[0].Kind != SeatKindNorthbound || sell[0].NetAmt != -67000000 {
+		t.Errorf("unexpected sell seats: %+v", sell)
+	}
+
+	summary := SeatSummary(rec.Seats)
+	if summary != "买:章盟主/机构x2/东财拉萨 卖:北向" {
+		t.Errorf("SeatSummary = %q", summary)
+	}
+
+	if len(records[1].Seats) != 0 {
+		t.Errorf("older record without detail should have no seats")
+	}
+}
+
+func TestSeatDictOverride(t *testing.T) {
+	d, err := ParseSeatDict(strings.NewReader("游资|老股民|某某证券股份有限公司某某路\n"))
+	if err != nil {
+		t.Fatalf("ParseSeatDict failed: %v", err)
+	}
+	if err := d.Load(strings.NewReader("游资|新别名|某某证券股份有限公司某某路\n")); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if _, alias := d.Tag("某某证券股份有限公司某某路证券营业部"); alias != "新别名" {
+		t.Errorf("later entries should take precedence, got %q", alias)
+	}
+	if _, err := ParseSeatDict(strings.NewReader("bad line\n")); err == nil {
+		t.Error("expected error for malformed line")
+	}
+}
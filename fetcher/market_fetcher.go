@@ -1,34 +1,31 @@
 package fetcher
 
 import (
+	"context"
 	"dragon-quant/model"
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
-	"net/http"
 	"strconv"
 	"strings"
 	"time"
 )
 
 // 🆕 获取大盘(上证指数) 30分钟K线上下文
-func FetchMarket30mKline(days int) string {
+func FetchMarket30mKline(ctx context.Context, days int) (string, error) {
 	// 000001 (SH Index) -> secid: 1.000001
+	secID := model.ParseSecurity("sh000001").SecID()
 	// 56 bars = 7 days * 8 bars/day
 	limit := days * 8
-	url := fmt.Sprintf("http://push2his.eastmoney.com/api/qt/stock/kline/get?secid=1.000001&fields1=f1&fields2=f51,f53,f57,f6&klt=30&fqt=1&end=20500000&lmt=%d", limit)
+	url := fmt.Sprintf("http://push2his.eastmoney.com/api/qt/stock/kline/get?secid=%s&fields1=f1&fields2=f51,f53,f57,f6&klt=30&fqt=1&end=20500000&lmt=%d", secID, limit)
 
-	client := http.Client{Timeout: 5 * time.Second}
-	resp, err := client.Get(url)
+	body, err := getBody(ctx, 5*time.Second, url)
 	if err != nil {
-		fmt.Printf("❌ FetchMarketContext Error: %v\n", err)
-		return ""
+		return "", fail(ctx, "FetchMarket30mKline", "000001", "", err)
 	}
-	defer resp.Body.Close()
-
-	body, _ := ioutil.ReadAll(resp.Body)
 	var kResp model.KLineResponse
-	json.Unmarshal(body, &kResp)
+	if err := json.Unmarshal(body, &kResp); err != nil {
+		return "", fail(ctx, "FetchMarket30mKline", "000001", ErrSchema, err)
+	}
 
 	var sb strings.Builder
 	sb.WriteString(fmt.Sprintf("【上证指数 (000001) - 近%d日 30m走势】:\n", days))
@@ -37,7 +34,7 @@ func FetchMarket30mKline(days int) string {
 	// Last 56 bars
 	count := len(klines)
 	if count == 0 {
-		return ""
+		return "", fail(ctx, "FetchMarket30mKline", "000001", ErrEmpty, errEmpty)
 	}
 
 	lastClose := 0.0
@@ -68,5 +65,5 @@ func FetchMarket30mKline(days int) string {
 	}
 	result := sb.String()
 	fmt.Printf("\n🌡️ [Market Context Raw]:\n%s\n", result)
-	return result
+	return result, nil
 }
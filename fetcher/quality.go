@@ -0,0 +1,119 @@
+package fetcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// QualityReport 一次运行中的拉取失败汇总，区分 "接口坏了" 与 "没有符合条件的标的"。
+// 通过 WithQuality 挂在 ctx 上，fetcher 出错时自动记录；nil 时所有方法为空操作。
+type QualityReport struct {
+	mu       sync.Mutex
+	failures map[string]*Failure
+}
+
+// Failure 同一接口、同一代码、同一类别的失败 (Count 为次数，Err 为最后一次的错误)
+type Failure struct {
+	Op     string
+	Target string
+	Kind   ErrKind
+	Err    string
+	Count  int
+}
+
+type qualityKey struct{}
+
+func NewQualityReport() *QualityReport {
+	return &QualityReport{failures: make(map[string]*Failure)}
+}
+
+// WithQuality 返回挂载了 q 的 ctx
+func WithQuality(ctx context.Context, q *QualityReport) context.Context {
+	return context.WithValue(ctx, qualityKey{}, q)
+}
+
+// QualityFrom 取 ctx 上的报告 (没有时为 nil)
+func QualityFrom(ctx context.Context) *QualityReport {
+	q, _ := ctx.Value(qualityKey{}).(*QualityReport)
+	return q
+}
+
+// Record 记录一次 FetchError (非 FetchError 忽略)
+func (q *QualityReport) Record(err error) {
+	var fe *FetchError
+	if q == nil || !errors.As(err, &fe) {
+		return
+	}
+	key := fe.Op + "|" + fe.Target + "|" + string(fe.Kind)
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	f := q.failures[key]
+	if f == nil {
+		f = &Failure{Op: fe.Op, Target: fe.Target, Kind: fe.Kind}
+		q.failures[key] = f
+	}
+	f.Count++
+	f.Err = fe.Err.Error()
+}
+
+// Failures 按类别、接口、代码排序
+func (q *QualityReport) Failures() []Failure {
+	if q == nil {
+		return nil
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	out := make([]Failure, 0, len(q.failures))
+	for _, f := range q.failures {
+		out = append(out, *f)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Op != b.Op {
+			return a.Op < b.Op
+		}
+		return a.Target < b.Target
+	})
+	return out
+}
+
+// Len 失败条目数
+func (q *QualityReport) Len() int {
+	if q == nil {
+		return 0
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.failures)
+}
+
+// Print 按类别、接口分组打印失败的代码/板块
+func (q *QualityReport) Print() {
+	failures := q.Failures()
+	if len(failures) == 0 {
+		fmt.Println("✅ [数据质量] 本次运行所有接口拉取正常")
+		return
+	}
+	fmt.Printf("\n🩺 [数据质量] %d 项拉取失败:\n", len(failures))
+	for i := 0; i < len(failures); {
+		j := i
+		var targets []string
+		for ; j < len(failures) && failures[j].Kind == failures[i].Kind && failures[j].Op == failures[i].Op; j++ {
+			t := failures[j].Target
+			if failures[j].Count > 1 {
+				t = fmt.Sprintf("%s(x%d)", t, failures[j].Count)
+			}
+			targets = append(targets, t)
+		}
+		fmt.Printf("   - [%s] %s (%d): %s\n", failures[i].Kind, failures[i].Op, j-i, strings.Join(targets, ", "))
+		fmt.Printf("     例: %s\n", failures[j-1].Err)
+		i = j
+	}
+}
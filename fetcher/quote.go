@@ -0,0 +1,109 @@
+package fetcher
+
+import (
+	"context"
+	"dragon-quant/calendar"
+	"dragon-quant/model"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// quoteAPI 个股实时行情 (测试中替换为本地服务)
+var quoteAPI = "http://push2.eastmoney.com/api/qt/stock/get"
+
+// 个股行情字段 (fltt=2 时价格为小数，量为手):
+// f43 现价, f47 成交量, f48 成交额, f51 涨停价, f52 跌停价, f60 昨收, f85 流通股本, f86 行情时间
+// 买一~买五: f19/f20 f17/f18 f15/f16 f13/f14 f11/f12
+// 卖一~卖五: f39/f40 f37/f38 f35/f36 f33/f34 f31/f32
+var (
+	bidFields = [5][2]string{{"f19", "f20"}, {"f17", "f18"}, {"f15", "f16"}, {"f13", "f14"}, {"f11", "f12"}}
+	askFields = [5][2]string{{"f39", "f40"}, {"f37", "f38"}, {"f35", "f36"}, {"f33", "f34"}, {"f31", "f32"}}
+)
+
+const bookFields = "f43,f51,f52,f60,f85,f86," +
+	"f19,f20,f17,f18,f15,f16,f13,f14,f11,f12," +
+	"f39,f40,f37,f38,f35,f36,f33,f34,f31,f32"
+
+// quoteNum 兼容行情接口的 "-" (无数据) 与字符串数值
+type quoteNum float64
+
+func (n *quoteNum) UnmarshalJSON(b []byte) error {
+	s := strings.Trim(string(b), `"`)
+	if s == "" || s == "-" || s == "null" {
+		*n = 0
+		return nil
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return err
+	}
+	*n = quoteNum(v)
+	return nil
+}
+
+// quote 个股行情字段 -> 数值
+type quote map[string]quoteNum
+
+func (q quote) num(field string) float64 {
+	return float64(q[field])
+}
+
+// time 行情时间 (北京时间)，缺失时取当前时间
+func (q quote) time() time.Time {
+	now := calendar.Now()
+	if ts := int64(q["f86"]); ts > 0 {
+		return time.Unix(ts, 0).In(now.Location())
+	}
+	return now
+}
+
+// orderBook 解析五档盘口
+func (q quote) orderBook() model.OrderBook {
+	book := model.OrderBook{
+		Price:       q.num("f43"),
+		PrevClose:   q.num("f60"),
+		LimitUp:     q.num("f51"),
+		LimitDown:   q.num("f52"),
+		FloatShares: q.num("f85"),
+		Time:        q.time(),
+	}
+	for i := 0; i < 5; i++ {
+		book.Bids[i] = model.BookLevel{Price: q.num(bidFields[i][0]), Vol: int64(q.num(bidFields[i][1]))}
+		book.Asks[i] = model.BookLevel{Price: q.num(askFields[i][0]), Vol: int64(q.num(askFields[i][1]))}
+	}
+	return book
+}
+
+// fetchQuote 拉取个股行情指定字段
+func fetchQuote(ctx context.Context, op, code, fields string) (quote, error) {
+	secID := Resolve(code).SecID()
+	u := fmt.Sprintf("%s?secid=%s&fltt=2&fields=%s", quoteAPI, secID, fields)
+
+	body, err := getBody(ctx, 3*time.Second, u)
+	if err != nil {
+		return nil, fail(ctx, op, code, "", err)
+	}
+
+	var wrapper struct {
+		Data quote `json:"data"`
+	}
+	if err := json.Unmarshal(body, &wrapper); err != nil {
+		return nil, fail(ctx, op, code, ErrSchema, err)
+	}
+	if wrapper.Data == nil {
+		return nil, fail(ctx, op, code, ErrEmpty, errEmpty)
+	}
+	return wrapper.Data, nil
+}
+
+// FetchOrderBook 五档盘口 (东财失败时按数据源链切到腾讯 / 新浪)
+func FetchOrderBook(ctx context.Context, code string) (model.OrderBook, bool) {
+	q, err := Sources().Quote(ctx, code)
+	if err != nil {
+		return model.OrderBook{}, false
+	}
+	return q.OrderBook, true
+}
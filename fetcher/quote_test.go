@@ -0,0 +1,48 @@
+package fetcher
+
+import (
+	"context"
+	"dragon-quant/model"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func TestFetchStockDetailsOrderBook(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("secid") != "0.002001" {
+			t.Errorf("secid = %s", r.URL.Query().Get("secid"))
+		}
+		data, err := os.ReadFile("testdata/quote_limit_up.json")
+		if err != nil {
+			t.Fatalf("read fixture: %v", err)
+		}
+		w.Write(data)
+	}))
+	defer srv.Close()
+	old := quoteAPI
+	quoteAPI = srv.URL
+	defer func() { quoteAPI = old }()
+
+	s := &model.StockInfo{Code: "002001"}
+	if err := FetchStockDetails(context.Background(), s); err != nil {
+		t.Fatal(err)
+	}
+
+	b := s.OrderBook
+	if b == nil {
+		t.Fatal("OrderBook not set")
+	}
+	if b.Bids[0] != (model.BookLevel{Price: 11.0, Vol: 120000}) || b.Bids[4] != (model.BookLevel{Price: 10.96, Vol: 300}) {
+		t.Errorf("bids = %+v", b.Bids)
+	}
+	// 涨停时卖盘为 "-"
+	if b.Asks[0].Vol != 0 || b.LimitUp != 11.0 || b.FloatShares != 5e8 {
+		t.Errorf("asks=%+v limitUp=%.2f float=%.0f", b.Asks, b.LimitUp, b.FloatShares)
+	}
+	// 卖一量以前误取了 f18 (买二量)
+	if s.Buy1Price != 11.0 || s.Buy1Vol != 120000 || s.Sell1Vol != 0 || s.CallAuctionAmt != 8.6e7 {
+		t.Errorf("buy1=%.2f/%d sell1=%d call=%.0f", s.Buy1Price, s.Buy1Vol, s.Sell1Vol, s.CallAuctionAmt)
+	}
+}
@@ -0,0 +1,129 @@
+package fetcher
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+//go:embed seats.txt
+var bundledSeats string
+
+// 席位类型
+const (
+	SeatKindInstitution = "机构"
+	SeatKindNorthbound  = "北向"
+	SeatKindHotMoney    = "游资"
+	SeatKindQuant       = "量化"
+	SeatKindLasa        = "拉萨天团"
+)
+
+type seatEntry struct {
+	Kind    string
+	Alias   string
+	Pattern string
+}
+
+// SeatDict 龙虎榜席位字典: 按营业部名称关键字识别机构/北向/知名游资
+type SeatDict struct {
+	entries []seatEntry
+}
+
+// ParseSeatDict 解析席位字典 (每行 "类型|别名|关键字"，# 开头为注释)
+func ParseSeatDict(r io.Reader) (*SeatDict, error) {
+	d := &SeatDict{}
+	if err := d.Load(r); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Load 追加条目，新加载的条目优先匹配
+func (d *SeatDict) Load(r io.Reader) error {
+	var added []seatEntry
+	sc := bufio.NewScanner(r)
+	lineNo := 0
+	for sc.Scan() {
+		lineNo++
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.Split(line, "|")
+		if len(parts) != 3 || strings.TrimSpace(parts[2]) == "" {
+			return fmt.Errorf("seat dict line %d: want kind|alias|pattern", lineNo)
+		}
+		added = append(added, seatEntry{
+			Kind:    strings.TrimSpace(parts[0]),
+			Alias:   strings.TrimSpace(parts[1]),
+			Pattern: strings.TrimSpace(parts[2]),
+		})
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	d.entries = append(added, d.entries...)
+	return nil
+}
+
+// Tag 返回营业部名称对应的类型与别名 (未知席位返回空)
+func (d *SeatDict) Tag(name string) (kind, alias string) {
+	for _, e := range d.entries {
+		if strings.Contains(name, e.Pattern) {
+			return e.Kind, e.Alias
+		}
+	}
+	return "", ""
+}
+
+var (
+	seatDictMu sync.RWMutex
+	seatDict   *SeatDict
+)
+
+// DefaultSeatDict 返回当前使用的席位字典 (默认为内置 seats.txt)
+func DefaultSeatDict() *SeatDict {
+	seatDictMu.RLock()
+	d := seatDict
+	seatDictMu.RUnlock()
+	if d != nil {
+		return d
+	}
+
+	seatDictMu.Lock()
+	defer seatDictMu.Unlock()
+	if seatDict == nil {
+		dict, err := ParseSeatDict(strings.NewReader(bundledSeats))
+		if err != nil {
+			panic(fmt.Sprintf("bundled seats.txt invalid: %v", err))
+		}
+		seatDict = dict
+	}
+	return seatDict
+}
+
+// LoadSeatDict 在内置字典基础上追加 path (为空则只用内置字典)，并设为默认字典
+func LoadSeatDict(path string) error {
+	d, err := ParseSeatDict(strings.NewReader(bundledSeats))
+	if err != nil {
+		return err
+	}
+	if path != "" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if err := d.Load(f); err != nil {
+			return fmt.Errorf("load seats %s: %w", path, err)
+		}
+	}
+	seatDictMu.Lock()
+	seatDict = d
+	seatDictMu.Unlock()
+	return nil
+}
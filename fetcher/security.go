@@ -0,0 +1,58 @@
+package fetcher
+
+import (
+	"dragon-quant/model"
+	"strings"
+)
+
+// --- 证券市场解析: 代码 -> model.Security (secid / 新浪腾讯代码 / 板块) ---
+
+var searchAPI = "http://searchapi.eastmoney.com/api/suggest/get"
+
+// RegisterSecurity 登记接口确认过的证券 (按 secid，见 model.RegisterSecurity)
+func RegisterSecurity(sec model.Security) {
+	model.RegisterSecurity(sec)
+}
+
+// Resolve 代码所属的证券: 带市场前缀的写法直接解析，纯代码按代码段推断，再用 SearchStock 的登记补全名称与类别。
+// SearchStock 查到的证券请用 sec.Key() 传递，上证指数会写成 sh000001
+func Resolve(code string) model.Security {
+	return model.ResolveSecurity(code)
+}
+
+// searchMatch 搜索接口的一条结果。QuoteID 形如 "1.000001"；
+// MarketType: 1 上海、2 深圳 (北交所、板块按代码段)；SecurityTypeName 如 沪A / 深A / 京A / 指数 / 基金 / 板块
+type searchMatch struct {
+	Code         string `json:"Code"`
+	Name         string `json:"Name"`
+	Mkt          string `json:"MarketType"`
+	QuoteID      string `json:"QuoteID"`
+	Classify     string `json:"Classify"`
+	SecurityType string `json:"SecurityTypeName"`
+}
+
+func (m searchMatch) security() model.Security {
+	var sec model.Security
+	switch {
+	case m.QuoteID != "":
+		sec = model.ParseSecurity(m.QuoteID)
+	case m.Mkt == "1":
+		sec = model.NewSecurity(m.Code, model.ExchangeSH)
+	case m.Mkt == "2":
+		sec = model.NewSecurity(m.Code, model.ExchangeSZ)
+	default:
+		sec = model.ParseSecurity(m.Code)
+	}
+	sec.Code, sec.Name = m.Code, m.Name
+	kind := m.Classify + m.SecurityType
+	switch {
+	case strings.Contains(kind, "指数") || strings.Contains(kind, "Index"):
+		sec.Board, sec.Type = model.BoardIndex, model.TypeIndex
+	case strings.Contains(kind, "基金") || strings.Contains(kind, "Fund"):
+		sec.Board, sec.Type = model.BoardFund, model.TypeFund
+	case strings.Contains(kind, "京"):
+		sec = model.NewSecurity(m.Code, model.ExchangeBJ)
+		sec.Name = m.Name
+	}
+	return sec
+}
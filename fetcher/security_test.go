@@ -0,0 +1,75 @@
+package fetcher
+
+import (
+	"context"
+	"dragon-quant/model"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func TestParseSecurity(t *testing.T) {
+	cases := []struct {
+		code       string
+		secID, sym string
+		board      model.Board
+		typ        model.SecurityType
+		limitPct   float64
+	}{
+		{"600519", "1.600519", "sh600519", model.BoardMain, model.TypeStock, 0.10},
+		{"000001", "0.000001", "sz000001", model.BoardMain, model.TypeStock, 0.10},
+		{"sh000001", "1.000001", "sh000001", model.BoardIndex, model.TypeIndex, 0},
+		{"000300.SH", "1.000300", "sh000300", model.BoardIndex, model.TypeIndex, 0},
+		{"399006", "0.399006", "sz399006", model.BoardIndex, model.TypeIndex, 0},
+		{"510300", "1.510300", "sh510300", model.BoardFund, model.TypeFund, 0.10},
+		{"159915", "0.159915", "sz159915", model.BoardFund, model.TypeFund, 0.10},
+		{"688981", "1.688981", "sh688981", model.BoardSTAR, model.TypeStock, 0.20},
+		{"300750", "0.300750", "sz300750", model.BoardChiNext, model.TypeStock, 0.20},
+		{"430047", "0.430047", "bj430047", model.BoardBSE, model.TypeStock, 0.30},
+		{"920002", "0.920002", "bj920002", model.BoardBSE, model.TypeStock, 0.30},
+		{"900901", "1.900901", "sh900901", model.BoardB, model.TypeStock, 0.10},
+		{"200002", "0.200002", "sz200002", model.BoardB, model.TypeStock, 0.10},
+		{"BK0477", "90.BK0477", "", model.BoardSector, model.TypeSector, 0},
+		{"1.000001", "1.000001", "sh000001", model.BoardIndex, model.TypeIndex, 0},
+	}
+	for _, c := range cases {
+		s := model.ParseSecurity(c.code)
+		if s.SecID() != c.secID || s.Symbol() != c.sym || s.Board != c.board || s.Type != c.typ || s.LimitPct() != c.limitPct {
+			t.Errorf("%s -> %+v secid=%s symbol=%s limit=%.2f", c.code, s, s.SecID(), s.Symbol(), s.LimitPct())
+		}
+	}
+}
+
+func TestSearchStockRegistersMarket(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := os.ReadFile("testdata/search_index.json")
+		w.Write(data)
+	}))
+	defer srv.Close()
+	oldSearch := searchAPI
+	searchAPI = srv.URL + "/search"
+	defer func() { searchAPI = oldSearch }()
+
+	sec, err := SearchStock(context.Background(), "上证指数")
+	if err != nil || sec.Code != "000001" || sec.Exchange != model.ExchangeSH || sec.Type != model.TypeIndex || sec.Name != "上证指数" {
+		t.Fatalf("sec = %+v err = %v", sec, err)
+	}
+	// 调用方拿到的是带市场的写法，继续拉取时仍是上证指数
+	if sec.Key() != "sh000001" {
+		t.Errorf("key = %q, want sh000001", sec.Key())
+	}
+	if got := Resolve(sec.Key()); got.SecID() != "1.000001" || got.Name != "上证指数" || model.LimitPct(sec.Key(), "") != 0 {
+		t.Errorf("index resolve = %+v", got)
+	}
+	// 同一进程里再解析平安银行 000001: 不被上证指数的登记顶替
+	if got := Resolve("000001"); got.SecID() != "0.000001" || got.Symbol() != "sz000001" || got.Type != model.TypeStock {
+		t.Errorf("stock resolve = %+v", got)
+	}
+	if pct := model.LimitPct("000001", "平安银行"); pct != 0.10 {
+		t.Errorf("stock limit = %.2f, want 0.10", pct)
+	}
+	if key := model.ParseSecurity("600519").Key(); key != "600519" {
+		t.Errorf("plain stock key = %q", key)
+	}
+}
@@ -0,0 +1,156 @@
+package fetcher
+
+import (
+	"bytes"
+	"context"
+	"dragon-quant/calendar"
+	"dragon-quant/model"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// 新浪行情 (测试中替换为本地服务)。必须带新浪的 Referer，否则返回 403
+var (
+	sinaQuoteAPI = "http://hq.sinajs.cn/list="
+	sinaKLineAPI = "https://money.finance.sina.com.cn/quotes_service/api/json_v2.php/CN_MarketData.getKLineData"
+)
+
+const sinaReferer = "https://finance.sina.com.cn/"
+
+// Sina 新浪财经: 实时行情带五档，K 线为不复权、无成交额
+type Sina struct{}
+
+func (Sina) Name() string { return "sina" }
+
+// Quote 实时行情: var hq_str_sh600519="名称,今开,昨收,现价,最高,最低,买一,卖一,成交量(股),成交额,
+// 买一量,买一价,...,买五量,买五价,卖一量,卖一价,...,卖五量,卖五价,日期,时间,..."
+// 名称为 GBK 编码，非 UTF-8 时留空
+func (Sina) Quote(ctx context.Context, code string) (model.Quote, error) {
+	body, err := HTTPClient().GetWithReferer(ctx, 3*time.Second, sinaQuoteAPI+symbol(code), sinaReferer)
+	if err != nil {
+		return model.Quote{}, fail(ctx, "SinaQuote", code, "", err)
+	}
+	payload, ok := jsString(body)
+	if !ok {
+		return model.Quote{}, fail(ctx, "SinaQuote", code, ErrSchema, fmt.Errorf("unexpected payload %.80q", body))
+	}
+	if payload == "" {
+		return model.Quote{}, fail(ctx, "SinaQuote", code, ErrEmpty, errEmpty)
+	}
+	f := strings.Split(payload, ",")
+	if len(f) < 32 {
+		return model.Quote{}, fail(ctx, "SinaQuote", code, ErrSchema, fmt.Errorf("%d fields, want >= 32", len(f)))
+	}
+	nums, err := parseFields(f, 1, 30)
+	if err != nil {
+		return model.Quote{}, fail(ctx, "SinaQuote", code, ErrSchema, err)
+	}
+
+	q := model.Quote{
+		Code:   code,
+		Open:   nums[1],
+		High:   nums[4],
+		Low:    nums[5],
+		Volume: nums[8] / 100,
+		Amount: nums[9],
+		Source: "sina",
+	}
+	if utf8.ValidString(f[0]) {
+		q.Name = f[0]
+	}
+	q.Price, q.PrevClose = nums[3], nums[2]
+	for i := 0; i < 5; i++ {
+		q.Bids[i] = model.BookLevel{Price: nums[11+2*i], Vol: int64(nums[10+2*i] / 100)}
+		q.Asks[i] = model.BookLevel{Price: nums[21+2*i], Vol: int64(nums[20+2*i] / 100)}
+	}
+	q.Time = calendar.Now()
+	if t, err := time.ParseInLocation("2006-01-02 15:04:05", f[30]+" "+f[31], q.Time.Location()); err == nil {
+		q.Time = t
+	}
+	return q, nil
+}
+
+// Daily / Min30 新浪 K 线只有不复权
+func (Sina) Daily(ctx context.Context, code string, limit int, adj model.Adjust) ([]model.KLineData, error) {
+	if adj != model.AdjustNone {
+		return nil, unsupportedAdjust("SinaDaily", code, adj)
+	}
+	return sinaKLines(ctx, "SinaDaily", code, 240, limit)
+}
+
+func (Sina) Min30(ctx context.Context, code string, limit int, adj model.Adjust) ([]model.KLineData, error) {
+	if adj != model.AdjustNone {
+		return nil, unsupportedAdjust("SinaMin30", code, adj)
+	}
+	return sinaKLines(ctx, "SinaMin30", code, 30, limit)
+}
+
+// sinaKLines scale 为分钟数 (240 = 日线)。返回 [{"day":"2026-01-09 10:00:00","open":"10.0",...,"volume":"123400"}]
+func sinaKLines(ctx context.Context, op, code string, scale, limit int) ([]model.KLineData, error) {
+	url := fmt.Sprintf("%s?symbol=%s&scale=%d&ma=no&datalen=%d", sinaKLineAPI, symbol(code), scale, limit)
+	body, err := HTTPClient().GetWithReferer(ctx, 5*time.Second, url, sinaReferer)
+	if err != nil {
+		return nil, fail(ctx, op, code, "", err)
+	}
+	var rows []struct {
+		Day    string `json:"day"`
+		Open   string `json:"open"`
+		High   string `json:"high"`
+		Low    string `json:"low"`
+		Close  string `json:"close"`
+		Volume string `json:"volume"`
+	}
+	if err := json.Unmarshal(body, &rows); err != nil {
+		return nil, fail(ctx, op, code, ErrSchema, err)
+	}
+	if len(rows) == 0 {
+		return nil, fail(ctx, op, code, ErrEmpty, errEmpty)
+	}
+
+	bars := make([]ohlcv, 0, len(rows))
+	for i, r := range rows {
+		nums, err := parseFields([]string{r.Open, r.High, r.Low, r.Close, r.Volume}, 0, 5)
+		if err != nil {
+			return nil, fail(ctx, op, code, ErrSchema, fmt.Errorf("bar %d: %w", i, err))
+		}
+		date := r.Day
+		if scale < 240 && len(date) >= 16 {
+			date = date[:16] // 与东财一致: 2006-01-02 15:04
+		} else if scale >= 240 && len(date) >= 10 {
+			date = date[:10]
+		}
+		bars = append(bars, ohlcv{date: date, open: nums[0], high: nums[1], low: nums[2], close: nums[3], volume: nums[4]})
+	}
+	return toKLines(bars), nil
+}
+
+// jsString 取 `var x="...";` 中引号内的内容
+func jsString(body []byte) (string, bool) {
+	start := bytes.IndexByte(body, '"')
+	end := bytes.LastIndexByte(body, '"')
+	if start < 0 || end <= start {
+		return "", false
+	}
+	return string(body[start+1 : end]), true
+}
+
+// parseFields 把 f[from:to] 解析为数值 (空串记 0)
+func parseFields(f []string, from, to int) ([]float64, error) {
+	nums := make([]float64, to)
+	for i := from; i < to; i++ {
+		s := strings.TrimSpace(f[i])
+		if s == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, fmt.Errorf("field %d: %q", i, s)
+		}
+		nums[i] = v
+	}
+	return nums, nil
+}
@@ -0,0 +1,186 @@
+package fetcher
+
+import (
+	"context"
+	"dragon-quant/model"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// --- 多数据源: 东财为主，腾讯 / 新浪备用 ---
+// Chain 按顺序尝试，前一个源失败 (网络 / HTTP / 结构变化 / 空数据) 时自动切到下一个。
+// K 线按调用方指定的复权口径拉取: 东财三种都支持，腾讯日K支持三种、30分钟只有不复权，新浪只有不复权
+// (local 为导入时记录的口径)；不支持的口径返回空数据，由链切到下一个源。新浪、腾讯的 K 线没有成交额，按成交量 × 均价估算。
+
+// Source 一个行情数据源
+type Source interface {
+	Name() string
+	Quote(ctx context.Context, code string) (model.Quote, error)
+	Daily(ctx context.Context, code string, limit int, adj model.Adjust) ([]model.KLineData, error)
+	Min30(ctx context.Context, code string, limit int, adj model.Adjust) ([]model.KLineData, error)
+}
+
+// Chain 按顺序尝试的数据源链
+type Chain []Source
+
+func (c Chain) Quote(ctx context.Context, code string) (model.Quote, error) {
+	return failover(ctx, c, "Quote", code, func(s Source) (model.Quote, error) { return s.Quote(ctx, code) })
+}
+
+func (c Chain) Daily(ctx context.Context, code string, limit int, adj model.Adjust) ([]model.KLineData, error) {
+	return failover(ctx, c, "Daily", code, func(s Source) ([]model.KLineData, error) { return s.Daily(ctx, code, limit, adj) })
+}
+
+func (c Chain) Min30(ctx context.Context, code string, limit int, adj model.Adjust) ([]model.KLineData, error) {
+	return failover(ctx, c, "Min30", code, func(s Source) ([]model.KLineData, error) { return s.Min30(ctx, code, limit, adj) })
+}
+
+// Names 各数据源名称
+func (c Chain) Names() []string {
+	names := make([]string, len(c))
+	for i, s := range c {
+		names[i] = s.Name()
+	}
+	return names
+}
+
+// switched 已提示过的切换 (同一接口、同一失败源只提示一次，失败明细见数据质量报告)
+var switched sync.Map
+
+// failover 依次调用各数据源，返回第一个成功的结果；全部失败时返回各源错误的合并
+func failover[T any](ctx context.Context, c Chain, op, code string, call func(Source) (T, error)) (T, error) {
+	var zero T
+	var errs []error
+	for i, src := range c {
+		v, err := call(src)
+		if err == nil {
+			if i > 0 {
+				key := op + "|" + c[i-1].Name()
+				if _, seen := switched.LoadOrStore(key, true); !seen {
+					fmt.Printf("🔀 [数据源] %s %s: %s 失败，已切换到 %s (后续同类切换不再提示)\n",
+						op, code, strings.Join(c[:i].Names(), "/"), src.Name())
+				}
+			}
+			return v, nil
+		}
+		errs = append(errs, err)
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	if len(errs) == 0 {
+		return zero, fail(ctx, op, code, ErrEmpty, errors.New("no data source configured"))
+	}
+	return zero, errors.Join(errs...)
+}
+
+var (
+	sourcesMu sync.RWMutex
+	sources   = Chain{EastMoney{}, Tencent{}, Sina{}}
+)
+
+// SetSources 替换默认数据源链 (启动时按 config 设置)
+func SetSources(c Chain) {
+	sourcesMu.Lock()
+	sources = c
+	sourcesMu.Unlock()
+}
+
+// Sources 当前数据源链
+func Sources() Chain {
+	sourcesMu.RLock()
+	defer sourcesMu.RUnlock()
+	return sources
+}
+
+// ParseSources 按名称 (eastmoney / tencent / sina) 组成数据源链；
+// extra 为其他包提供的数据源 (如仓库的 local)，按 Name() 匹配
+func ParseSources(names []string, extra ...Source) (Chain, error) {
+	var c Chain
+next:
+	for _, n := range names {
+		name := strings.ToLower(strings.TrimSpace(n))
+		for _, src := range extra {
+			if src.Name() == name {
+				c = append(c, src)
+				continue next
+			}
+		}
+		switch name {
+		case "eastmoney", "em":
+			c = append(c, EastMoney{})
+		case "tencent", "qq":
+			c = append(c, Tencent{})
+		case "sina":
+			c = append(c, Sina{})
+		default:
+			return nil, fmt.Errorf("unknown data source %q", n)
+		}
+	}
+	return c, nil
+}
+
+// EastMoney 东方财富 (主数据源，字段最全: 竞价金额、涨跌停价、流通股本)
+type EastMoney struct{}
+
+func (EastMoney) Name() string { return "eastmoney" }
+
+// f44 最高, f45 最低, f46 今开, f47 成交量 (手), f48 成交额
+func (EastMoney) Quote(ctx context.Context, code string) (model.Quote, error) {
+	q, err := fetchQuote(ctx, "FetchQuote", code, bookFields+",f44,f45,f46,f47,f48")
+	if err != nil {
+		return model.Quote{}, err
+	}
+	return model.Quote{
+		OrderBook: q.orderBook(),
+		Code:      code,
+		Open:      q.num("f46"),
+		High:      q.num("f44"),
+		Low:       q.num("f45"),
+		Volume:    q.num("f47"),
+		Amount:    q.num("f48"),
+		Source:    "eastmoney",
+	}, nil
+}
+
+func (EastMoney) Daily(ctx context.Context, code string, limit int, adj model.Adjust) ([]model.KLineData, error) {
+	return FetchDailyAdj(ctx, code, limit, adj)
+}
+
+func (EastMoney) Min30(ctx context.Context, code string, limit int, adj model.Adjust) ([]model.KLineData, error) {
+	return Fetch30MinKlineAdj(ctx, code, limit, adj)
+}
+
+// symbol 新浪 / 腾讯的代码格式: sh600519 / sz000001 / bj430047
+func symbol(code string) string {
+	return Resolve(code).Symbol()
+}
+
+// ohlcv 新浪 / 腾讯的一根 K 线 (成交量单位: 股)
+type ohlcv struct {
+	date                           string
+	open, high, low, close, volume float64
+}
+
+// toKLines 转为 KLineData: Change 为较前一根的涨跌额 (与东财一致)，成交额按 成交量 × (开+高+低+收)/4 估算
+func toKLines(bars []ohlcv) []model.KLineData {
+	klines := make([]model.KLineData, 0, len(bars))
+	for i, b := range bars {
+		k := model.KLineData{
+			Date:   b.date,
+			Open:   b.open,
+			High:   b.high,
+			Low:    b.low,
+			Close:  b.close,
+			Volume: b.volume / 100,
+			Amount: b.volume * (b.open + b.high + b.low + b.close) / 4,
+		}
+		if i > 0 {
+			k.Change = b.close - bars[i-1].close
+		}
+		klines = append(klines, k)
+	}
+	return klines
+}
@@ -0,0 +1,248 @@
+package fetcher
+
+import (
+	"context"
+	"dragon-quant/model"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fixtureServer 按路径返回 testdata 中录制的新浪 / 腾讯响应，并把各接口指向它
+func fixtureServer(t *testing.T) {
+	t.Helper()
+	files := map[string]string{
+		"/sina/list=sh600519": "sina_quote.txt",
+		"/sina/kline/240":     "sina_kline_day.json",
+		"/sina/kline/30":      "sina_kline_m30.json",
+		"/qq/q=sh600519":      "tencent_quote.txt",
+		"/qq/fqkline":         "tencent_kline_day.json",
+		"/qq/mkline":          "tencent_kline_m30.json",
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := r.URL.Path
+		if path == "/sina/kline" {
+			if r.Header.Get("Referer") != sinaReferer {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			path += "/" + r.URL.Query().Get("scale")
+		}
+		name, ok := files[path]
+		if !ok { // 代码不存在
+			if strings.HasPrefix(path, "/sina/") {
+				w.Write([]byte(`var hq_str_sh600000="";`))
+			} else {
+				w.Write([]byte(`v_pv_none_match="1";`))
+			}
+			return
+		}
+		data, err := os.ReadFile("testdata/" + name)
+		if err != nil {
+			t.Fatalf("read fixture: %v", err)
+		}
+		w.Write(data)
+	}))
+	t.Cleanup(srv.Close)
+
+	apis := []*string{&sinaQuoteAPI, &sinaKLineAPI, &tencentQuoteAPI, &tencentKLineAPI, &tencentMinAPI}
+	old := make([]string, len(apis))
+	for i, p := range apis {
+		old[i] = *p
+	}
+	sinaQuoteAPI, sinaKLineAPI = srv.URL+"/sina/list=", srv.URL+"/sina/kline"
+	tencentQuoteAPI, tencentKLineAPI, tencentMinAPI = srv.URL+"/qq/q=", srv.URL+"/qq/fqkline", srv.URL+"/qq/mkline"
+	t.Cleanup(func() {
+		for i, p := range apis {
+			*p = old[i]
+		}
+	})
+}
+
+func TestSinaTencentQuotes(t *testing.T) {
+	fixtureServer(t)
+	ctx := context.Background()
+
+	for _, src := range []Source{Sina{}, Tencent{}} {
+		q, err := src.Quote(ctx, "600519")
+		if err != nil {
+			t.Fatalf("%s: %v", src.Name(), err)
+		}
+		// 名称是 GBK，不解码
+		if q.Source != src.Name() || q.Name != "" || q.Price != 1710 || q.PrevClose != 1698 || q.Open != 1700 || q.High != 1720 || q.Low != 1695 {
+			t.Errorf("%s quote = %+v", src.Name(), q)
+		}
+		if q.Volume != 23456 || q.Amount < 4.0123e9 || q.Amount > 4.0124e9 {
+			t.Errorf("%s volume=%.0f amount=%.0f", src.Name(), q.Volume, q.Amount)
+		}
+		if q.Bids[0] != (model.BookLevel{Price: 1709.99, Vol: 12}) || q.Asks[4] != (model.BookLevel{Price: 1711.2, Vol: 1}) {
+			t.Errorf("%s book = %+v / %+v", src.Name(), q.Bids, q.Asks)
+		}
+		if q.Time.Format("2006-01-02 15:04:05") != "2026-01-09 15:00:03" {
+			t.Errorf("%s time = %s", src.Name(), q.Time)
+		}
+	}
+	// 腾讯额外提供涨跌停价与流通市值
+	q, _ := Tencent{}.Quote(ctx, "600519")
+	if q.LimitUp != 1867.8 || q.LimitDown != 1528.2 || q.FloatShares < 1.25e9 || q.FloatShares > 1.26e9 {
+		t.Errorf("limits = %.2f/%.2f float=%.0f", q.LimitUp, q.LimitDown, q.FloatShares)
+	}
+	// 代码不存在
+	for _, src := range []Source{Sina{}, Tencent{}} {
+		if _, err := src.Quote(ctx, "600000"); KindOf(err) != ErrEmpty {
+			t.Errorf("%s unknown code: %v", src.Name(), err)
+		}
+	}
+}
+
+func TestSinaTencentKLines(t *testing.T) {
+	fixtureServer(t)
+	ctx := context.Background()
+
+	for _, src := range []Source{Sina{}, Tencent{}} {
+		daily, err := src.Daily(ctx, "600519", 3, model.AdjustNone)
+		if err != nil || len(daily) != 3 {
+			t.Fatalf("%s daily: %d bars, %v", src.Name(), len(daily), err)
+		}
+		last := daily[2]
+		// 成交额按 成交量 × (开+高+低+收)/4 估算
+		if last.Date != "2026-01-09" || last.Close != 1710 || last.Change != 12 || last.Amount != 2345600*1706.25 {
+			t.Errorf("%s daily = %+v", src.Name(), last)
+		}
+
+		m30, err := src.Min30(ctx, "600519", 2, model.AdjustNone)
+		if err != nil || len(m30) != 2 {
+			t.Fatalf("%s m30: %d bars, %v", src.Name(), len(m30), err)
+		}
+		if m30[1].Date != "2026-01-09 15:00" || m30[1].Close != 1710 || m30[1].Change != 2 {
+			t.Errorf("%s m30 = %+v", src.Name(), m30[1])
+		}
+	}
+
+	// 新浪没有复权K线，腾讯30分钟只有不复权: 按空数据交给下一个源
+	for _, src := range []Source{Sina{}, Tencent{}} {
+		if _, err := src.Min30(ctx, "600519", 2, model.AdjustForward); KindOf(err) != ErrEmpty {
+			t.Errorf("%s qfq m30: %v", src.Name(), err)
+		}
+	}
+}
+
+func TestAdjFactors(t *testing.T) {
+	raw := []model.KLineData{
+		{Date: "2026-06-01", Close: 20.00}, {Date: "2026-06-02", Close: 20.40},
+		{Date: "2026-06-03", Close: 19.50}, // 10 派 10 元除息
+		{Date: "2026-06-04", Close: 19.70}, {Date: "2026-06-05", Close: 19.61},
+	}
+	hfq := []model.KLineData{
+		{Date: "2026-06-01", Close: 60.00}, {Date: "2026-06-02", Close: 61.20},
+		{Date: "2026-06-03", Close: 61.50}, {Date: "2026-06-04", Close: 62.13}, {Date: "2026-06-05", Close: 61.85},
+	}
+	got := adjFactors(raw, hfq)
+	if len(got) != 3 || got[0].Date != "2026-06-01" || got[0].Factor != 3 ||
+		got[1].Date != "2026-06-03" || math.Abs(got[1].Factor-61.5/19.5) > 1e-6 || got[2].Date != "2026-06-05" || got[2].Factor != got[1].Factor {
+		t.Errorf("factors = %+v", got)
+	}
+}
+
+func TestChainFailover(t *testing.T) {
+	fixtureServer(t)
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer down.Close()
+	old := quoteAPI
+	quoteAPI = down.URL
+	defer func() { quoteAPI = old }()
+	oldClient := HTTPClient()
+	SetHTTPClient(NewClient(HTTPOptions{MaxRetries: Retries(0), QPS: 1000}))
+	defer SetHTTPClient(oldClient)
+
+	q := NewQualityReport()
+	ctx := WithQuality(context.Background(), q)
+
+	// 东财 502 -> 腾讯
+	quote, err := Chain{EastMoney{}, Tencent{}, Sina{}}.Quote(ctx, "600519")
+	if err != nil || quote.Source != "tencent" || quote.Price != 1710 {
+		t.Fatalf("quote = %+v, err = %v", quote, err)
+	}
+	if f := q.Failures(); len(f) != 1 || f[0].Op != "FetchQuote" || f[0].Kind != ErrHTTP {
+		t.Errorf("quality = %+v", f)
+	}
+
+	// 全部失败: 合并各源错误
+	_, err = Chain{EastMoney{}, Sina{}}.Quote(ctx, "600000")
+	var se *StatusError
+	if err == nil || !errors.As(err, &se) || !strings.Contains(err.Error(), "SinaQuote") {
+		t.Errorf("all failed: %v", err)
+	}
+
+	if c, err := ParseSources([]string{"tencent", "Sina", "eastmoney"}); err != nil || strings.Join(c.Names(), ",") != "tencent,sina,eastmoney" {
+		t.Errorf("ParseSources = %v, %v", c.Names(), err)
+	}
+	if _, err := ParseSources([]string{"yahoo"}); err == nil {
+		t.Error("unknown source should fail")
+	}
+}
+
+// fakeSource 固定返回的数据源 (交叉核对)
+type fakeSource struct {
+	name  string
+	quote model.Quote
+	bar   model.KLineData
+	err   error
+}
+
+func (f fakeSource) Name() string { return f.name }
+
+func (f fakeSource) Quote(context.Context, string) (model.Quote, error) {
+	q := f.quote
+	q.Source = f.name
+	return q, f.err
+}
+
+func (f fakeSource) Daily(context.Context, string, int, model.Adjust) ([]model.KLineData, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return []model.KLineData{f.bar}, nil
+}
+
+func (f fakeSource) Min30(context.Context, string, int, model.Adjust) ([]model.KLineData, error) {
+	return nil, f.err
+}
+
+func TestCrossCheck(t *testing.T) {
+	quote := func(price, vol float64) model.Quote {
+		return model.Quote{OrderBook: model.OrderBook{Price: price, Time: time.Now()}, Volume: vol}
+	}
+	bar := func(date string, close float64) model.KLineData { return model.KLineData{Date: date, Close: close} }
+	chain := Chain{
+		fakeSource{name: "eastmoney", quote: quote(10.00, 10000), bar: bar("2026-01-09", 10.00)},
+		fakeSource{name: "tencent", quote: quote(10.02, 10300), bar: bar("2026-01-09", 10.00)}, // 在容差内
+		fakeSource{name: "sina", quote: quote(10.00, 12000), bar: bar("2026-01-09", 10.80)},    // 成交量差 20%，收盘价差 8%
+		fakeSource{name: "broken", err: &FetchError{Op: "X", Target: "600001", Kind: ErrNetwork, Err: errEmpty}},
+	}
+
+	q := NewQualityReport()
+	res := CrossCheck(WithQuality(context.Background(), q), chain, "600001", CrossCheckOptions{})
+	if len(res.Quotes) != 3 || len(res.Bars) != 3 || res.Errors["broken"] == nil {
+		t.Fatalf("res = %+v", res)
+	}
+	if len(res.Diffs) != 2 || res.Diffs[0].Field != "volume" || res.Diffs[0].Other != "sina" || res.Diffs[1].Field != "close" {
+		t.Fatalf("diffs = %+v", res.Diffs)
+	}
+	if f := q.Failures(); len(f) != 1 || f[0].Kind != ErrMismatch || f[0].Count != 2 {
+		t.Errorf("quality = %+v", f)
+	}
+
+	// 更宽的容差: 一致
+	res = CrossCheck(context.Background(), chain, "600001", CrossCheckOptions{PriceTolPct: 10, VolumeTolPct: 25})
+	if len(res.Diffs) != 0 {
+		t.Errorf("diffs = %+v", res.Diffs)
+	}
+}
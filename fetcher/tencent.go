@@ -0,0 +1,162 @@
+package fetcher
+
+import (
+	"context"
+	"dragon-quant/calendar"
+	"dragon-quant/model"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// 腾讯行情 (测试中替换为本地服务)
+var (
+	tencentQuoteAPI = "http://qt.gtimg.cn/q="
+	tencentKLineAPI = "https://web.ifzq.gtimg.cn/appstock/app/fqkline/get"
+	tencentMinAPI   = "https://ifzq.gtimg.cn/appstock/app/kline/mkline"
+)
+
+// Tencent 腾讯证券: 实时行情带五档和涨跌停价，日 K 为前复权，K 线无成交额
+type Tencent struct{}
+
+func (Tencent) Name() string { return "tencent" }
+
+// Quote 实时行情: v_sh600519="1~名称~代码~现价~昨收~今开~成交量(手)~外盘~内盘~买一价~买一量(手)~...~
+// 卖五价~卖五量~最近成交~时间(20060102150405)~涨跌~涨跌幅~最高~最低~价/量/额~成交量(手)~成交额(万)~
+// 换手率~...~流通市值(亿)~总市值(亿)~市净率~涨停价~跌停价~..."。名称为 GBK 编码，非 UTF-8 时留空
+func (Tencent) Quote(ctx context.Context, code string) (model.Quote, error) {
+	body, err := HTTPClient().Get(ctx, 3*time.Second, tencentQuoteAPI+symbol(code))
+	if err != nil {
+		return model.Quote{}, fail(ctx, "TencentQuote", code, "", err)
+	}
+	payload, ok := jsString(body)
+	if !ok {
+		return model.Quote{}, fail(ctx, "TencentQuote", code, ErrSchema, fmt.Errorf("unexpected payload %.80q", body))
+	}
+	f := strings.Split(payload, "~")
+	if len(f) < 49 {
+		if len(f) <= 1 { // 代码不存在: v_pv_none_match="1";
+			return model.Quote{}, fail(ctx, "TencentQuote", code, ErrEmpty, errEmpty)
+		}
+		return model.Quote{}, fail(ctx, "TencentQuote", code, ErrSchema, fmt.Errorf("%d fields, want >= 49", len(f)))
+	}
+	f[35] = "" // "价/量/额" 组合字段，不解析
+	nums, err := parseFields(f, 3, 29)
+	if err != nil {
+		return model.Quote{}, fail(ctx, "TencentQuote", code, ErrSchema, err)
+	}
+	tail, err := parseFields(f, 31, 49)
+	if err != nil {
+		return model.Quote{}, fail(ctx, "TencentQuote", code, ErrSchema, err)
+	}
+
+	q := model.Quote{
+		Code:   code,
+		Open:   nums[5],
+		High:   tail[33],
+		Low:    tail[34],
+		Volume: tail[36],
+		Amount: tail[37] * 1e4,
+		Source: "tencent",
+	}
+	if utf8.ValidString(f[1]) {
+		q.Name = f[1]
+	}
+	q.Price, q.PrevClose = nums[3], nums[4]
+	q.LimitUp, q.LimitDown = tail[47], tail[48]
+	if q.Price > 0 {
+		q.FloatShares = tail[44] * 1e8 / q.Price
+	}
+	for i := 0; i < 5; i++ {
+		q.Bids[i] = model.BookLevel{Price: nums[9+2*i], Vol: int64(nums[10+2*i])}
+		q.Asks[i] = model.BookLevel{Price: nums[19+2*i], Vol: int64(nums[20+2*i])}
+	}
+	q.Time = calendar.Now()
+	if t, err := time.ParseInLocation("20060102150405", f[30], q.Time.Location()); err == nil {
+		q.Time = t
+	}
+	return q, nil
+}
+
+// Daily 日 K: data.sh600519.qfqday / hfqday / day = [["2026-01-09","开","收","高","低","量(手)"],...]
+func (Tencent) Daily(ctx context.Context, code string, limit int, adj model.Adjust) ([]model.KLineData, error) {
+	fq := ""
+	if adj != model.AdjustNone {
+		fq = string(adj)
+	}
+	url := fmt.Sprintf("%s?param=%s,day,,,%d,%s", tencentKLineAPI, symbol(code), limit, fq)
+	return tencentKLines(ctx, "TencentDaily", code, url, fq+"day")
+}
+
+// Min30 不复权 30 分钟 K: data.sh600519.m30 = [["202601091000","开","收","高","低","量(手)",{},...],...]
+func (Tencent) Min30(ctx context.Context, code string, limit int, adj model.Adjust) ([]model.KLineData, error) {
+	if adj != model.AdjustNone {
+		return nil, unsupportedAdjust("TencentMin30", code, adj)
+	}
+	url := fmt.Sprintf("%s?param=%s,m30,,%d", tencentMinAPI, symbol(code), limit)
+	return tencentKLines(ctx, "TencentMin30", code, url, "m30")
+}
+
+// tencentKLines 取 data.<symbol> 下第一个存在的 keys 数组
+func tencentKLines(ctx context.Context, op, code, url string, keys ...string) ([]model.KLineData, error) {
+	body, err := HTTPClient().Get(ctx, 5*time.Second, url)
+	if err != nil {
+		return nil, fail(ctx, op, code, "", err)
+	}
+	var resp struct {
+		Code int                        `json:"code"`
+		Msg  string                     `json:"msg"`
+		Data map[string]json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		// 代码不存在时 data 为 []
+		if strings.Contains(string(body), `"data":[]`) {
+			return nil, fail(ctx, op, code, ErrEmpty, errEmpty)
+		}
+		return nil, fail(ctx, op, code, ErrSchema, err)
+	}
+	if resp.Code != 0 {
+		return nil, fail(ctx, op, code, ErrSchema, fmt.Errorf("code %d: %s", resp.Code, resp.Msg))
+	}
+	var series map[string]json.RawMessage
+	if raw, ok := resp.Data[symbol(code)]; !ok || json.Unmarshal(raw, &series) != nil {
+		return nil, fail(ctx, op, code, ErrEmpty, errEmpty)
+	}
+	var rows [][]json.RawMessage
+	for _, k := range keys {
+		if raw, ok := series[k]; ok {
+			if err := json.Unmarshal(raw, &rows); err != nil {
+				return nil, fail(ctx, op, code, ErrSchema, err)
+			}
+			break
+		}
+	}
+	if len(rows) == 0 {
+		return nil, fail(ctx, op, code, ErrEmpty, errEmpty)
+	}
+
+	bars := make([]ohlcv, 0, len(rows))
+	for i, row := range rows {
+		if len(row) < 6 {
+			return nil, fail(ctx, op, code, ErrSchema, fmt.Errorf("bar %d: %d fields", i, len(row)))
+		}
+		f := make([]string, 6)
+		for j := range f {
+			if err := json.Unmarshal(row[j], &f[j]); err != nil {
+				return nil, fail(ctx, op, code, ErrSchema, fmt.Errorf("bar %d field %d: %s", i, j, row[j]))
+			}
+		}
+		nums, err := parseFields(f, 1, 6)
+		if err != nil {
+			return nil, fail(ctx, op, code, ErrSchema, fmt.Errorf("bar %d: %w", i, err))
+		}
+		date := f[0]
+		if len(date) == 12 { // 202601091000 -> 2026-01-09 10:00
+			date = fmt.Sprintf("%s-%s-%s %s:%s", date[:4], date[4:6], date[6:8], date[8:10], date[10:])
+		}
+		bars = append(bars, ohlcv{date: date, open: nums[1], close: nums[2], high: nums[3], low: nums[4], volume: nums[5] * 100})
+	}
+	return toKLines(bars), nil
+}
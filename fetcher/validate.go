@@ -0,0 +1,244 @@
+package fetcher
+
+import (
+	"context"
+	"dragon-quant/calendar"
+	"dragon-quant/model"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// --- 字段校验: 东财的 fNN 字段会不打招呼地改含义 (f19 从竞价金额变成了买一价就是例子) ---
+// 列表接口逐行解码后检查取值范围与缺失字段。个别行异常 (脏数据) 只丢弃该行；
+// 同一字段异常的行占比达到 DriftRatio 时判定为 schema 漂移: 大声告警、保存原始报文样本，
+// 并以 ErrSchema 记入数据质量报告。
+
+// DriftRatio 同一字段异常行占比达到该值即判定为 schema 漂移
+var DriftRatio = 0.3
+
+// SchemaSampleDir schema 漂移时保存原始报文样本的目录 (为空时只打印第一条样本)
+var SchemaSampleDir = ""
+
+const (
+	driftMinRows  = 3  // 异常行少于此数不判漂移 (小板块里一两只脏数据不算)
+	maxSampleRows = 20 // 每次漂移最多保存的原始行数
+)
+
+// 列表接口请求的字段 (同时用于检查缺失)
+const (
+	sectorStockFields = "f12,f14,f2,f3,f8,f10,f62,f7,f19,f267,f164"
+	topSectorFields   = "f12,f14,f62,f164"
+)
+
+// fieldIssue 一行数据中某个字段的问题。fatal 表示该行不可用 (丢弃)
+type fieldIssue struct {
+	field   string
+	problem string
+	fatal   bool
+}
+
+// decodeRow 解码列表接口的一行到 v。"-" / null / "" 视为缺失 (停牌股常见)，
+// 与请求了但没有返回的字段一起作为 missing 返回
+func decodeRow(raw json.RawMessage, fields string, v any) (missing []string, err error) {
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return nil, err
+	}
+	for _, f := range strings.Split(fields, ",") {
+		val, ok := m[f]
+		if ok {
+			if s := string(val); s != `"-"` && s != "null" && s != `""` {
+				continue
+			}
+			delete(m, f)
+		}
+		missing = append(missing, f)
+	}
+	clean, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	return missing, json.Unmarshal(clean, v)
+}
+
+// checkStock 个股行情的合理性: 现价 > 0，涨跌幅不超过涨跌停 (新股除外)，换手率 0~100。
+// 停牌股 (现价、涨跌幅都缺失) 只检查代码和名称
+func checkStock(s model.StockInfo, missing []string) []fieldIssue {
+	miss := make(map[string]bool, len(missing))
+	for _, f := range missing {
+		miss[f] = true
+	}
+	var issues []fieldIssue
+	for _, f := range missing {
+		switch f {
+		case "f12", "f14":
+			issues = append(issues, fieldIssue{f, "缺失", true})
+		case "f2", "f3":
+			if !(miss["f2"] && miss["f3"]) {
+				issues = append(issues, fieldIssue{f, "缺失", true})
+			}
+		default:
+			if !(miss["f2"] && miss["f3"]) {
+				issues = append(issues, fieldIssue{f, "缺失", false})
+			}
+		}
+	}
+	if miss["f2"] && miss["f3"] {
+		return issues // 停牌
+	}
+
+	if !miss["f2"] && s.Price <= 0 {
+		issues = append(issues, fieldIssue{"f2", fmt.Sprintf("现价 %.2f <= 0", s.Price), true})
+	}
+	// 涨跌停价四舍五入到分，低价股的实际涨幅可能略超限制
+	if bound := model.LimitPct(s.Code, s.Name)*100 + 0.5; !miss["f3"] && !model.NewListing(s.Name) && math.Abs(s.ChangePct) > bound {
+		issues = append(issues, fieldIssue{"f3", fmt.Sprintf("涨跌幅 %.2f%% 超出 ±%.0f%%", s.ChangePct, bound-0.5), true})
+	}
+	if !miss["f8"] && (s.Turnover < 0 || s.Turnover > 100) {
+		issues = append(issues, fieldIssue{"f8", fmt.Sprintf("换手率 %.2f%% 不在 0~100", s.Turnover), true})
+	}
+	return issues
+}
+
+// checkSector 板块列表: 代码必须是 BK 开头，名称不能为空；资金流缺失不影响使用
+func checkSector(s model.SectorInfo, missing []string) []fieldIssue {
+	var issues []fieldIssue
+	for _, f := range missing {
+		issues = append(issues, fieldIssue{f, "缺失", f == "f12" || f == "f14"})
+	}
+	if s.Code != "" && model.ParseSecurity(s.Code).Type != model.TypeSector {
+		issues = append(issues, fieldIssue{"f12", fmt.Sprintf("板块代码 %q 不是 BK 开头", s.Code), true})
+	}
+	return issues
+}
+
+// validator 累计一批数据的逐字段异常
+type validator struct {
+	op, target string
+	rows, kept int
+	bad        map[string]int        // 字段 -> 异常行数
+	example    map[string]fieldIssue // 字段 -> 第一次出现的问题
+	samples    []json.RawMessage     // 异常行的原始报文
+}
+
+func newValidator(op, target string) *validator {
+	return &validator{op: op, target: target, bad: make(map[string]int), example: make(map[string]fieldIssue)}
+}
+
+// check 记录一行的问题，返回该行是否可用
+func (v *validator) check(raw json.RawMessage, issues []fieldIssue) bool {
+	v.rows++
+	ok := true
+	for _, is := range issues {
+		if v.bad[is.field] == 0 {
+			v.example[is.field] = is
+		}
+		v.bad[is.field]++
+		ok = ok && !is.fatal
+	}
+	if len(issues) > 0 && len(v.samples) < maxSampleRows {
+		v.samples = append(v.samples, raw)
+	}
+	if ok {
+		v.kept++
+	}
+	return ok
+}
+
+// reject 记录一行无法解码的数据
+func (v *validator) reject(raw json.RawMessage, err error) {
+	v.check(raw, []fieldIssue{{"decode", err.Error(), true}})
+}
+
+// drifted 异常占比达到 DriftRatio 的字段 (按异常行数降序)
+func (v *validator) drifted() []string {
+	var fields []string
+	for f, n := range v.bad {
+		if n >= driftMinRows && float64(n) >= DriftRatio*float64(v.rows) {
+			fields = append(fields, f)
+		}
+	}
+	sort.Slice(fields, func(i, j int) bool {
+		if v.bad[fields[i]] != v.bad[fields[j]] {
+			return v.bad[fields[i]] > v.bad[fields[j]]
+		}
+		return fields[i] < fields[j]
+	})
+	return fields
+}
+
+// done 汇总校验结果。判定漂移时告警、保存样本并记入数据质量报告；
+// 漂移涉及必须字段 (或所有行都不可用) 时返回 ErrSchema，已通过校验的行照常使用
+func (v *validator) done(ctx context.Context) error {
+	fields := v.drifted()
+	allBad := v.rows > 0 && v.kept == 0
+	if len(fields) == 0 && !allBad {
+		if dropped := v.rows - v.kept; dropped > 0 {
+			fmt.Printf("   ⚠️ [校验] %s %s: 丢弃 %d/%d 行异常数据\n", v.op, v.target, dropped, v.rows)
+		}
+		return nil
+	}
+	if len(fields) == 0 {
+		for f := range v.bad {
+			fields = append(fields, f)
+		}
+		sort.Strings(fields)
+	}
+
+	fatal := allBad
+	var parts []string
+	fmt.Printf("\n🚨🚨 [Schema 漂移] %s %s: 字段含义或格式可能已变化!\n", v.op, v.target)
+	for _, f := range fields {
+		ex := v.example[f]
+		fatal = fatal || ex.fatal
+		fmt.Printf("   - %s: %d/%d 行异常，例: %s\n", f, v.bad[f], v.rows, ex.problem)
+		parts = append(parts, fmt.Sprintf("%s %d/%d (%s)", f, v.bad[f], v.rows, ex.problem))
+	}
+	if len(v.samples) > 0 {
+		sample := string(v.samples[0])
+		if len(sample) > 300 {
+			sample = sample[:300] + "..."
+		}
+		fmt.Printf("   原始报文: %s\n", sample)
+	}
+	if path, err := v.saveSamples(); err != nil {
+		fmt.Printf("   ⚠️ 样本保存失败: %v\n", err)
+	} else if path != "" {
+		parts = append(parts, "样本 "+path)
+		fmt.Printf("   样本已保存: %s\n", path)
+	}
+
+	err := fail(ctx, v.op, v.target, ErrSchema, fmt.Errorf("schema drift: %s", strings.Join(parts, "; ")))
+	if !fatal {
+		return nil // 只是可选字段缺失: 已告警并记录，数据照常使用
+	}
+	return err
+}
+
+// saveSamples 把异常行的原始报文写入 SchemaSampleDir，返回文件路径
+func (v *validator) saveSamples() (string, error) {
+	if SchemaSampleDir == "" || len(v.samples) == 0 {
+		return "", nil
+	}
+	if err := os.MkdirAll(SchemaSampleDir, 0o755); err != nil {
+		return "", err
+	}
+	target := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`/\:+*?"<>| `, r) {
+			return '_'
+		}
+		return r
+	}, v.target)
+	name := fmt.Sprintf("%s_%s_%s.json", v.op, target, calendar.Now().Format("20060102-150405"))
+	data, err := json.MarshalIndent(v.samples, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(SchemaSampleDir, name)
+	return path, os.WriteFile(path, data, 0o644)
+}
@@ -0,0 +1,93 @@
+package fetcher
+
+import (
+	"context"
+	"dragon-quant/model"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func rows(t *testing.T, raw string) []json.RawMessage {
+	t.Helper()
+	var items []json.RawMessage
+	if err := json.Unmarshal([]byte(raw), &items); err != nil {
+		t.Fatal(err)
+	}
+	return items
+}
+
+func TestSectorStocksValidation(t *testing.T) {
+	q := NewQualityReport()
+	ctx := WithQuality(context.Background(), q)
+
+	// 正常 / 停牌 ("-") / 新股 (N 前缀不设涨跌幅) / 创业板 20% / 一行脏数据 (换手率 > 100)
+	items := rows(t, `[
+		{"f12":"600001","f14":"甲","f2":11.0,"f3":10.0,"f8":12.5,"f10":2.1,"f62":1e8,"f7":9.1,"f19":1e7,"f267":2e8,"f164":3e8},
+		{"f12":"600002","f14":"乙","f2":"-","f3":"-","f8":"-","f10":"-","f62":"-","f7":"-","f19":"-","f267":"-","f164":"-"},
+		{"f12":"603003","f14":"N丙","f2":44.0,"f3":44.0,"f8":80.0,"f10":1.0,"f62":1e7,"f7":50,"f19":0,"f267":0,"f164":0},
+		{"f12":"300004","f14":"丁","f2":12.0,"f3":19.98,"f8":30.0,"f10":3.0,"f62":1e7,"f7":21,"f19":0,"f267":0,"f164":0},
+		{"f12":"600005","f14":"戊","f2":9.0,"f3":1.0,"f8":350.0,"f10":1.0,"f62":1e6,"f7":2,"f19":0,"f267":0,"f164":0},
+		{"f12":"600006","f14":"己","f2":9.0,"f3":1.0,"f8":3.0,"f10":1.0,"f62":1e6,"f7":2,"f19":0,"f267":0,"f164":0},
+		{"f12":"600007","f14":"庚","f2":9.0,"f3":1.0,"f8":3.0,"f10":1.0,"f62":1e6,"f7":2,"f19":0,"f267":0,"f164":0}
+	]`)
+	list, err := parseSectorStocks(ctx, "BK0477", items)
+	if err != nil || len(list) != 6 || q.Len() != 0 {
+		t.Fatalf("list=%d err=%v quality=%+v", len(list), err, q.Failures())
+	}
+	if list[1].Code != "600002" || list[1].Price != 0 {
+		t.Errorf("suspended row = %+v", list[1])
+	}
+
+	// f3 变成了价格: 大部分行超出涨跌停 -> 漂移，保存样本
+	SchemaSampleDir = t.TempDir()
+	defer func() { SchemaSampleDir = "" }()
+	drift := rows(t, `[
+		{"f12":"600001","f14":"甲","f2":11.0,"f3":11.0,"f8":1,"f10":1,"f62":0,"f7":0,"f19":0,"f267":0,"f164":0},
+		{"f12":"600002","f14":"乙","f2":25.3,"f3":25.3,"f8":1,"f10":1,"f62":0,"f7":0,"f19":0,"f267":0,"f164":0},
+		{"f12":"600003","f14":"丙","f2":8.2,"f3":8.2,"f8":1,"f10":1,"f62":0,"f7":0,"f19":0,"f267":0,"f164":0},
+		{"f12":"600004","f14":"丁","f2":31.0,"f3":31.0,"f8":1,"f10":1,"f62":0,"f7":0,"f19":0,"f267":0,"f164":0}
+	]`)
+	list, err = parseSectorStocks(ctx, "BK0478", drift)
+	if KindOf(err) != ErrSchema || len(list) != 1 || list[0].Code != "600003" {
+		t.Fatalf("drift: list=%d err=%v", len(list), err)
+	}
+	if f := q.Failures(); len(f) != 1 || f[0].Target != "BK0478" {
+		t.Errorf("quality = %+v", f)
+	}
+	files, _ := filepath.Glob(filepath.Join(SchemaSampleDir, "FetchSectorStocks_BK0478_*.json"))
+	if len(files) != 1 {
+		t.Fatalf("samples = %v", files)
+	}
+	data, _ := os.ReadFile(files[0])
+	var saved []json.RawMessage
+	if err := json.Unmarshal(data, &saved); err != nil || len(saved) != 3 {
+		t.Errorf("saved %d rows: %v", len(saved), err)
+	}
+}
+
+func TestSectorValidationOptionalDrift(t *testing.T) {
+	q := NewQualityReport()
+	ctx := WithQuality(context.Background(), q)
+
+	// 资金流字段整体消失: 告警并记录，但板块照常使用
+	items := rows(t, `[{"f12":"BK0001","f14":"甲"},{"f12":"BK0002","f14":"乙"},{"f12":"BK0003","f14":"丙"}]`)
+	v := newValidator("FetchTopSectors", "行业")
+	for _, item := range items {
+		var s model.SectorInfo
+		missing, err := decodeRow(item, topSectorFields, &s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(missing) != 2 {
+			t.Errorf("missing = %v", missing)
+		}
+		if !v.check(item, checkSector(s, missing)) {
+			t.Errorf("%s rejected", s.Code)
+		}
+	}
+	if err := v.done(ctx); err != nil || q.Len() != 1 {
+		t.Errorf("err=%v quality=%d", err, q.Len())
+	}
+}
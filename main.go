@@ -1,16 +1,44 @@
 package main
 
 import (
+	"context"
+	"dragon-quant/ai_reviewer/mock_llm"
+	"dragon-quant/broker_importer"
+	"dragon-quant/calendar"
 	"dragon-quant/config"
 	core "dragon-quant/core/analysis_all_stocks"
 	"dragon-quant/core/analysis_special_stocks/hold_kline"
+	"dragon-quant/core/auction_collector"
+	"dragon-quant/core/intraday_watch"
+	"dragon-quant/fetcher"
+	"dragon-quant/model"
+	"dragon-quant/notifier"
 	"dragon-quant/output_formatter"
+	"dragon-quant/scheduler"
+	"dragon-quant/warehouse"
 	"flag"
 	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
 )
 
 var holdKlineMode = flag.Bool("hold-kline", false, "Run Hold Kline Processor only")
 var reviewDays = flag.Int("days", 7, "Days for hold review (1 or 7)")
+var holdingsFile = flag.String("holdings", "", "Broker statement (CSV/XLS) to import holdings from (overrides holdings_file)")
+var syncMode = flag.Bool("sync", false, "Incrementally sync K-lines for the watchlist into the warehouse, backfill gaps and report stale codes")
+var lhbBackfill = flag.Int("lhb-backfill", 0, "Backfill N trading days of market-wide LHB seats into the warehouse and print seat profiles")
+var auctionMode = flag.Bool("auction", false, "Poll candidate stocks during the 9:15-9:25 call auction and store the time series")
+var watchMode = flag.Bool("watch", false, "Watch the last FinalPool and holdings during trading hours and alert on rule hits")
+var scheduleMode = flag.Bool("schedule", false, "Stay resident and run the schedule.jobs entries from config.yaml on trading days")
+var resumeRun = flag.String("resume", "", "Resume the full scan RUN_ID from its checkpoints (see -from)")
+var fromStage = flag.String("from", "", "Stage to rerun when resuming: sectors / candidates / leaders / review / final")
+var crossCheck = flag.String("crosscheck", "", "Compare quotes and the latest daily bar across data sources for comma-separated codes (\"hold\" = holdings)")
+var importFiles = flag.String("import", "", "Import K-lines from comma-separated CSV/Parquet files (globs allowed) into the warehouse")
+var mockLLM = flag.Bool("mock-llm", false, "Use the in-process deterministic mock LLM instead of DeepSeek")
 
 func main() {
 	fmt.Println(`
@@ -31,59 +59,500 @@ func main() {
 		return
 	}
 
-	if *holdKlineMode {
-		analysisSpecialStocks(cfg)
+	if err := calendar.LoadDefault(cfg.Calendar.HolidaysFile); err != nil {
+		fmt.Printf("⚠️ 加载交易日历失败, 使用内置休市表: %v\n", err)
+	}
+
+	if cfg.LHB.LookbackDays > 0 {
+		fetcher.LHBLookbackDays = cfg.LHB.LookbackDays
+	}
+	if err := fetcher.LoadSeatDict(cfg.LHB.SeatsFile); err != nil {
+		fmt.Printf("⚠️ 加载席位字典失败, 使用内置字典: %v\n", err)
+	}
+	if cfg.Validate.DriftRatio > 0 {
+		fetcher.DriftRatio = cfg.Validate.DriftRatio
+	}
+	fetcher.SchemaSampleDir = cfg.Validate.SampleDir
+	fetcher.SetHTTPClient(fetcher.NewClient(fetcher.HTTPOptions{
+		QPS:             cfg.HTTP.QPS,
+		Burst:           cfg.HTTP.Burst,
+		MaxConnsPerHost: cfg.HTTP.MaxConnsPerHost,
+		MaxRetries:      cfg.HTTP.Retries,
+		Backoff:         time.Duration(cfg.HTTP.BackoffMs) * time.Millisecond,
+		UserAgent:       cfg.HTTP.UserAgent,
+		Referer:         cfg.HTTP.Referer,
+	}))
+	defer fetcher.PrintHTTPStats()
+
+	if *mockLLM {
+		srv := mock_llm.NewServer(mock_llm.Options{})
+		url, err := srv.Start("")
+		if err != nil {
+			fmt.Printf("⚠️ 启动 Mock LLM 失败: %v\n", err)
+			return
+		}
+		defer srv.Close()
+		cfg.DeepSeek.APIURL = url
+		cfg.DeepSeek.APIKey = "mock"
+		fmt.Printf("🤖 使用 Mock LLM: %s\n", url)
+	}
+
+	// Ctrl-C / SIGTERM: 取消进行中的请求，已完成部分照常输出报告
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	wh := openWarehouse(cfg)
+	defer wh.Close()
+
+	// 数据源链在仓库打开后设置: local 源读取仓库中的 K 线
+	if len(cfg.Sources.Order) > 0 {
+		if chain, err := fetcher.ParseSources(cfg.Sources.Order, warehouse.LocalSource{W: wh}); err != nil {
+			fmt.Printf("⚠️ 数据源配置有误, 使用默认顺序: %v\n", err)
+		} else {
+			fetcher.SetSources(chain)
+		}
+	}
+
+	notify, err := notifier.FromConfig(cfg.Notify)
+	if err != nil {
+		fmt.Printf("⚠️ 推送配置有误, 本次不推送: %v\n", err)
+	}
+
+	if *scheduleMode {
+		runSchedule(ctx, cfg, wh, notify)
+	} else if *importFiles != "" {
+		importLocal(cfg, wh, *importFiles)
+	} else if *crossCheck != "" {
+		crossCheckSources(ctx, cfg, *crossCheck)
+	} else if *syncMode {
+		syncWarehouse(ctx, cfg, wh)
+	} else if *watchMode {
+		watchIntraday(ctx, cfg, wh, notify)
+	} else if *auctionMode {
+		collectAuction(ctx, cfg, wh)
+	} else if *lhbBackfill > 0 {
+		backfillSeats(ctx, wh, *lhbBackfill)
+	} else if *holdKlineMode {
+		analysisSpecialStocks(ctx, cfg, wh)
 	} else {
-		analysisAllStocks(cfg)
+		if *fromStage != "" && *resumeRun == "" {
+			fmt.Println("⚠️ -from 需要配合 -resume RUN_ID 使用")
+			return
+		}
+		analysisAllStocks(ctx, cfg, wh, notify, *resumeRun, *fromStage)
 	}
 }
 
-func analysisAllStocks(cfg *config.Config) {
-	// Public variables for Report Generation
+// openWarehouse 打开持久化行情仓库；未配置或打开失败时返回 nil (退化为每次全量拉取)
+func openWarehouse(cfg *config.Config) *warehouse.Warehouse {
+	if cfg.Warehouse.Path == "" {
+		return nil
+	}
+	wh, err := warehouse.Open(cfg.Warehouse.Path)
+	if err != nil {
+		fmt.Printf("⚠️ 打开行情仓库失败, 本次不落盘: %v\n", err)
+		return nil
+	}
+	fmt.Printf("🏛️ 行情仓库: %s (%s)\n", wh.Path, wh.Stats())
+	return wh
+}
 
-	// --- Step 1: 扫描热点 ---
-	scanHotPointSectorsResult := core.ScanHotPointSectors(cfg)
+// withDeadline 单次扫描/持仓审视的整体截止时间 (run.deadline_minutes)
+func withDeadline(ctx context.Context, cfg *config.Config) (context.Context, context.CancelFunc) {
+	if d := cfg.Run.Deadline(); d > 0 {
+		return context.WithTimeout(ctx, d)
+	}
+	return context.WithCancel(ctx)
+}
 
-	// --- Step 2: 竞价与资金初筛 ---
-	findCandidatesResult := core.FindCandidates(cfg, scanHotPointSectorsResult)
+// runSchedule 常驻并按 schedule.jobs 定时运行各模式 (每次运行使用独立的报告文件名)
+func runSchedule(ctx context.Context, cfg *config.Config, wh *warehouse.Warehouse, notify *notifier.Dispatcher) {
+	if len(cfg.Schedule.Jobs) == 0 {
+		fmt.Println("⚠️ config.yaml 未配置 schedule.jobs")
+		return
+	}
+	withRun := func(task func(ctx context.Context, c *config.Config)) func(context.Context) {
+		return func(ctx context.Context) {
+			c := *cfg
+			if err := c.NewRun(time.Now()); err != nil {
+				fmt.Printf("⚠️ 创建输出目录失败: %v\n", err)
+				return
+			}
+			task(ctx, &c)
+		}
+	}
+	tasks := map[string]func(context.Context){
+		"scan":         withRun(func(ctx context.Context, c *config.Config) { analysisAllStocks(ctx, c, wh, notify, "", "") }),
+		"hold-kline":   withRun(func(ctx context.Context, c *config.Config) { analysisSpecialStocks(ctx, c, wh) }),
+		"auction":      withRun(func(ctx context.Context, c *config.Config) { collectAuction(ctx, c, wh) }),
+		"watch":        withRun(func(ctx context.Context, c *config.Config) { watchIntraday(ctx, c, wh, notify) }),
+		"sync":         withRun(func(ctx context.Context, c *config.Config) { syncWarehouse(ctx, c, wh) }),
+		"lhb-backfill": func(ctx context.Context) { backfillSeats(ctx, wh, 1) },
+	}
+	s, err := scheduler.New(cfg.Schedule, tasks)
+	if err != nil {
+		fmt.Printf("⚠️ 定时任务配置有误: %v\n", err)
+		return
+	}
+	s.LHBReady = func(date string) bool {
+		records, err := fetcher.FetchLHBByDate(ctx, date)
+		return err == nil && len(records) > 0
+	}
+	s.Run(ctx)
+}
 
-	// --- Step 3: 深度技术 + 龙头地位推演 ---
-	inferStockLeadersResult := core.InferStockLeaders(cfg, findCandidatesResult)
+// backfillSeats 回补全市场龙虎榜席位库并打印最活跃席位的画像
+func backfillSeats(ctx context.Context, wh *warehouse.Warehouse, days int) {
+	if wh == nil {
+		fmt.Println("⚠️ 未配置 warehouse.path，无法建立席位库")
+		return
+	}
+	fmt.Printf("🐉 回补最近 %d 个交易日的龙虎榜席位...\n", days)
+	n := wh.BackfillLHB(ctx, days, calendar.Now())
+	fmt.Printf("✅ 新增 %d 条上榜记录 (%s)\n", n, wh.Stats())
+	warehouse.PrintSeatProfiles(wh.TopSeats(30))
+}
 
-	// --- Step 4: 输出 ---
+// watchIntraday 盘中盯盘直到收盘
+func watchIntraday(ctx context.Context, cfg *config.Config, wh *warehouse.Warehouse, notify *notifier.Dispatcher) {
+	var holds []model.WatchTarget
+	for _, pos := range cfg.HoldStocks {
+		code, name := pos.Code, pos.Name
+		if code == "" {
+			sec, err := fetcher.SearchStock(ctx, pos.Name)
+			if err != nil {
+				fmt.Printf("⚠️ 持仓 %s 代码查询失败, 不盯: %v\n", pos.Name, err)
+				continue
+			}
+			code, name = sec.Key(), sec.Name
+		}
+		holds = append(holds, model.WatchTarget{Code: code, Name: name, Source: model.WatchHold})
+	}
+	targets := intraday_watch.Merge(wh.WatchTargets(), holds)
+	if len(targets) == 0 {
+		fmt.Println("⚠️ 没有盯盘对象 (先跑一次扫描或配置 hold_stocks)")
+		return
+	}
+	if wh == nil {
+		fmt.Println("⚠️ 未配置 warehouse.path，只盯持仓，且没有 AI 止损/止盈价位")
+	}
 
-	if len(inferStockLeadersResult.FinalPool) > 0 {
-		output_formatter.PrintDragonTable(inferStockLeadersResult.FinalPool)
-		output_formatter.GenFiles(cfg, scanHotPointSectorsResult.AllSectors,
-			inferStockLeadersResult.FinalPool, inferStockLeadersResult.Elapsed,
-			scanHotPointSectorsResult.SentimentStr)
+	fmt.Printf("👀 盘中盯盘: %d 只\n", len(targets))
+	for _, t := range targets {
+		fmt.Printf("   - %s %s [%s] 止损 %.2f 止盈 %.2f\n", t.Code, t.Name, t.Source, t.StopLoss, t.TargetPrice)
+	}
+	w := intraday_watch.New(targets, wh)
+	if cfg.Watch.IntervalSec > 0 {
+		w.Interval = time.Duration(cfg.Watch.IntervalSec) * time.Second
+	}
+	if cfg.Watch.VolSpike > 0 {
+		w.Rules.VolSpike = cfg.Watch.VolSpike
+	}
+	if notify.Len() > 0 {
+		w.Notify = func(a model.Alert) {
+			intraday_watch.PrintAlert(a)
+			notify.Send(notifier.AlertMessage(a))
+		}
+	}
+	w.Run(ctx)
+}
 
-		// --- Step 6: DeepSeek 老狐狸鉴股 (V10.4 Full Scan) ---
-		findWinnersResult := core.FindWinners(cfg, scanHotPointSectorsResult, inferStockLeadersResult)
+// collectAuction 采集集合竞价时序并打印竞价特征
+func collectAuction(ctx context.Context, cfg *config.Config, wh *warehouse.Warehouse) {
+	seen := make(map[string]bool)
+	var codes []string
+	add := func(c string) {
+		if c != "" && !seen[c] {
+			seen[c] = true
+			codes = append(codes, c)
+		}
+	}
+	for _, c := range cfg.Auction.Codes {
+		add(c)
+	}
+	for _, pos := range cfg.HoldStocks {
+		code := pos.Code
+		if code == "" {
+			sec, _ := fetcher.SearchStock(ctx, pos.Name) // 查不到时 code 为空, add 会忽略
+			code = sec.Key()
+		}
+		add(code)
+	}
+	for _, c := range wh.RecentSnapshotCodes() {
+		add(c)
+	}
+	if len(codes) == 0 {
+		fmt.Println("⚠️ 没有竞价候选股 (auction.codes / hold_stocks / 上一轮扫描均为空)")
+		return
+	}
+	if wh == nil {
+		fmt.Println("⚠️ 未配置 warehouse.path，竞价时序不落盘，扫描时无法使用")
+	}
 
-		output_formatter.PrintRiskReport(findWinnersResult.RiskResults)
+	fmt.Printf("🔔 集合竞价采集: %d 只候选股\n", len(codes))
+	c := auction_collector.New(codes, wh)
+	if cfg.Auction.IntervalSec > 0 {
+		c.Interval = time.Duration(cfg.Auction.IntervalSec) * time.Second
+	}
+	auction_collector.PrintReport(c.Run(ctx))
+}
 
-		// Generate MD5
-		output_formatter.WriteMD(cfg.ReportTop3FileMD, findWinnersResult.Top3MdBuffer.String())
-		output_formatter.WriteMD(cfg.ReportTop1FileMD, findWinnersResult.Top1MdBuffer.String())
-		output_formatter.WriteMD(cfg.ReportWinnersFileMD, findWinnersResult.WinnersMdBuffer.String())
-		// Generate HTML
-		output_formatter.SimpleMDToHTMLFile(cfg.ReportTop3FileMD, cfg.ReportTop3FileHTML)
-		output_formatter.SimpleMDToHTMLFile(cfg.ReportTop1FileMD, cfg.ReportTop1FileHTML)
-		output_formatter.SimpleMDToHTMLFile(cfg.ReportWinnersFileMD, cfg.ReportWinnersFileHTML)
+// analysisAllStocks 全量扫描流水线。runID 非空时续跑该次运行: from 之前的阶段从断点加载。
+// 中断 (Ctrl-C / 超过 run.deadline_minutes) 时已完成部分照常输出报告。
+func analysisAllStocks(ctx context.Context, cfg *config.Config, wh *warehouse.Warehouse, notify *notifier.Dispatcher, runID, from string) {
+	ctx, cancel := withDeadline(ctx, cfg)
+	defer cancel()
+	quality := fetcher.NewQualityReport()
+	ctx = fetcher.WithQuality(ctx, quality)
+	defer quality.Print()
 
-		fmt.Printf("✅ 老狐狸报告(HTML)已更新: %s\n", cfg.ReportWinnersFileHTML)
+	run := core.NewRun(cfg, wh, runID)
+	fmt.Printf("🆔 运行 ID: %s (断点目录 %s)\n", run.ID, run.Dir)
 
-	} else {
-		fmt.Println("❌ 无符合条件的标的。")
+	// --- Step 4: 输出 (龙头推演完成后立即生成) ---
+	dragonWritten := false
+	writeDragon := func(r *core.Run) {
+		if len(r.Leaders.FinalPool) > 0 {
+			output_formatter.PrintDragonTable(r.Leaders.FinalPool)
+			output_formatter.GenFiles(cfg, r.Sectors.AllSectors,
+				r.Leaders.FinalPool, r.Leaders.Elapsed, r.Sectors.SentimentStr)
+			dragonWritten = true
+		}
+	}
+	run.OnStage = func(stage string, r *core.Run) {
+		if stage == core.StageLeaders {
+			writeDragon(r)
+		}
+	}
+
+	err := run.Execute(ctx, from)
+	if ctx.Err() != nil {
+		fmt.Printf("⏹️ 运行被中断 (%v)，输出已完成部分的报告\n", context.Cause(ctx))
+		if !dragonWritten {
+			writeDragon(run) // 龙头推演中途中断: 输出已算完的个股
+		}
+	}
+	if len(run.Leaders.FinalPool) == 0 {
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+		} else if quality.Len() > 0 {
+			// 接口坏了和"没有标的"要区分开，否则会误以为今天行情不好
+			fmt.Printf("❌ 没有标的，且有 %d 项数据拉取失败 (很可能是数据源故障，见下方数据质量报告)\n", quality.Len())
+		} else {
+			fmt.Println("❌ 无符合条件的标的。")
+		}
+		return
+	}
+	if !run.Review.Reviewed && err != nil {
+		fmt.Printf("❌ %v\n", err)
+		fmt.Printf("♻️ 修复后可续跑: -resume %s -from %s\n", run.ID, run.NextStage())
+		return
+	}
+
+	// --- Step 6: DeepSeek 老狐狸鉴股 (V10.4 Full Scan) ---
+	findWinnersResult := run.Result()
+
+	output_formatter.PrintRiskReport(findWinnersResult.RiskResults)
+
+	// 🆕 FinalPool + 板块王者的止损/止盈交给 -watch
+	pool := intraday_watch.PoolTargets(run.Leaders.FinalPool, findWinnersResult.Picks)
+	if err := wh.ReplaceWatchTargets(model.WatchPool, pool); err != nil {
+		fmt.Printf("⚠️ 保存盯盘列表失败: %v\n", err)
+	}
+
+	// Generate MD5
+	output_formatter.WriteMD(cfg.ReportTop3FileMD, findWinnersResult.Top3MdBuffer.String())
+	output_formatter.WriteMD(cfg.ReportTop1FileMD, findWinnersResult.Top1MdBuffer.String())
+	output_formatter.WriteMD(cfg.ReportWinnersFileMD, findWinnersResult.WinnersMdBuffer.String())
+	// Generate HTML
+	output_formatter.SimpleMDToHTMLFile(cfg.ReportTop3FileMD, cfg.ReportTop3FileHTML)
+	output_formatter.SimpleMDToHTMLFile(cfg.ReportTop1FileMD, cfg.ReportTop1FileHTML)
+	output_formatter.SimpleMDToHTMLFile(cfg.ReportWinnersFileMD, cfg.ReportWinnersFileHTML)
+
+	fmt.Printf("✅ 老狐狸报告(HTML)已更新: %s\n", cfg.ReportWinnersFileHTML)
+
+	if err != nil {
+		// 复审报告已写出 (中断时可能不完整)，从第一个没有断点的阶段续跑
+		fmt.Printf("❌ %v\n", err)
+		fmt.Printf("♻️ 修复后可续跑: -resume %s -from %s\n", run.ID, run.NextStage())
+		return
+	}
+
+	// 🆕 推送总决赛结果
+	if notify.Len() > 0 {
+		title := fmt.Sprintf("🏆 龙头总决赛 %s", cfg.StartTime.Format("2006-01-02 15:04"))
+		// 报告路径放在最前: 机器人消息有长度上限，过长的正文会被截断
+		body := fmt.Sprintf("📄 完整报告: %s\n%s", cfg.ReportWinnersFileHTML, findWinnersResult.WinnersMdBuffer.String())
+		if err := notify.Send(notifier.ReportMessage(title, body)); err == nil {
+			fmt.Printf("📨 总决赛已推送 (%d 个渠道)\n", notify.Len())
+		}
 	}
 }
 
-func analysisSpecialStocks(cfg *config.Config) {
+func analysisSpecialStocks(ctx context.Context, cfg *config.Config, wh *warehouse.Warehouse) {
 	fmt.Println("🛡️ 启动持仓 30m K线深度审视模式...")
 
-	processor := hold_kline.NewHoldProcessor(cfg.DeepSeek.APIKey)
+	// 券商对账单导入 (命令行优先于 config)
+	path := cfg.HoldingsFile
+	if *holdingsFile != "" {
+		path = *holdingsFile
+	}
+	if path != "" {
+		st, err := broker_importer.ImportFile(path)
+		if err != nil {
+			fmt.Printf("⚠️ 导入对账单失败: %v\n", err)
+			return
+		}
+		fmt.Printf("📥 导入对账单 %s (%s/%s): %d 只持仓\n", path, st.Format, st.Kind, len(st.Holdings))
+		for _, h := range st.Holdings {
+			fmt.Printf("   - %s %s: %d 股 (可卖 %d) 成本 %.3f 已实现 %+.0f\n",
+				h.Code, h.Name, h.Shares, h.Sellable, h.Cost, h.RealizedPnL)
+		}
+		cfg.HoldStocks = broker_importer.MergePositions(cfg.HoldStocks, st.Positions())
+	}
+
+	processor := hold_kline.NewHoldProcessor(cfg.DeepSeek.APIKey, cfg.DeepSeek.APIURL)
 	defer processor.Close()
+	processor.Warehouse = wh
+
+	ctx, cancel := withDeadline(ctx, cfg)
+	defer cancel()
+	quality := fetcher.NewQualityReport()
+	defer quality.Print()
+	processor.Run(fetcher.WithQuality(ctx, quality), cfg, *reviewDays)
+}
+
+func syncWarehouse(ctx context.Context, cfg *config.Config, wh *warehouse.Warehouse) {
+	if wh == nil {
+		fmt.Println("⚠️ -sync 需要在 config.yaml 中配置 warehouse.path")
+		return
+	}
 
-	processor.Run(cfg, *reviewDays)
+	quality := fetcher.NewQualityReport()
+	ctx = fetcher.WithQuality(ctx, quality)
+	defer quality.Print()
+
+	sc := cfg.Warehouse.Sync
+	codes := append([]string{}, sc.Codes...)
+	for _, pos := range cfg.HoldStocks {
+		code := pos.Code
+		if code == "" {
+			sec, err := fetcher.SearchStock(ctx, pos.Name)
+			if err != nil {
+				fmt.Printf("⚠️ 持仓 %s 代码查询失败, 跳过: %v\n", pos.Name, err)
+				continue
+			}
+			code = sec.Key()
+		}
+		codes = append(codes, code)
+	}
+
+	results := wh.Sync(ctx, warehouse.SyncOptions{
+		Codes:      codes,
+		Sectors:    sc.Sectors,
+		Timeframes: warehouse.ParseTimeframes(sc.Timeframes),
+		Days:       sc.Days,
+		DailyLimit: sc.DailyLimit,
+	})
+	warehouse.PrintSyncReport(results)
+	fmt.Printf("🏛️ 行情仓库: %s\n", wh.Stats())
+}
+
+// crossCheckSources 对比各数据源的行情，codes 为逗号分隔的代码 ("hold" = 持仓)
+func crossCheckSources(ctx context.Context, cfg *config.Config, codes string) {
+	quality := fetcher.NewQualityReport()
+	ctx = fetcher.WithQuality(ctx, quality)
+	defer quality.Print()
+
+	var list []string
+	if codes == "hold" {
+		for _, pos := range cfg.HoldStocks {
+			code := pos.Code
+			if code == "" {
+				sec, err := fetcher.SearchStock(ctx, pos.Name)
+				if err != nil {
+					fmt.Printf("⚠️ 持仓 %s 代码查询失败, 跳过: %v\n", pos.Name, err)
+					continue
+				}
+				code = sec.Key()
+			}
+			list = append(list, code)
+		}
+	} else {
+		for _, c := range strings.Split(codes, ",") {
+			if c = strings.TrimSpace(c); c != "" {
+				list = append(list, c)
+			}
+		}
+	}
+	if len(list) == 0 {
+		fmt.Println("⚠️ 没有要核对的代码")
+		return
+	}
+
+	chain := fetcher.Sources()
+	fmt.Printf("🔍 交叉核对 %d 只股票: %s\n", len(list), strings.Join(chain.Names(), " / "))
+	opts := fetcher.CrossCheckOptions{PriceTolPct: cfg.Sources.PriceTolPct, VolumeTolPct: cfg.Sources.VolumeTolPct}
+	var results []fetcher.CrossResult
+	for _, code := range list {
+		if ctx.Err() != nil {
+			break
+		}
+		results = append(results, fetcher.CrossCheck(ctx, chain, code, opts))
+	}
+	fetcher.PrintCrossCheck(chain, results)
+}
+
+// importLocal 把 CSV / Parquet K 线导入仓库，paths 为逗号分隔的文件 (支持通配符)
+func importLocal(cfg *config.Config, wh *warehouse.Warehouse, paths string) {
+	if wh == nil {
+		fmt.Println("⚠️ 未配置 warehouse.path, 无法导入")
+		return
+	}
+	var files []string
+	for _, p := range strings.Split(paths, ",") {
+		if p = strings.TrimSpace(p); p == "" {
+			continue
+		}
+		matches, err := filepath.Glob(p)
+		if err != nil || len(matches) == 0 {
+			fmt.Printf("⚠️ 没有匹配的文件: %s\n", p)
+			continue
+		}
+		files = append(files, matches...)
+	}
+
+	ic := cfg.Import
+	adjust, err := model.ParseAdjust(ic.Adjust)
+	if err != nil {
+		fmt.Printf("⚠️ import.adjust 配置有误: %v\n", err)
+		return
+	}
+	if adjust != model.AdjustNone {
+		fmt.Printf("⚠️ 导入的是复权K线 (%s)：已有不复权K线的代码会被拒绝，导入的代码不再实时同步；回测建议使用单独的 warehouse.path\n", adjust)
+	}
+	opts := warehouse.ImportOptions{
+		Code:        ic.Code,
+		Timeframe:   warehouse.Timeframe(ic.Timeframe),
+		Timezone:    ic.Timezone,
+		Adjust:      adjust,
+		StartLabel:  ic.StartLabel,
+		Columns:     ic.Columns,
+		VolumeScale: ic.VolumeScale,
+		AmountScale: ic.AmountScale,
+	}
+	var results []warehouse.ImportResult
+	for _, f := range files {
+		res, err := wh.ImportFile(f, opts)
+		if err != nil {
+			fmt.Printf("❌ 导入失败 %s: %v\n", f, err)
+		}
+		results = append(results, res...)
+	}
+	if len(results) == 0 {
+		fmt.Println("⚠️ 没有导入任何K线")
+		return
+	}
+	fmt.Printf("📥 导入完成: %d 个文件, %d 组K线\n", len(files), len(results))
+	warehouse.PrintImportReport(results)
 }
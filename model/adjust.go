@@ -0,0 +1,36 @@
+package model
+
+import "fmt"
+
+// Adjust 复权口径
+type Adjust string
+
+const (
+	AdjustNone     Adjust = "none" // 不复权 (交易所实际成交价，涨跌停按此计算)
+	AdjustForward  Adjust = "qfq"  // 前复权 (最新价不变，历史价格随每次除权下调)
+	AdjustBackward Adjust = "hfq"  // 后复权 (上市首日价不变，历史价格固定)
+)
+
+// ParseAdjust 解析配置中的复权口径，空字符串为不复权
+func ParseAdjust(s string) (Adjust, error) {
+	switch a := Adjust(s); a {
+	case "":
+		return AdjustNone, nil
+	case AdjustNone, AdjustForward, AdjustBackward:
+		return a, nil
+	}
+	return "", fmt.Errorf("adjust %q: want none / qfq / hfq", s)
+}
+
+// AdjFactor 后复权因子: 自 Date 起 (直到下一条) 不复权价 × Factor = 后复权价。
+// 只在除权除息日变化；序列最后一条为最近一次核对的日期
+type AdjFactor struct {
+	Date   string  `json:"date"` // 2006-01-02
+	Factor float64 `json:"factor"`
+}
+
+// ExRight 除权除息事件 (由复权因子的跳变推出)
+type ExRight struct {
+	Date  string  `json:"date"`
+	Ratio float64 `json:"ratio"` // 当日因子 / 前一日因子，除权参考价 = 前收盘 / Ratio
+}
@@ -0,0 +1,18 @@
+package model
+
+import "strings"
+
+// LimitPct 涨跌幅限制 (小数): 主板 10%，ST 5%，创业板/科创板 20%，北交所 30%，指数 / 板块 0。
+// code 可带市场前缀，与拉取行情使用同一解析 (见 ResolveSecurity)
+func LimitPct(code, name string) float64 {
+	s := ResolveSecurity(code)
+	if name != "" {
+		s.Name = name
+	}
+	return s.LimitPct()
+}
+
+// NewListing 名称带 N (上市首日) / C (注册制上市前 5 日) 前缀的新股，不设涨跌幅限制
+func NewListing(name string) bool {
+	return strings.HasPrefix(name, "N") || strings.HasPrefix(name, "C")
+}
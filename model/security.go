@@ -0,0 +1,194 @@
+package model
+
+import (
+	"strings"
+	"sync"
+)
+
+// Exchange 交易所 (BK 为东财板块指数的虚拟市场)
+type Exchange string
+
+const (
+	ExchangeSH Exchange = "SH"
+	ExchangeSZ Exchange = "SZ"
+	ExchangeBJ Exchange = "BJ"
+	ExchangeBK Exchange = "BK"
+)
+
+// Board 板块
+type Board string
+
+const (
+	BoardMain    Board = "main"    // 沪深主板
+	BoardChiNext Board = "chinext" // 创业板 300/301
+	BoardSTAR    Board = "star"    // 科创板 688/689
+	BoardBSE     Board = "bse"     // 北交所
+	BoardB       Board = "b"       // B 股 900 / 200
+	BoardFund    Board = "fund"    // 场内基金 (ETF / LOF)
+	BoardIndex   Board = "index"   // 指数
+	BoardSector  Board = "sector"  // 东财行业 / 概念板块
+)
+
+// SecurityType 证券类别
+type SecurityType string
+
+const (
+	TypeStock  SecurityType = "stock"
+	TypeFund   SecurityType = "fund"
+	TypeIndex  SecurityType = "index"
+	TypeSector SecurityType = "sector"
+)
+
+// Security 一个证券及其所属市场。东财 secid、新浪 / 腾讯代码与涨跌幅限制都由它推出，
+// 不再按 "6 开头是上海" 猜测
+type Security struct {
+	Code     string       `json:"code"` // 纯代码: 600519 / 000001 / BK0477
+	Name     string       `json:"name,omitempty"`
+	Exchange Exchange     `json:"exchange"`
+	Board    Board        `json:"board"`
+	Type     SecurityType `json:"type"`
+}
+
+// NewSecurity 已知交易所时按代码段确定板块与类别
+func NewSecurity(code string, ex Exchange) Security {
+	s := Security{Code: code, Exchange: ex, Board: BoardMain, Type: TypeStock}
+	has := func(prefixes ...string) bool {
+		for _, p := range prefixes {
+			if strings.HasPrefix(code, p) {
+				return true
+			}
+		}
+		return false
+	}
+	switch ex {
+	case ExchangeBK:
+		s.Board, s.Type = BoardSector, TypeSector
+	case ExchangeBJ:
+		s.Board = BoardBSE
+	case ExchangeSH:
+		switch {
+		case has("688", "689"):
+			s.Board = BoardSTAR
+		case has("900"):
+			s.Board = BoardB
+		case has("000", "880", "999"):
+			s.Board, s.Type = BoardIndex, TypeIndex
+		case has("5"):
+			s.Board, s.Type = BoardFund, TypeFund
+		}
+	case ExchangeSZ:
+		switch {
+		case has("300", "301"):
+			s.Board = BoardChiNext
+		case has("200"):
+			s.Board = BoardB
+		case has("399"):
+			s.Board, s.Type = BoardIndex, TypeIndex
+		case has("15", "16", "18"):
+			s.Board, s.Type = BoardFund, TypeFund
+		}
+	}
+	return s
+}
+
+// ParseSecurity 解析代码。支持带市场的写法 sh000001 / 000001.SH / 1.000001 / 90.BK0477；
+// 纯 6 位数字按代码段推断，000xxx 视为深市股票 (上证指数请写 sh000001 或按名称搜索)
+func ParseSecurity(code string) Security {
+	c := strings.TrimSpace(code)
+	if i := strings.IndexByte(c, '.'); i > 0 {
+		head, tail := c[:i], c[i+1:]
+		switch strings.ToUpper(head) {
+		case "1":
+			return NewSecurity(tail, ExchangeSH)
+		case "0":
+			if ParseSecurity(tail).Exchange == ExchangeBJ {
+				return NewSecurity(tail, ExchangeBJ)
+			}
+			return NewSecurity(tail, ExchangeSZ)
+		case "90":
+			return NewSecurity(tail, ExchangeBK)
+		}
+		if ex := Exchange(strings.ToUpper(tail)); ex == ExchangeSH || ex == ExchangeSZ || ex == ExchangeBJ {
+			return NewSecurity(head, ex)
+		}
+	}
+	if len(c) > 2 {
+		if ex := Exchange(strings.ToUpper(c[:2])); ex == ExchangeSH || ex == ExchangeSZ || ex == ExchangeBJ {
+			return NewSecurity(c[2:], ex)
+		}
+	}
+	switch {
+	case strings.HasPrefix(strings.ToUpper(c), "BK"):
+		return NewSecurity(strings.ToUpper(c), ExchangeBK)
+	case strings.HasPrefix(c, "92") || strings.HasPrefix(c, "4") || strings.HasPrefix(c, "8"):
+		return NewSecurity(c, ExchangeBJ)
+	case strings.HasPrefix(c, "6") || strings.HasPrefix(c, "9") || strings.HasPrefix(c, "5"):
+		return NewSecurity(c, ExchangeSH)
+	}
+	return NewSecurity(c, ExchangeSZ)
+}
+
+// securities SearchStock 等按接口确认过市场的证券，按 secid 登记 (上证指数 1.000001 与平安银行 0.000001 互不覆盖)
+var securities sync.Map
+
+// RegisterSecurity 登记接口确认过的证券 (名称 / 类别以登记的为准)
+func RegisterSecurity(sec Security) {
+	if sec.Code != "" {
+		securities.Store(sec.SecID(), sec)
+	}
+}
+
+// ResolveSecurity 解析代码 (同 ParseSecurity)，再用登记信息补全名称与类别。
+// 纯代码只按代码段推断市场，不会被同号的其他市场证券顶替；上证指数等需用 Key() 的带前缀写法
+func ResolveSecurity(code string) Security {
+	s := ParseSecurity(code)
+	if v, ok := securities.Load(s.SecID()); ok {
+		return v.(Security)
+	}
+	return s
+}
+
+// Key 在各模块间传递的代码: 纯代码能推断出同一市场时用纯代码 (仓库 / 配置里的写法不变)，
+// 否则带市场前缀 (上证指数 sh000001)，保证再次解析时落在同一市场
+func (s Security) Key() string {
+	if s.Exchange == ExchangeBK || ParseSecurity(s.Code).Exchange == s.Exchange {
+		return s.Code
+	}
+	return s.Symbol()
+}
+
+// SecID 东财行情接口的 secid: 上海 1.，深圳 / 北交所 0.，板块 90.
+func (s Security) SecID() string {
+	switch s.Exchange {
+	case ExchangeSH:
+		return "1." + s.Code
+	case ExchangeBK:
+		return "90." + s.Code
+	}
+	return "0." + s.Code
+}
+
+// Symbol 新浪 / 腾讯的代码格式: sh600519 / sz000001 / bj430047；板块没有对应代码
+func (s Security) Symbol() string {
+	if s.Exchange == ExchangeBK {
+		return ""
+	}
+	return strings.ToLower(string(s.Exchange)) + s.Code
+}
+
+// LimitPct 涨跌幅限制 (小数): 主板 / B 股 / 基金 10%，ST 5%，创业板 / 科创板 20%，北交所 30%；
+// 指数与板块没有涨跌停，返回 0
+func (s Security) LimitPct() float64 {
+	switch s.Board {
+	case BoardIndex, BoardSector:
+		return 0
+	case BoardChiNext, BoardSTAR:
+		return 0.20
+	case BoardBSE:
+		return 0.30
+	}
+	if strings.Contains(strings.ToUpper(s.Name), "ST") {
+		return 0.05
+	}
+	return 0.10
+}
@@ -1,6 +1,9 @@
 package model
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"time"
+)
 
 // StockInfo represents the detailed information of a stock.
 type StockInfo struct {
@@ -18,12 +21,20 @@ type StockInfo struct {
 	Tags          []string // 板块标签
 
 	// --- V9.0 新增指标 ---
-	CallAuctionAmt float64 `json:"call_auction_amt"` // 真实竞价金额 (f277)
-	LHBInfo        string  `json:"lhb_info"`         // 龙虎榜摘要
-	LHBNet         float64 `json:"lhb_net"`          // 龙虎榜净买入
-	Buy1Vol        int     `json:"buy1_vol"`         // 买一量 (手)
-	Buy1Price      float64 `json:"buy1_price"`       // 买一价
-	Sell1Vol       int     `json:"sell1_vol"`        // 卖一量 (手)
+	CallAuctionAmt float64          `json:"call_auction_amt"`     // 真实竞价金额 (f277)
+	LHBInfo        string           `json:"lhb_info"`             // 龙虎榜摘要
+	LHBNet         float64          `json:"lhb_net"`              // 龙虎榜净买入
+	LHBDate        string           `json:"lhb_date"`             // 🆕 上榜日期
+	LHBSeats       []LHBSeat        `json:"lhb_seats"`            // 🆕 龙虎榜买卖席位明细
+	HotSeats       []SeatFollow     `json:"hot_seats"`            // 🆕 今日买入的知名席位及其历史跟风表现
+	Auction        *AuctionFeatures `json:"auction,omitempty"`    // 🆕 集合竞价时序特征 (需运行 -auction 采集)
+	Buy1Vol        int              `json:"buy1_vol"`             // 买一量 (手)
+	Buy1Price      float64          `json:"buy1_price"`           // 买一价
+	Sell1Vol       int              `json:"sell1_vol"`            // 卖一量 (手)
+	OrderBook      *OrderBook       `json:"order_book,omitempty"` // 🆕 五档盘口
+	BookImbalance  float64          `json:"book_imbalance"`       // 🆕 盘口不平衡 (五档买量-卖量)/(买量+卖量)，-1~1
+	SealAmt        float64          `json:"seal_amt"`             // 🆕 涨停封单金额 (买一挂单，未涨停为 0)
+	SealRatio      float64          `json:"seal_ratio"`           // 🆕 封单占流通市值 (%)
 
 	// --- V10.0 深度记忆 ---
 	VWAP        float64 `json:"vwap"`         // 30日均价
@@ -49,6 +60,144 @@ type StockInfo struct {
 	KLine30mStr string  `json:"kline_30m_str"` // 30m K线原始数据
 }
 
+// LHBSeat 龙虎榜席位 (营业部)
+type LHBSeat struct {
+	Name    string  `json:"name"`
+	Side    string  `json:"side"` // buy: 买入前五 / sell: 卖出前五
+	BuyAmt  float64 `json:"buy_amt"`
+	SellAmt float64 `json:"sell_amt"`
+	NetAmt  float64 `json:"net_amt"`
+	Kind    string  `json:"kind,omitempty"`  // 机构 / 北向 / 游资 / 拉萨天团
+	Alias   string  `json:"alias,omitempty"` // 知名游资别名 (如 章盟主)
+}
+
+const (
+	SeatBuy  = "buy"
+	SeatSell = "sell"
+)
+
+// LHBRecord 个股某日的龙虎榜 (一只股票同日可能因多个上榜原因出现多次，此处合并)
+type LHBRecord struct {
+	Date    string    `json:"date"`
+	Code    string    `json:"code"`
+	Name    string    `json:"name"`
+	Explain string    `json:"explain"` // 解读 (如 "买一主买")
+	Reason  string    `json:"reason"`  // 上榜原因
+	NetAmt  float64   `json:"net_amt"`
+	BuyAmt  float64   `json:"buy_amt"`
+	SellAmt float64   `json:"sell_amt"`
+	Seats   []LHBSeat `json:"seats"`
+}
+
+// BookLevel 盘口一档
+type BookLevel struct {
+	Price float64 `json:"price"`
+	Vol   int64   `json:"vol"` // 手
+}
+
+// OrderBook 五档盘口 (Bids[0] 为买一，Asks[0] 为卖一)
+type OrderBook struct {
+	Bids        [5]BookLevel `json:"bids"`
+	Asks        [5]BookLevel `json:"asks"`
+	Price       float64      `json:"price"`
+	PrevClose   float64      `json:"prev_close"`
+	LimitUp     float64      `json:"limit_up"`     // 涨停价
+	LimitDown   float64      `json:"limit_down"`   // 跌停价
+	FloatShares float64      `json:"float_shares"` // 流通股本 (股)
+	Time        time.Time    `json:"time"`
+}
+
+// Quote 实时行情快照 (东财 / 新浪 / 腾讯统一格式)
+type Quote struct {
+	OrderBook
+	Code   string  `json:"code"`
+	Name   string  `json:"name"`
+	Open   float64 `json:"open"`
+	High   float64 `json:"high"`
+	Low    float64 `json:"low"`
+	Volume float64 `json:"volume"` // 成交量 (手)
+	Amount float64 `json:"amount"` // 成交额 (元)
+	Source string  `json:"source"` // 数据源
+}
+
+// AuctionTick 集合竞价 (9:15-9:25) 某一时刻的虚拟撮合快照
+type AuctionTick struct {
+	Code          string    `json:"code"`
+	Time          time.Time `json:"time"`
+	Price         float64   `json:"price"`          // 虚拟匹配价
+	Volume        int64     `json:"volume"`         // 匹配量 (手)
+	Amount        float64   `json:"amount"`         // 匹配金额
+	UnmatchedBuy  int64     `json:"unmatched_buy"`  // 未匹配买量 (手)
+	UnmatchedSell int64     `json:"unmatched_sell"` // 未匹配卖量 (手)
+	PrevClose     float64   `json:"prev_close"`
+}
+
+// AuctionFeatures 集合竞价时序特征 (涨幅均相对昨收，单位 %)
+type AuctionFeatures struct {
+	Ticks       int     `json:"ticks"`
+	Pct920      float64 `json:"pct_920"`      // 9:20 前最后一笔涨幅
+	PeakPct920  float64 `json:"peak_pct_920"` // 9:20 前最高涨幅 (可撤单阶段的挂单高点)
+	OpenPct     float64 `json:"open_pct"`     // 9:25 撮合涨幅
+	TrendPct    float64 `json:"trend_pct"`    // 9:20→9:25 涨幅变化 (百分点)
+	WithdrawPct float64 `json:"withdraw_pct"` // 撤单率: 9:20 前未匹配买量峰值到 9:20 后的回落比例 (0-1)
+	VolGrowth   float64 `json:"vol_growth"`   // 9:25 匹配量 / 9:20 匹配量
+	Imbalance   float64 `json:"imbalance"`    // 最后一笔 (未匹配买-卖)/(买+卖)，-1~1
+	Amount      float64 `json:"amount"`       // 9:25 匹配金额
+	Signal      string  `json:"signal"`       // 抢筹 / 走强 / 走弱 / 撤单诱多 / 平稳
+}
+
+// WatchTarget 盘中盯盘对象 (来自扫描 FinalPool 或持仓审视)，价位由 AI 给出，解析不到时为 0
+type WatchTarget struct {
+	Code        string  `json:"code"`
+	Name        string  `json:"name"`
+	Source      string  `json:"source"`       // pool / hold
+	StopLoss    float64 `json:"stop_loss"`    // 止损价
+	TargetPrice float64 `json:"target_price"` // 止盈价
+}
+
+const (
+	WatchPool = "pool"
+	WatchHold = "hold"
+)
+
+// Alert 盘中预警
+type Alert struct {
+	Time    time.Time `json:"time"`
+	Code    string    `json:"code"`
+	Name    string    `json:"name"`
+	Rule    string    `json:"rule"`
+	Price   float64   `json:"price"`
+	Message string    `json:"message"`
+}
+
+// 预警规则
+const (
+	AlertLimitBreak = "炸板"
+	AlertVolSpike   = "放量异动"
+	AlertStopLoss   = "触及止损"
+	AlertTarget     = "触及止盈"
+	AlertVWAPLoss   = "跌破30m均价"
+)
+
+// SeatProfile 席位历史画像 (基于本地龙虎榜库)
+type SeatProfile struct {
+	Name        string  `json:"name"`
+	Kind        string  `json:"kind,omitempty"`
+	Alias       string  `json:"alias,omitempty"`
+	Appearances int     `json:"appearances"`   // 上榜次数 (买卖合计)
+	Samples     int     `json:"samples"`       // 净买入且有次日行情的样本数
+	WinRate     float64 `json:"win_rate"`      // 次日收涨比例 (0-1)
+	AvgNextRet  float64 `json:"avg_next_ret"`  // 次日平均涨幅 (%)
+	AvgHoldDays float64 `json:"avg_hold_days"` // 买入到上榜卖出的平均交易日 (0 = 未见卖出)
+	Style       string  `json:"style"`         // 一日游 / 超短接力 / 波段 / 中线锁仓 / 未见卖出
+}
+
+// SeatFollow 今日买入的席位及其历史跟风表现
+type SeatFollow struct {
+	SeatProfile
+	NetAmt float64 `json:"net_amt"` // 今日净买入
+}
+
 type SectorInfo struct {
 	Code string `json:"f12"`
 	Name string `json:"f14"`
@@ -70,6 +219,12 @@ type KLineData struct {
 	Close  float64
 	Change float64
 	Amount float64 // 成交额
+
+	// 🆕 开/高/低/量: 新浪、腾讯与本地导入的数据才有，东财 K 线接口只取收盘价和成交额
+	Open   float64 `json:",omitempty"`
+	High   float64 `json:",omitempty"`
+	Low    float64 `json:",omitempty"`
+	Volume float64 `json:",omitempty"` // 成交量 (手)
 }
 
 // --- API Response ---
@@ -167,12 +322,20 @@ type ReadableStockInfo struct {
 	Tags          []string `json:"tags"`          // 板块标签
 
 	// --- V9.0 新增指标 ---
-	CallAuctionAmt float64 `json:"call_auction_amt"` // 真实竞价金额 (f277)
-	LHBInfo        string  `json:"lhb_info"`         // 龙虎榜摘要
-	LHBNet         float64 `json:"lhb_net"`          // 龙虎榜净买入
-	Buy1Vol        int     `json:"buy1_vol"`         // 买一量 (手)
-	Buy1Price      float64 `json:"buy1_price"`       // 买一价
-	Sell1Vol       int     `json:"sell1_vol"`        // 卖一量 (手)
+	CallAuctionAmt float64          `json:"call_auction_amt"`     // 真实竞价金额 (f277)
+	LHBInfo        string           `json:"lhb_info"`             // 龙虎榜摘要
+	LHBNet         float64          `json:"lhb_net"`              // 龙虎榜净买入
+	LHBDate        string           `json:"lhb_date"`             // 🆕 上榜日期
+	LHBSeats       []LHBSeat        `json:"lhb_seats"`            // 🆕 龙虎榜买卖席位明细
+	HotSeats       []SeatFollow     `json:"hot_seats"`            // 🆕 今日买入的知名席位及其历史跟风表现
+	Auction        *AuctionFeatures `json:"auction,omitempty"`    // 🆕 集合竞价时序特征 (需运行 -auction 采集)
+	Buy1Vol        int              `json:"buy1_vol"`             // 买一量 (手)
+	Buy1Price      float64          `json:"buy1_price"`           // 买一价
+	Sell1Vol       int              `json:"sell1_vol"`            // 卖一量 (手)
+	OrderBook      *OrderBook       `json:"order_book,omitempty"` // 🆕 五档盘口
+	BookImbalance  float64          `json:"book_imbalance"`       // 🆕 盘口不平衡 (五档买量-卖量)/(买量+卖量)，-1~1
+	SealAmt        float64          `json:"seal_amt"`             // 🆕 涨停封单金额 (买一挂单，未涨停为 0)
+	SealRatio      float64          `json:"seal_ratio"`           // 🆕 封单占流通市值 (%)
 
 	// --- V10.0 深度记忆 ---
 	VWAP        float64 `json:"vwap"`         // 30日均价
@@ -212,6 +375,16 @@ type RiskConfig struct {
 	// 寻宝配置
 	GoodHabits    []string `json:"good_habits"`     // 好的股性
 	MinBoardCount int      `json:"min_board_count"` // 最小上榜次数
+
+	// 🆕 龙虎榜席位
+	MinSeatSamples  int     `json:"min_seat_samples"`   // 席位历史样本数达到才参与评分
+	GoodSeatWinRate float64 `json:"good_seat_win_rate"` // 买入席位次日胜率 >= 此值加分
+	BadSeatWinRate  float64 `json:"bad_seat_win_rate"`  // 买入席位次日胜率 <= 此值 (或一日游) 扣分
+
+	// 🆕 五档盘口
+	MinSealRatio     float64 `json:"min_seal_ratio"`     // 涨停封单占流通市值 (%) 低于此值视为封单薄弱
+	StrongSealRatio  float64 `json:"strong_seal_ratio"`  // 封单占比 >= 此值加分
+	MinBookImbalance float64 `json:"min_book_imbalance"` // 盘口不平衡度 <= 此值视为卖压过重
 }
 
 type RiskResult struct {
@@ -219,3 +392,22 @@ type RiskResult struct {
 	Reason    string
 	RiskScore int // 1-5分
 }
+
+// --- 持仓 / 交易记录 ---
+
+// Trade 一笔成交 (来自券商交割单或成交导出)
+type Trade struct {
+	Date     string  `json:"date"` // 2006-01-02
+	Time     string  `json:"time"` // 15:04:05 (可为空)
+	Code     string  `json:"code"`
+	Name     string  `json:"name"`
+	Side     string  `json:"side"` // "buy" / "sell"
+	Quantity int     `json:"quantity"`
+	Price    float64 `json:"price"`
+	Amount   float64 `json:"amount"` // 成交金额 (缺失时为 Price*Quantity)
+}
+
+const (
+	TradeBuy  = "buy"
+	TradeSell = "sell"
+)
@@ -0,0 +1,236 @@
+package notifier
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+// 机器人消息的长度上限 (字节)，超出时接口直接报错
+const (
+	wecomMaxBytes    = 4096  // 企业微信 markdown.content
+	dingtalkMaxBytes = 20000 // 钉钉 markdown.text
+	feishuMaxBytes   = 20000 // 飞书请求体上限 20K，正文留出签名等字段的余量
+)
+
+// truncateNote 截断后追加的提示 (计入上限)
+const truncateNote = "\n…(内容过长已截断，完整内容见报告)"
+
+// truncateBytes 把 s 截断到不超过 max 字节 (按 UTF-8 字符边界，不截半个汉字)
+func truncateBytes(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	cut := max - len(truncateNote)
+	if cut < 0 {
+		cut = 0
+	}
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + truncateNote
+}
+
+// postJSON 发送 JSON，非 2xx 视为失败。机器人接口在 200 里返回 errcode/code，非 0 也视为失败。
+func postJSON(rawURL string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	resp, err := httpClient.Post(rawURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("http %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	var ack struct {
+		ErrCode *int   `json:"errcode"` // 企业微信 / 钉钉
+		Code    *int   `json:"code"`    // 飞书
+		ErrMsg  string `json:"errmsg"`
+		Msg     string `json:"msg"`
+	}
+	if json.Unmarshal(data, &ack) == nil {
+		if ack.ErrCode != nil && *ack.ErrCode != 0 {
+			return fmt.Errorf("errcode %d: %s", *ack.ErrCode, ack.ErrMsg)
+		}
+		if ack.Code != nil && *ack.Code != 0 {
+			return fmt.Errorf("code %d: %s", *ack.Code, ack.Msg)
+		}
+	}
+	return nil
+}
+
+// Webhook 通用 JSON POST: {"kind","title","body","code","time","alert"}，body 为模板渲染后的正文
+type Webhook struct {
+	URL string
+}
+
+func (n *Webhook) Name() string { return "webhook" }
+
+func (n *Webhook) Send(msg Message, text Text) error {
+	msg.Body = text.Body
+	return postJSON(n.URL, msg)
+}
+
+// WeCom 企业微信群机器人 (markdown)
+type WeCom struct {
+	URL string
+}
+
+func (n *WeCom) Name() string { return "wecom" }
+
+func (n *WeCom) Send(_ Message, text Text) error {
+	return postJSON(n.URL, map[string]interface{}{
+		"msgtype":  "markdown",
+		"markdown": map[string]string{"content": truncateBytes("**"+text.Title+"**\n"+text.Body, wecomMaxBytes)},
+	})
+}
+
+// DingTalk 钉钉群机器人 (markdown)，配置 Secret 时按加签方式追加 timestamp/sign
+type DingTalk struct {
+	URL    string
+	Secret string
+	Now    func() time.Time // 测试用
+}
+
+func (n *DingTalk) Name() string { return "dingtalk" }
+
+func (n *DingTalk) Send(_ Message, text Text) error {
+	target := n.URL
+	if n.Secret != "" {
+		ts := strconv.FormatInt(nowOr(n.Now).UnixMilli(), 10)
+		mac := hmac.New(sha256.New, []byte(n.Secret))
+		mac.Write([]byte(ts + "\n" + n.Secret))
+		sign := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+		target = appendQuery(target, url.Values{"timestamp": {ts}, "sign": {sign}})
+	}
+	return postJSON(target, map[string]interface{}{
+		"msgtype":  "markdown",
+		"markdown": map[string]string{"title": text.Title, "text": truncateBytes("#### "+text.Title+"\n"+text.Body, dingtalkMaxBytes)},
+	})
+}
+
+// Feishu 飞书群机器人 (文本)，配置 Secret 时在消息体中带 timestamp/sign
+type Feishu struct {
+	URL    string
+	Secret string
+	Now    func() time.Time // 测试用
+}
+
+func (n *Feishu) Name() string { return "feishu" }
+
+func (n *Feishu) Send(_ Message, text Text) error {
+	payload := map[string]interface{}{
+		"msg_type": "text",
+		"content":  map[string]string{"text": truncateBytes(text.Title+"\n"+text.Body, feishuMaxBytes)},
+	}
+	if n.Secret != "" {
+		ts := strconv.FormatInt(nowOr(n.Now).Unix(), 10)
+		// 飞书以 timestamp+"\n"+secret 为密钥对空串签名
+		mac := hmac.New(sha256.New, []byte(ts+"\n"+n.Secret))
+		payload["timestamp"] = ts
+		payload["sign"] = base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	}
+	return postJSON(n.URL, payload)
+}
+
+// Email SMTP 邮件 (纯文本)
+type Email struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	To       []string
+
+	// 测试中替换
+	SendMail func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+func (n *Email) Name() string { return "email" }
+
+func (n *Email) Send(_ Message, text Text) error {
+	if len(n.To) == 0 {
+		return fmt.Errorf("no recipients")
+	}
+	port := n.Port
+	if port == 0 {
+		port = 587
+	}
+	var auth smtp.Auth
+	if n.Username != "" {
+		auth = smtp.PlainAuth("", n.Username, n.Password, n.Host)
+	}
+	from := n.From
+	if from == "" {
+		from = n.Username
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(n.To, ", "))
+	fmt.Fprintf(&b, "Subject: =?UTF-8?B?%s?=\r\n", base64.StdEncoding.EncodeToString([]byte(text.Title)))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(text.Body, "\n", "\r\n"))
+
+	send := n.SendMail
+	if send == nil {
+		send = smtp.SendMail
+	}
+	return send(fmt.Sprintf("%s:%d", n.Host, port), auth, from, n.To, []byte(b.String()))
+}
+
+// File 追加写入本地文件 (每条消息一段，空行分隔)
+type File struct {
+	Path string
+}
+
+func (n *File) Name() string { return "file" }
+
+func (n *File) Send(_ Message, text Text) error {
+	if dir := filepath.Dir(n.Path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	f, err := os.OpenFile(n.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = fmt.Fprintf(f, "%s\n%s\n\n", text.Title, text.Body)
+	return err
+}
+
+func nowOr(now func() time.Time) time.Time {
+	if now != nil {
+		return now()
+	}
+	return time.Now()
+}
+
+func appendQuery(rawURL string, q url.Values) string {
+	sep := "?"
+	if strings.Contains(rawURL, "?") {
+		sep = "&"
+	}
+	return rawURL + sep + q.Encode()
+}
@@ -0,0 +1,209 @@
+package notifier
+
+import (
+	"bytes"
+	"dragon-quant/config"
+	"dragon-quant/model"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// 消息类型
+const (
+	KindAlert  = "alert"  // 盘中预警
+	KindReport = "report" // 报告完成 (总决赛等)
+)
+
+// Message 一条待推送的消息。Key 用于去重，为空时按 Kind+Title+Body 去重。
+type Message struct {
+	Kind  string       `json:"kind"`
+	Key   string       `json:"key,omitempty"`
+	Title string       `json:"title"`
+	Body  string       `json:"body"`
+	Code  string       `json:"code,omitempty"`
+	Time  time.Time    `json:"time"`
+	Alert *model.Alert `json:"alert,omitempty"`
+}
+
+// Text 渲染后的正文 (由渠道模板生成)，各 Notifier 直接发送
+type Text struct {
+	Title string
+	Body  string
+}
+
+// Notifier 推送渠道
+type Notifier interface {
+	Name() string
+	Send(msg Message, text Text) error
+}
+
+// AlertMessage 预警转消息 (同一股票同一规则按 Key 去重)
+func AlertMessage(a model.Alert) Message {
+	return Message{
+		Kind:  KindAlert,
+		Key:   a.Code + "|" + a.Rule,
+		Title: fmt.Sprintf("🚨 %s %s %s", a.Name, a.Code, a.Rule),
+		Body:  fmt.Sprintf("%s %s", a.Time.Format("15:04:05"), a.Message),
+		Code:  a.Code,
+		Time:  a.Time,
+		Alert: &a,
+	}
+}
+
+// ReportMessage 报告消息
+func ReportMessage(title, body string) Message {
+	return Message{Kind: KindReport, Title: title, Body: body, Time: time.Now()}
+}
+
+// DefaultTemplate 未配置模板时的正文
+const DefaultTemplate = "{{.Body}}"
+
+// channel 渠道 + 模板 + 去重窗口 + 订阅的消息类型
+type channel struct {
+	Notifier
+	tmpl   *template.Template
+	window time.Duration
+	kinds  []string
+
+	mu   sync.Mutex
+	seen map[string]time.Time
+}
+
+func (c *channel) accepts(kind string) bool {
+	if len(c.kinds) == 0 {
+		return true
+	}
+	for _, k := range c.kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// duplicate 去重窗口内已发送过同 Key 的消息。未重复时先占位 (并发发送同 Key 只发一次)，发送失败由 release 撤销。
+func (c *channel) duplicate(key string, now time.Time) bool {
+	if c.window <= 0 {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if last, ok := c.seen[key]; ok && now.Sub(last) < c.window {
+		return true
+	}
+	c.seen[key] = now
+	return false
+}
+
+// release 撤销 duplicate 的占位 (渲染或推送失败)，下次同 Key 消息可以重试
+func (c *channel) release(key string, at time.Time) {
+	if c.window <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.seen[key].Equal(at) {
+		delete(c.seen, key)
+	}
+}
+
+// Dispatcher 把消息分发到全部渠道。方法对 nil 安全 (未配置推送时不做任何事)。
+type Dispatcher struct {
+	channels []*channel
+	now      func() time.Time
+}
+
+// New 创建分发器
+func New() *Dispatcher {
+	return &Dispatcher{now: time.Now}
+}
+
+// Add 注册渠道。tmpl 为 text/template (字段同 Message)，为空用 DefaultTemplate；window<=0 不去重；kinds 为空接收全部类型。
+func (d *Dispatcher) Add(n Notifier, tmpl string, window time.Duration, kinds ...string) error {
+	if tmpl == "" {
+		tmpl = DefaultTemplate
+	}
+	t, err := template.New(n.Name()).Parse(tmpl)
+	if err != nil {
+		return fmt.Errorf("notifier %s template: %w", n.Name(), err)
+	}
+	d.channels = append(d.channels, &channel{
+		Notifier: n, tmpl: t, window: window, kinds: kinds,
+		seen: make(map[string]time.Time),
+	})
+	return nil
+}
+
+// Len 已注册渠道数
+func (d *Dispatcher) Len() int {
+	if d == nil {
+		return 0
+	}
+	return len(d.channels)
+}
+
+// Send 推送到全部订阅该类型的渠道，单个渠道失败不影响其他渠道
+func (d *Dispatcher) Send(msg Message) error {
+	if d == nil {
+		return nil
+	}
+	if msg.Time.IsZero() {
+		msg.Time = d.now()
+	}
+	key := msg.Key
+	if key == "" {
+		key = msg.Kind + "|" + msg.Title + "|" + msg.Body
+	}
+
+	var errs []error
+	for _, c := range d.channels {
+		now := d.now()
+		if !c.accepts(msg.Kind) || c.duplicate(key, now) {
+			continue
+		}
+		var buf bytes.Buffer
+		if err := c.tmpl.Execute(&buf, msg); err != nil {
+			c.release(key, now)
+			errs = append(errs, fmt.Errorf("%s: render: %w", c.Name(), err))
+			continue
+		}
+		if err := c.Send(msg, Text{Title: msg.Title, Body: strings.TrimSpace(buf.String())}); err != nil {
+			c.release(key, now)
+			fmt.Printf("⚠️ [Notify] %s 推送失败: %v\n", c.Name(), err)
+			errs = append(errs, fmt.Errorf("%s: %w", c.Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// FromConfig 按 config.yaml 的 notify.channels 创建分发器
+func FromConfig(cfg config.NotifyConfig) (*Dispatcher, error) {
+	d := New()
+	for i, ch := range cfg.Channels {
+		var n Notifier
+		switch strings.ToLower(ch.Type) {
+		case "webhook":
+			n = &Webhook{URL: ch.URL}
+		case "wecom":
+			n = &WeCom{URL: ch.URL}
+		case "dingtalk":
+			n = &DingTalk{URL: ch.URL, Secret: ch.Secret}
+		case "feishu":
+			n = &Feishu{URL: ch.URL, Secret: ch.Secret}
+		case "email":
+			n = &Email{Host: ch.SMTPHost, Port: ch.SMTPPort, Username: ch.Username, Password: ch.Password, From: ch.From, To: ch.To}
+		case "file":
+			n = &File{Path: ch.Path}
+		default:
+			return nil, fmt.Errorf("notify channel %d: unknown type %q", i, ch.Type)
+		}
+		if err := d.Add(n, ch.Template, time.Duration(ch.DedupMinutes)*time.Minute, ch.Kinds...); err != nil {
+			return nil, err
+		}
+	}
+	return d, nil
+}
@@ -0,0 +1,212 @@
+package notifier
+
+import (
+	"dragon-quant/config"
+	"dragon-quant/model"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+// hookServer 本地 HTTP 替身，记录每次请求的 URL 与 JSON body
+type hookServer struct {
+	*httptest.Server
+	urls   []string
+	bodies []map[string]interface{}
+}
+
+func newHookServer(t *testing.T, reply string) *hookServer {
+	h := &hookServer{}
+	h.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		var m map[string]interface{}
+		if err := json.Unmarshal(data, &m); err != nil {
+			t.Errorf("bad json: %s", data)
+		}
+		h.urls = append(h.urls, r.URL.String())
+		h.bodies = append(h.bodies, m)
+		io.WriteString(w, reply)
+	}))
+	t.Cleanup(h.Close)
+	return h
+}
+
+func TestBotFormats(t *testing.T) {
+	alert := AlertMessage(model.Alert{
+		Time: time.Date(2026, 3, 2, 10, 31, 0, 0, time.UTC), Code: "600001", Name: "测试股份",
+		Rule: model.AlertLimitBreak, Price: 11, Message: "涨停打开，现价 11.00",
+	})
+	fixed := func() time.Time { return time.UnixMilli(1700000000000) }
+
+	wecom := newHookServer(t, `{"errcode":0}`)
+	ding := newHookServer(t, `{"errcode":0}`)
+	feishu := newHookServer(t, `{"code":0}`)
+	hook := newHookServer(t, `ok`)
+
+	d := New()
+	d.Add(&WeCom{URL: wecom.URL}, "", 0)
+	d.Add(&DingTalk{URL: ding.URL + "/robot/send?access_token=x", Secret: "SEC", Now: fixed}, "", 0)
+	d.Add(&Feishu{URL: feishu.URL, Secret: "SEC", Now: fixed}, "{{.Code}} {{.Alert.Rule}}", 0)
+	d.Add(&Webhook{URL: hook.URL}, "", 0)
+	if err := d.Send(alert); err != nil {
+		t.Fatal(err)
+	}
+
+	md := wecom.bodies[0]["markdown"].(map[string]interface{})
+	if wecom.bodies[0]["msgtype"] != "markdown" || !strings.Contains(md["content"].(string), "涨停打开") {
+		t.Errorf("wecom payload %v", wecom.bodies[0])
+	}
+
+	if !strings.Contains(ding.urls[0], "access_token=x&") || !strings.Contains(ding.urls[0], "timestamp=1700000000000") || !strings.Contains(ding.urls[0], "sign=") {
+		t.Errorf("dingtalk url %s", ding.urls[0])
+	}
+	if md := ding.bodies[0]["markdown"].(map[string]interface{}); md["title"] != alert.Title {
+		t.Errorf("dingtalk payload %v", ding.bodies[0])
+	}
+
+	fb := feishu.bodies[0]
+	if fb["msg_type"] != "text" || fb["timestamp"] != "1700000000" || fb["sign"] == nil {
+		t.Errorf("feishu payload %v", fb)
+	}
+	if text := fb["content"].(map[string]interface{})["text"].(string); !strings.HasSuffix(text, "600001 炸板") {
+		t.Errorf("feishu template not applied: %q", text)
+	}
+
+	if hb := hook.bodies[0]; hb["kind"] != KindAlert || hb["code"] != "600001" || hb["alert"] == nil {
+		t.Errorf("webhook payload %v", hb)
+	}
+}
+
+func TestBotLongReportTruncated(t *testing.T) {
+	wecom := newHookServer(t, `{"errcode":0}`)
+	ding := newHookServer(t, `{"errcode":0}`)
+	d := New()
+	d.Add(&WeCom{URL: wecom.URL}, "", 0)
+	d.Add(&DingTalk{URL: ding.URL}, "", 0)
+
+	body := "📄 完整报告: /tmp/winners.html\n" + strings.Repeat("龙头逻辑详解。", 2000)
+	if err := d.Send(ReportMessage("🏆 龙头总决赛", body)); err != nil {
+		t.Fatal(err)
+	}
+	content := wecom.bodies[0]["markdown"].(map[string]interface{})["content"].(string)
+	if len(content) > wecomMaxBytes || !utf8.ValidString(content) || !strings.Contains(content, "/tmp/winners.html") || !strings.HasSuffix(content, truncateNote) {
+		t.Errorf("wecom content: %d bytes, valid=%v", len(content), utf8.ValidString(content))
+	}
+	if text := ding.bodies[0]["markdown"].(map[string]interface{})["text"].(string); len(text) > dingtalkMaxBytes {
+		t.Errorf("dingtalk text: %d bytes", len(text))
+	}
+}
+
+func TestBotErrorCode(t *testing.T) {
+	bad := newHookServer(t, `{"errcode":310000,"errmsg":"sign not match"}`)
+	good := newHookServer(t, `{"errcode":0}`)
+	d := New()
+	d.Add(&DingTalk{URL: bad.URL}, "", 0)
+	d.Add(&WeCom{URL: good.URL}, "", 0)
+
+	err := d.Send(ReportMessage("总决赛", "body"))
+	if err == nil || !strings.Contains(err.Error(), "sign not match") {
+		t.Errorf("err = %v", err)
+	}
+	if len(good.bodies) != 1 {
+		t.Error("one failing channel should not block the others")
+	}
+}
+
+func TestDedupAndKinds(t *testing.T) {
+	hook := newHookServer(t, `ok`)
+	now := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	d := New()
+	d.now = func() time.Time { return now }
+	d.Add(&Webhook{URL: hook.URL}, "", 30*time.Minute, KindAlert)
+
+	a := model.Alert{Time: now, Code: "600001", Rule: model.AlertVolSpike, Message: "10:00 放量"}
+	d.Send(AlertMessage(a))
+	a.Message = "10:05 放量"
+	d.Send(AlertMessage(a)) // 同股同规则，窗口内去重
+	d.Send(ReportMessage("总决赛", "x"))
+	if len(hook.bodies) != 1 {
+		t.Fatalf("sent %d, want 1", len(hook.bodies))
+	}
+
+	now = now.Add(31 * time.Minute)
+	d.Send(AlertMessage(a))
+	if len(hook.bodies) != 2 {
+		t.Errorf("sent %d after window, want 2", len(hook.bodies))
+	}
+}
+
+func TestDedupRetriesAfterFailure(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			http.Error(w, "busy", http.StatusBadGateway)
+			return
+		}
+		io.WriteString(w, "ok")
+	}))
+	defer srv.Close()
+
+	d := New()
+	d.Add(&Webhook{URL: srv.URL}, "", 30*time.Minute)
+	a := model.Alert{Time: time.Now(), Code: "600001", Rule: model.AlertStopLoss, Message: "跌破止损"}
+	if err := d.Send(AlertMessage(a)); err == nil {
+		t.Fatal("expected first send to fail")
+	}
+	// 失败的推送不占用去重窗口，下一轮应当重发
+	if err := d.Send(AlertMessage(a)); err != nil || calls != 2 {
+		t.Fatalf("retry: calls=%d err=%v, want 2 calls", calls, err)
+	}
+	d.Send(AlertMessage(a))
+	if calls != 2 {
+		t.Errorf("sent %d, want dedup after success", calls)
+	}
+}
+
+func TestFileAndEmail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out", "alerts.log")
+	var sent []byte
+	var rcpt []string
+	d, err := FromConfig(config.NotifyConfig{Channels: []config.NotifyChannel{
+		{Type: "file", Path: path, Template: "[{{.Kind}}] {{.Body}}"},
+		{Type: "email", SMTPHost: "smtp.example.com", From: "a@example.com", To: []string{"b@example.com"}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.channels[1].Notifier.(*Email).SendMail = func(addr string, _ smtp.Auth, _ string, to []string, msg []byte) error {
+		if addr != "smtp.example.com:587" {
+			t.Errorf("addr %s", addr)
+		}
+		rcpt, sent = to, msg
+		return nil
+	}
+
+	if err := d.Send(ReportMessage("🏆 总决赛", "600001 测试股份")); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	if string(data) != "🏆 总决赛\n[report] 600001 测试股份\n\n" {
+		t.Errorf("file = %q", data)
+	}
+	if len(rcpt) != 1 || !strings.Contains(string(sent), "Subject: =?UTF-8?B?") || !strings.HasSuffix(string(sent), "600001 测试股份") {
+		t.Errorf("mail = %q", sent)
+	}
+
+	if _, err := FromConfig(config.NotifyConfig{Channels: []config.NotifyChannel{{Type: "pager"}}}); err == nil {
+		t.Error("unknown type should fail")
+	}
+	var nilD *Dispatcher
+	if nilD.Send(ReportMessage("x", "y")) != nil || nilD.Len() != 0 {
+		t.Error("nil dispatcher should be a no-op")
+	}
+}
@@ -153,9 +153,17 @@ func GenFiles(cfg *config.Config, allSectors []model.SectorInfo, stocks []*model
 					CallAuctionAmt: s.CallAuctionAmt,
 					LHBInfo:        s.LHBInfo,
 					LHBNet:         s.LHBNet,
+					LHBDate:        s.LHBDate,
+					LHBSeats:       s.LHBSeats,
+					HotSeats:       s.HotSeats,
+					Auction:        s.Auction,
 					Buy1Vol:        s.Buy1Vol,
 					Buy1Price:      s.Buy1Price,
 					Sell1Vol:       s.Sell1Vol,
+					OrderBook:      s.OrderBook,
+					BookImbalance:  s.BookImbalance,
+					SealAmt:        s.SealAmt,
+					SealRatio:      s.SealRatio,
 					VWAP:           s.VWAP,
 					ProfitDev:      s.ProfitDev,
 					OpenVolRatio:   s.OpenVolRatio,
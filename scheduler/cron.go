@@ -0,0 +1,106 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Spec 解析后的 cron 表达式 "分 时 日 月 周"
+type Spec struct {
+	minute [60]bool
+	hour   [24]bool
+	dom    [32]bool
+	month  [13]bool
+	dow    [7]bool // 0 = 周日 (7 也视为周日)
+
+	domAny, dowAny bool
+}
+
+// ParseSpec 解析五段 cron，每段支持 * / 单值 / a-b / 逗号列表 / 步长 (*/5, 1-10/2, 5/15 = 5-最大值/15)
+func ParseSpec(expr string) (*Spec, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron %q: want 5 fields, got %d", expr, len(fields))
+	}
+	s := &Spec{domAny: fields[2] == "*", dowAny: fields[4] == "*"}
+	ranges := []struct {
+		set      []bool
+		min, max int
+	}{
+		{s.minute[:], 0, 59},
+		{s.hour[:], 0, 23},
+		{s.dom[:], 1, 31},
+		{s.month[:], 1, 12},
+		{make([]bool, 8), 0, 7},
+	}
+	for i, f := range fields {
+		r := ranges[i]
+		if err := parseField(f, r.set, r.min, r.max); err != nil {
+			return nil, fmt.Errorf("cron %q: %w", expr, err)
+		}
+	}
+	for d, ok := range ranges[4].set {
+		if ok {
+			s.dow[d%7] = true
+		}
+	}
+	return s, nil
+}
+
+func parseField(field string, set []bool, min, max int) error {
+	for _, part := range strings.Split(field, ",") {
+		step, stepped := 1, false
+		if i := strings.Index(part, "/"); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n <= 0 {
+				return fmt.Errorf("bad step %q", part)
+			}
+			step, part, stepped = n, part[:i], true
+		}
+		lo, hi := min, max
+		if part != "*" {
+			var err error
+			if i := strings.Index(part, "-"); i >= 0 {
+				lo, err = strconv.Atoi(part[:i])
+				if err == nil {
+					hi, err = strconv.Atoi(part[i+1:])
+				}
+			} else {
+				lo, err = strconv.Atoi(part)
+				hi = lo
+				if stepped {
+					hi = max // 按 cron 惯例 N/step 从 N 起到最大值
+				}
+			}
+			if err != nil {
+				return fmt.Errorf("bad value %q", part)
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return fmt.Errorf("%q out of range %d-%d", part, min, max)
+		}
+		for v := lo; v <= hi; v += step {
+			set[v] = true
+		}
+	}
+	return nil
+}
+
+// Match t 所在的这一分钟是否命中。日/周同时指定时按 cron 惯例任一命中即可。
+func (s *Spec) Match(t time.Time) bool {
+	if !s.minute[t.Minute()] || !s.hour[t.Hour()] || !s.month[t.Month()] {
+		return false
+	}
+	dom, dow := s.dom[t.Day()], s.dow[t.Weekday()]
+	switch {
+	case s.domAny && s.dowAny:
+		return true
+	case s.domAny:
+		return dow
+	case s.dowAny:
+		return dom
+	}
+	return dom || dow
+}
@@ -0,0 +1,180 @@
+package scheduler
+
+import (
+	"context"
+	"dragon-quant/calendar"
+	"dragon-quant/config"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Job 一条已解析的定时任务
+type Job struct {
+	Name    string
+	Spec    *Spec
+	Task    string
+	WaitLHB bool
+	LHBWait time.Duration
+}
+
+// Scheduler 按 cron 在交易日触发任务。任务串行执行: 上一个任务未结束时到点的任务直接跳过。
+type Scheduler struct {
+	Jobs     []*Job
+	Tasks    map[string]func(ctx context.Context)
+	Calendar *calendar.Calendar
+
+	// 以下可在测试中替换
+	LHBReady func(date string) bool // 当日龙虎榜是否已公布
+	Now      func() time.Time
+	Sleep    func(ctx context.Context, d time.Duration) error
+
+	mu      sync.Mutex
+	running string               // 正在运行的任务名 ("" = 空闲)
+	fired   map[string]time.Time // 每个任务最后一次触发的分钟，防止同一分钟重复触发
+	wg      sync.WaitGroup
+}
+
+// New 解析配置中的任务；tasks 为任务名到执行函数的映射，引用未知任务名时报错
+func New(cfg config.ScheduleConfig, tasks map[string]func(ctx context.Context)) (*Scheduler, error) {
+	s := &Scheduler{
+		Tasks:    tasks,
+		Calendar: calendar.Default(),
+		Now:      calendar.Now,
+		Sleep:    calendar.Sleep,
+		fired:    make(map[string]time.Time),
+	}
+	for i, j := range cfg.Jobs {
+		spec, err := ParseSpec(j.Cron)
+		if err != nil {
+			return nil, fmt.Errorf("schedule job %d: %w", i, err)
+		}
+		if _, ok := tasks[j.Task]; !ok {
+			return nil, fmt.Errorf("schedule job %d: unknown task %q (want %s)", i, j.Task, strings.Join(taskNames(tasks), " / "))
+		}
+		name := j.Name
+		if name == "" {
+			name = j.Task + "@" + j.Cron
+		}
+		wait := time.Duration(j.LHBWaitMinutes) * time.Minute
+		if wait <= 0 {
+			wait = 180 * time.Minute
+		}
+		s.Jobs = append(s.Jobs, &Job{Name: name, Spec: spec, Task: j.Task, WaitLHB: j.WaitLHB, LHBWait: wait})
+	}
+	return s, nil
+}
+
+func taskNames(tasks map[string]func(ctx context.Context)) []string {
+	names := make([]string, 0, len(tasks))
+	for n := range tasks {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Run 常驻运行，每分钟检查一次到点任务。ctx 取消后不再触发新任务，等正在运行的任务收尾后返回
+func (s *Scheduler) Run(ctx context.Context) {
+	fmt.Printf("⏰ [Schedule] 已加载 %d 个定时任务\n", len(s.Jobs))
+	for _, j := range s.Jobs {
+		fmt.Printf("   - %s: %s\n", j.Name, j.Task)
+	}
+	for ctx.Err() == nil {
+		now := s.Now()
+		s.Tick(ctx, now)
+		s.Sleep(ctx, now.Truncate(time.Minute).Add(time.Minute).Sub(now))
+	}
+	fmt.Println("⏹️ [Schedule] 收到退出信号，等待运行中的任务结束...")
+	s.Wait()
+}
+
+// Tick 触发 now 这一分钟到点的任务 (异步执行，随 ctx 取消)，返回实际启动的任务名
+func (s *Scheduler) Tick(ctx context.Context, now time.Time) []string {
+	minute := now.Truncate(time.Minute)
+	tradingDay := s.Calendar.IsTradingDay(now)
+
+	var started []string
+	for _, j := range s.Jobs {
+		if !j.Spec.Match(now) {
+			continue
+		}
+		s.mu.Lock()
+		if last, ok := s.fired[j.Name]; ok && last.Equal(minute) {
+			s.mu.Unlock()
+			continue
+		}
+		s.fired[j.Name] = minute
+		busy := s.running
+		if tradingDay && busy == "" {
+			s.running = j.Name
+		}
+		s.mu.Unlock()
+
+		switch {
+		case !tradingDay:
+			reason := s.Calendar.HolidayName(now)
+			if reason == "" {
+				reason = "周末"
+			}
+			fmt.Printf("💤 [Schedule] %s 休市 (%s)，跳过 %s\n", now.Format("2006-01-02"), reason, j.Name)
+		case busy != "":
+			fmt.Printf("⚠️ [Schedule] %s 仍在运行，跳过 %s\n", busy, j.Name)
+		default:
+			started = append(started, j.Name)
+			s.wg.Add(1)
+			go s.run(ctx, j, now)
+		}
+	}
+	return started
+}
+
+// Wait 等待正在运行的任务结束
+func (s *Scheduler) Wait() {
+	s.wg.Wait()
+}
+
+func (s *Scheduler) run(ctx context.Context, j *Job, now time.Time) {
+	defer s.wg.Done()
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Printf("❌ [Schedule] %s 异常退出: %v\n", j.Name, r)
+		}
+		s.mu.Lock()
+		s.running = ""
+		s.mu.Unlock()
+	}()
+
+	if j.WaitLHB && !s.waitLHB(ctx, j, now) {
+		return
+	}
+	fmt.Printf("🚀 [Schedule] %s 开始执行 %s\n", s.Now().Format("15:04:05"), j.Name)
+	start := s.Now()
+	s.Tasks[j.Task](ctx)
+	fmt.Printf("✅ [Schedule] %s 完成，耗时 %s\n", j.Name, s.Now().Sub(start).Round(time.Second))
+}
+
+// waitLHB 每 10 分钟检查一次当日龙虎榜，超过 LHBWait 仍未公布 (或 ctx 取消) 则放弃本次运行
+func (s *Scheduler) waitLHB(ctx context.Context, j *Job, now time.Time) bool {
+	if s.LHBReady == nil {
+		return true
+	}
+	date := now.Format("2006-01-02")
+	deadline := now.Add(j.LHBWait)
+	for {
+		if s.LHBReady(date) {
+			return true
+		}
+		t := s.Now()
+		if !t.Before(deadline) {
+			fmt.Printf("⚠️ [Schedule] %s 龙虎榜等待超时，放弃 %s\n", date, j.Name)
+			return false
+		}
+		fmt.Printf("⏳ [Schedule] %s 龙虎榜尚未公布，10 分钟后重试...\n", date)
+		if s.Sleep(ctx, min(10*time.Minute, deadline.Sub(t))) != nil {
+			return false
+		}
+	}
+}
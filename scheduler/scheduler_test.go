@@ -0,0 +1,162 @@
+package scheduler
+
+import (
+	"context"
+	"dragon-quant/calendar"
+	"dragon-quant/config"
+	"sync"
+	"testing"
+	"time"
+)
+
+func at(s string) time.Time {
+	t, err := time.Parse("2006-01-02 15:04", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestParseSpec(t *testing.T) {
+	cases := []struct {
+		expr string
+		t    string
+		want bool
+	}{
+		{"26 9 * * *", "2026-03-02 09:26", true},
+		{"26 9 * * *", "2026-03-02 09:27", false},
+		{"*/20 14 * * 1-5", "2026-03-02 14:40", true},
+		{"*/20 14 * * 1-5", "2026-03-07 14:40", false}, // 周六
+		{"0 17,18 * * 0,7", "2026-03-08 18:00", true},  // 周日
+		{"0 9 15 * 1", "2026-03-15 09:00", true},       // 日/周任一命中
+		{"0 9 15 * 1", "2026-03-02 09:00", true},
+		{"0 9 15 * 1", "2026-03-03 09:00", false},
+		{"5/15 10 * * *", "2026-03-02 10:50", true}, // 5,20,35,50
+		{"5/15 10 * * *", "2026-03-02 10:05", true},
+		{"5/15 10 * * *", "2026-03-02 10:15", false},
+	}
+	for _, c := range cases {
+		spec, err := ParseSpec(c.expr)
+		if err != nil {
+			t.Fatalf("%s: %v", c.expr, err)
+		}
+		if got := spec.Match(at(c.t)); got != c.want {
+			t.Errorf("%s @ %s = %v, want %v", c.expr, c.t, got, c.want)
+		}
+	}
+
+	for _, bad := range []string{"26 9 * *", "60 9 * * *", "5-1 9 * * *", "*/0 9 * * *", "a 9 * * *"} {
+		if _, err := ParseSpec(bad); err == nil {
+			t.Errorf("%q should fail", bad)
+		}
+	}
+}
+
+func TestTickSkipsHolidaysAndOverlap(t *testing.T) {
+	release := make(chan struct{})
+	var mu sync.Mutex
+	runs := map[string]int{}
+	task := func(name string, block bool) func(context.Context) {
+		return func(context.Context) {
+			if block {
+				<-release
+			}
+			mu.Lock()
+			runs[name]++
+			mu.Unlock()
+		}
+	}
+	s, err := New(config.ScheduleConfig{Jobs: []config.ScheduleJob{
+		{Name: "竞价后扫描", Cron: "26 9 * * *", Task: "scan"},
+		{Name: "尾盘持仓", Cron: "40 14 * * *", Task: "hold-kline"},
+	}}, map[string]func(context.Context){"scan": task("scan", true), "hold-kline": task("hold-kline", false)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 国庆休市 / 周末不触发
+	if got := s.Tick(context.Background(), at("2026-10-02 09:26")); len(got) != 0 {
+		t.Errorf("holiday started %v", got)
+	}
+	if got := s.Tick(context.Background(), at("2026-03-07 09:26")); len(got) != 0 {
+		t.Errorf("weekend started %v", got)
+	}
+
+	// 交易日触发；同一分钟重复 Tick 不重复触发
+	if got := s.Tick(context.Background(), at("2026-03-02 09:26")); len(got) != 1 || got[0] != "竞价后扫描" {
+		t.Fatalf("started %v", got)
+	}
+	if got := s.Tick(context.Background(), at("2026-03-02 09:26")); len(got) != 0 {
+		t.Errorf("same minute fired twice: %v", got)
+	}
+	// 扫描未结束，14:40 的任务跳过
+	if got := s.Tick(context.Background(), at("2026-03-02 14:40")); len(got) != 0 {
+		t.Errorf("overlapping run started %v", got)
+	}
+	close(release)
+	s.Wait()
+
+	if got := s.Tick(context.Background(), at("2026-03-03 14:40")); len(got) != 1 {
+		t.Errorf("started %v after previous run finished", got)
+	}
+	s.Wait()
+	if runs["scan"] != 1 || runs["hold-kline"] != 1 {
+		t.Errorf("runs = %v", runs)
+	}
+
+	if _, err := New(config.ScheduleConfig{Jobs: []config.ScheduleJob{{Cron: "0 9 * * *", Task: "nope"}}}, nil); err == nil {
+		t.Error("unknown task should fail")
+	}
+}
+
+func TestWaitLHB(t *testing.T) {
+	var mu sync.Mutex
+	now := at("2026-03-02 17:00")
+	clock := func() time.Time { mu.Lock(); defer mu.Unlock(); return now }
+	sleep := func(_ context.Context, d time.Duration) error { mu.Lock(); now = now.Add(d); mu.Unlock(); return nil }
+
+	ran := 0
+	newScheduler := func(wait int, readyAt string) *Scheduler {
+		s, err := New(config.ScheduleConfig{Jobs: []config.ScheduleJob{
+			{Name: "龙虎榜复盘", Cron: "0 17 * * *", Task: "scan", WaitLHB: true, LHBWaitMinutes: wait},
+		}}, map[string]func(context.Context){"scan": func(context.Context) { ran++ }})
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.Now, s.Sleep = clock, sleep
+		s.LHBReady = func(date string) bool {
+			if date != readyAt[:10] {
+				t.Errorf("date %s", date)
+			}
+			return !clock().Before(at(readyAt))
+		}
+		return s
+	}
+
+	s := newScheduler(60, "2026-03-02 17:25")
+	s.Tick(context.Background(), now)
+	s.Wait()
+	if ran != 1 || !clock().Equal(at("2026-03-02 17:30")) {
+		t.Errorf("ran=%d at %s, want 1 at 17:30", ran, clock().Format("15:04"))
+	}
+
+	now = at("2026-03-03 17:00")
+	s = newScheduler(30, "2026-03-03 20:00")
+	s.Tick(context.Background(), now)
+	s.Wait()
+	if ran != 1 {
+		t.Error("should give up after lhb_wait_minutes")
+	}
+
+	// 退出信号: 不再等待龙虎榜
+	now = at("2026-03-04 17:00")
+	s = newScheduler(60, "2026-03-04 17:25")
+	s.Sleep = calendar.Sleep
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	s.Tick(ctx, now)
+	s.Wait()
+	if ran != 1 {
+		t.Error("cancelled run should not start the task")
+	}
+}
@@ -0,0 +1,118 @@
+package warehouse
+
+import (
+	"context"
+	"dragon-quant/data_processor"
+	"dragon-quant/model"
+	"fmt"
+	"time"
+)
+
+// --- 复权因子与除权除息事件 ---
+// bars_<tf> 只存不复权价；adj_factors 存后复权因子的变化点 (除权日) 和最近核对日，
+// 读取时由 data_processor.AdjustBars 换算为前复权 / 后复权。
+
+// migrateRawBars 旧版仓库的K线是前复权价 (日K与分钟K线都按 fqt=1 拉取)，与不复权价混存会被重复复权:
+// 首次升级时清空全部周期，由下次读取 / -sync 重新拉取。只保留按不复权口径导入的区间 (import_meta.adjust = none)
+func (w *Warehouse) migrateRawBars() error {
+	var v string
+	if w.Duck.DB.QueryRow("SELECT value FROM meta WHERE key = 'bars_adjust'").Scan(&v) == nil {
+		return nil
+	}
+	for _, tf := range Timeframes {
+		res, err := w.Duck.DB.Exec(fmt.Sprintf(`
+			DELETE FROM %s WHERE NOT EXISTS (
+				SELECT 1 FROM import_meta m
+				WHERE m.code = %[1]s.code AND m.timeframe = ? AND m.adjust = ?
+				  AND %[1]s.time BETWEEN m.first_time AND m.last_time)`, tf.Table()),
+			string(tf), string(model.AdjustNone))
+		if err != nil {
+			return fmt.Errorf("migrate %s failed: %w", tf.Table(), err)
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			fmt.Printf("♻️ [Warehouse] 仓库改为存储不复权K线，已清空 %d 根旧的前复权%sK线 (将自动重新拉取)\n", n, tf)
+		}
+	}
+	if _, err := w.Duck.DB.Exec("DELETE FROM import_meta WHERE adjust <> ?", string(model.AdjustNone)); err != nil {
+		return err
+	}
+	_, err := w.Duck.DB.Exec("INSERT OR REPLACE INTO meta VALUES ('bars_adjust', ?)", string(model.AdjustNone))
+	return err
+}
+
+// SaveAdjFactors 写入复权因子: 覆盖 factors 第一天及之后的记录 (因子由收盘价反推，有舍入误差，不与旧记录混排)
+func (w *Warehouse) SaveAdjFactors(code string, factors []model.AdjFactor) error {
+	if w == nil || len(factors) == 0 {
+		return nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	tx, err := w.Duck.DB.Begin()
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM adj_factors WHERE code = ? AND date >= CAST(? AS DATE)", code, factors[0].Date); err != nil {
+		tx.Rollback()
+		return err
+	}
+	for _, f := range factors {
+		if _, err := tx.Exec("INSERT INTO adj_factors VALUES (?, CAST(? AS DATE), ?)", code, f.Date, f.Factor); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// AdjFactors 仓库中某代码的复权因子 (按日期升序)
+func (w *Warehouse) AdjFactors(code string) []model.AdjFactor {
+	if w == nil {
+		return nil
+	}
+	rows, err := w.Duck.DB.Query("SELECT date, factor FROM adj_factors WHERE code = ? ORDER BY date", code)
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+	var factors []model.AdjFactor
+	for rows.Next() {
+		var d time.Time
+		var f model.AdjFactor
+		if rows.Scan(&d, &f.Factor) == nil {
+			f.Date = d.Format("2006-01-02")
+			factors = append(factors, f)
+		}
+	}
+	return factors
+}
+
+// ExRights 仓库中某代码的除权除息事件
+func (w *Warehouse) ExRights(code string) []model.ExRight {
+	return data_processor.ExRights(w.AdjFactors(code))
+}
+
+// refreshFactors 拉取最近 days 个交易日的因子并入库，返回入库后的全部因子。拉取失败时返回已有因子
+func (w *Warehouse) refreshFactors(ctx context.Context, code string, days int) []model.AdjFactor {
+	if w.src.factors != nil {
+		if factors, err := w.src.factors(ctx, code, days); err == nil {
+			if err := w.SaveAdjFactors(code, factors); err != nil {
+				fmt.Printf("⚠️ [Warehouse] %s 复权因子写入失败: %v\n", code, err)
+			}
+		}
+	}
+	return w.AdjFactors(code)
+}
+
+// factorsFor bars 对应的复权因子。最近核对日早于最后一根K线 (可能有新的除权) 时重新拉取
+func (w *Warehouse) factorsFor(ctx context.Context, code string, bars []model.KLineData, days int) []model.AdjFactor {
+	if len(bars) == 0 {
+		return nil
+	}
+	factors := w.AdjFactors(code)
+	last := bars[len(bars)-1].Date[:10]
+	if len(factors) > 0 && factors[len(factors)-1].Date >= last {
+		return factors
+	}
+	return w.refreshFactors(ctx, code, days)
+}
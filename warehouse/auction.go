@@ -0,0 +1,91 @@
+package warehouse
+
+import (
+	"dragon-quant/data_processor"
+	"dragon-quant/model"
+	"fmt"
+	"time"
+)
+
+// SaveAuctionTicks 记录集合竞价快照 (同 code+time 覆盖)
+func (w *Warehouse) SaveAuctionTicks(ticks []model.AuctionTick) error {
+	if w == nil || len(ticks) == 0 {
+		return nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	tx, err := w.Duck.DB.Begin()
+	if err != nil {
+		return err
+	}
+	for _, t := range ticks {
+		_, err := tx.Exec("INSERT OR REPLACE INTO auction_ticks VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+			t.Code, wallClock(t.Time), t.Price, t.Volume, t.Amount, t.UnmatchedBuy, t.UnmatchedSell, t.PrevClose)
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("save auction ticks failed: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// wallClock 以墙上时间存储 (不随时区换算)，与 K线表一致
+func wallClock(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.UTC)
+}
+
+// AuctionTicks 返回某代码某交易日 (2006-01-02) 的集合竞价快照 (时间升序)
+func (w *Warehouse) AuctionTicks(code, date string) []model.AuctionTick {
+	if w == nil {
+		return nil
+	}
+	rows, err := w.Duck.DB.Query(`
+		SELECT time, price, volume, amount, unmatched_buy, unmatched_sell, prev_close
+		FROM auction_ticks WHERE code = ? AND CAST(time AS DATE) = CAST(? AS DATE)
+		ORDER BY time`, code, date)
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+
+	var ticks []model.AuctionTick
+	for rows.Next() {
+		t := model.AuctionTick{Code: code}
+		var ts time.Time
+		if rows.Scan(&ts, &t.Price, &t.Volume, &t.Amount, &t.UnmatchedBuy, &t.UnmatchedSell, &t.PrevClose) == nil {
+			t.Time = ts
+			ticks = append(ticks, t)
+		}
+	}
+	return ticks
+}
+
+// AuctionFeatures 当日已采集竞价时序的特征；没有采集数据时 ok=false
+func (w *Warehouse) AuctionFeatures(code, date string) (model.AuctionFeatures, bool) {
+	ticks := w.AuctionTicks(code, date)
+	if len(ticks) == 0 {
+		return model.AuctionFeatures{}, false
+	}
+	return data_processor.AnalyzeAuction(ticks), true
+}
+
+// RecentSnapshotCodes 返回最近一次扫描入库快照的代码 (上一轮深度分析过的候选股)
+func (w *Warehouse) RecentSnapshotCodes() []string {
+	if w == nil {
+		return nil
+	}
+	rows, err := w.Duck.DB.Query("SELECT code FROM snapshots WHERE time = (SELECT MAX(time) FROM snapshots) ORDER BY code")
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+	var codes []string
+	for rows.Next() {
+		var c string
+		if rows.Scan(&c) == nil {
+			codes = append(codes, c)
+		}
+	}
+	return codes
+}
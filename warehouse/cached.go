@@ -0,0 +1,210 @@
+package warehouse
+
+import (
+	"context"
+	"dragon-quant/calendar"
+	"dragon-quant/data_processor"
+	"dragon-quant/fetcher"
+	"dragon-quant/model"
+	"fmt"
+	"math"
+	"time"
+)
+
+// --- 增量拉取: 先查仓库，只下载缺失区间，再统一从仓库读 ---
+// w 为 nil 时直接调用 fetcher (不落盘)，保证未配置仓库时行为不变。
+// 拉取失败时仍返回仓库里已有的K线；只有仓库里也没有数据时才返回拉取错误。
+
+// Daily 前复权日K (最近 limit 根，用于均线 / MACD 等指标)
+func (w *Warehouse) Daily(ctx context.Context, code string, limit int) ([]model.KLineData, error) {
+	if w == nil {
+		return fetcher.Sources().Daily(ctx, code, limit, model.AdjustForward)
+	}
+	if stored := w.StoredAdjust(code, TFDaily); stored != model.AdjustNone {
+		return w.importedBars(code, TFDaily, limit, stored)
+	}
+	raw, factors, err := w.DailyRaw(ctx, code, limit)
+	return data_processor.AdjustBars(raw, factors, model.AdjustForward), err
+}
+
+// DailyRaw 不复权日K与对应的复权因子 (涨跌停按不复权价计算，指标可用 data_processor.AdjustBars 换算)。
+// 未配置仓库时同时拉取不复权与后复权日K求因子
+func (w *Warehouse) DailyRaw(ctx context.Context, code string, limit int) ([]model.KLineData, []model.AdjFactor, error) {
+	if w == nil {
+		raw, err := fetcher.Sources().Daily(ctx, code, limit, model.AdjustNone)
+		if err != nil {
+			return nil, nil, err
+		}
+		factors, _ := fetcher.FetchAdjFactors(ctx, code, limit) // 失败时按无除权处理，已记入数据质量报告
+		return raw, factors, nil
+	}
+	if stored := w.StoredAdjust(code, TFDaily); stored != model.AdjustNone {
+		return nil, nil, fmt.Errorf("%s 日K为导入的 %s 数据，没有不复权价", code, stored)
+	}
+	fetchErr := w.refresh(ctx, code, TFDaily, limit, 1, w.src.daily)
+	bars, err := w.Bars(code, TFDaily, limit)
+	if err != nil {
+		fmt.Printf("⚠️ [Warehouse] %s 日K读取失败: %v\n", code, err)
+	}
+	return bars, w.factorsFor(ctx, code, bars, limit), orStale(bars, fetchErr)
+}
+
+// Min30 前复权30分钟K (最近 limit 根)
+func (w *Warehouse) Min30(ctx context.Context, code string, limit int) ([]model.KLineData, error) {
+	if w == nil {
+		return fetcher.Sources().Min30(ctx, code, limit, model.AdjustForward)
+	}
+	if stored := w.StoredAdjust(code, TF30m); stored != model.AdjustNone {
+		return w.importedBars(code, TF30m, limit, stored)
+	}
+	fetchErr := w.refresh(ctx, code, TF30m, limit, TF30m.barsPerDay(), w.src.min30)
+	bars, err := w.Bars(code, TF30m, limit)
+	if err != nil {
+		fmt.Printf("⚠️ [Warehouse] %s 30m读取失败: %v\n", code, err)
+	}
+	factors := w.factorsFor(ctx, code, bars, limit/TF30m.barsPerDay()+1)
+	return data_processor.AdjustBars(bars, factors, model.AdjustForward), orStale(bars, fetchErr)
+}
+
+// importedBars 导入的复权K线: 不再增量拉取 (实时拉取的是不复权价，混存会错位)，也不再按因子换算一次。
+// 前复权原样返回；后复权价位与实时行情不一致，除以最新复权因子 (adj_factors) 折回前复权，没有因子时报错
+func (w *Warehouse) importedBars(code string, tf Timeframe, limit int, stored model.Adjust) ([]model.KLineData, error) {
+	bars, err := w.Bars(code, tf, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s读取失败: %w", code, tf, err)
+	}
+	if len(bars) == 0 {
+		return nil, fmt.Errorf("%s 没有导入的 %s %s K线", code, stored, tf)
+	}
+	if stored != model.AdjustBackward {
+		return bars, nil
+	}
+	factors := w.AdjFactors(code)
+	if len(factors) == 0 {
+		return nil, fmt.Errorf("%s %s 为导入的后复权K线，adj_factors 中没有复权因子，无法换算为前复权", code, tf)
+	}
+	base := factors[len(factors)-1].Factor
+	for i := range bars {
+		k := &bars[i]
+		k.Open, k.High, k.Low, k.Close = roundPrice(k.Open/base), roundPrice(k.High/base), roundPrice(k.Low/base), roundPrice(k.Close/base)
+		k.Change = 0
+		if i > 0 {
+			k.Change = roundPrice(k.Close - bars[i-1].Close)
+		}
+	}
+	return bars, nil
+}
+
+// roundPrice 换算后的价格保留 3 位小数 (与 data_processor.AdjustBars 一致)
+func roundPrice(v float64) float64 {
+	return math.Round(v*1000) / 1000
+}
+
+// Min5 5分钟K (只含最近若干根成交额，用于开盘承接率)。当日盘中数据总是实时拉取，拉到后入库。
+func (w *Warehouse) Min5(ctx context.Context, code string) ([]model.KLineData, error) {
+	bars, err := fetcher.Fetch5MinKline(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	if w != nil {
+		if _, err := w.UpsertBars(code, TF5m, bars); err != nil {
+			fmt.Printf("⚠️ [Warehouse] %s 5m写入失败: %v\n", code, err)
+		}
+	}
+	return bars, nil
+}
+
+// Min1 最近 days 个交易日的 1m K线。已收盘且完整入库的交易日不再下载，盘中的今天总是刷新。
+func (w *Warehouse) Min1(ctx context.Context, code string, days int) ([]model.KLineData, error) {
+	if w == nil {
+		return fetcher.Fetch1MinKline(ctx, code, days)
+	}
+
+	cal := calendar.Default()
+	now := calendar.Now()
+	tradingDays := cal.RecentTradingDays(now, days)
+	if len(tradingDays) == 0 {
+		return nil, nil
+	}
+	if stored := w.StoredAdjust(code, TF1m); stored != model.AdjustNone {
+		return w.BarsSince(code, TF1m, tradingDays[0]) // 导入的复权K线，不再增量拉取
+	}
+	dates := calendar.Dates(tradingDays)
+
+	var fetchErr error
+	counts := w.DayBarCounts(code, TF1m, dates)
+	for i, d := range dates {
+		if ctx.Err() != nil {
+			break
+		}
+		if cal.SessionComplete(tradingDays[i], now) && counts[d] >= TF1m.barsPerDay() {
+			continue
+		}
+		bars, err := w.src.min1Day(ctx, code, d)
+		if err != nil {
+			fetchErr = err
+			continue
+		}
+		if _, err := w.UpsertBars(code, TF1m, bars); err != nil {
+			fmt.Printf("⚠️ [Warehouse] %s 1m写入失败 (%s): %v\n", code, d, err)
+		}
+	}
+
+	bars, err := w.BarsSince(code, TF1m, tradingDays[0])
+	if err != nil {
+		fmt.Printf("⚠️ [Warehouse] %s 1m读取失败: %v\n", code, err)
+	}
+	return bars, orStale(bars, fetchErr)
+}
+
+// 🆕 Min1Window 返回 Min1(days) 读取窗口的起点: 首个请求交易日 0 点 (墙上时间，与仓库存储一致)。
+// 直接查询 bars_1m 的分析 (NewKlineProcessorForCode) 用它保持与 -days 相同的范围。
+func Min1Window(days int) time.Time {
+	tradingDays := calendar.Default().RecentTradingDays(calendar.Now(), days)
+	if len(tradingDays) == 0 {
+		return time.Time{}
+	}
+	d := tradingDays[0]
+	return time.Date(d.Year(), d.Month(), d.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// SectorStocks 板块成分股 (实时行情，每次都拉)，同时记录成分关系
+func (w *Warehouse) SectorStocks(ctx context.Context, sector model.SectorInfo) ([]model.StockInfo, error) {
+	if w == nil {
+		return fetcher.FetchSectorStocks(ctx, sector.Code)
+	}
+	stocks, err := w.src.sector(ctx, sector.Code)
+	if err != nil {
+		return nil, err
+	}
+	if err := w.SaveSectorMembers(sector, stocks); err != nil {
+		fmt.Printf("⚠️ [Warehouse] %s 成分股写入失败: %v\n", sector.Name, err)
+	}
+	return stocks, nil
+}
+
+// refresh 按交易日历估算自最后一根K线以来缺失的根数，只拉这一段 (含最后一个交易日整日，用于更新盘中数据)。
+// 返回拉取错误 (写入错误只打印)
+func (w *Warehouse) refresh(ctx context.Context, code string, tf Timeframe, limit, perDay int, fetch func(context.Context, string, int) ([]model.KLineData, error)) error {
+	need := limit
+	if last, ok := w.LastBarTime(code, tf); ok && w.CountBars(code, tf) >= limit {
+		missing := calendar.Default().TradingDaysBetween(last, calendar.Now())
+		need = min(limit, (missing+1)*perDay)
+	}
+	bars, err := fetch(ctx, code, need)
+	if err != nil {
+		return err
+	}
+	if _, err := w.UpsertBars(code, tf, bars); err != nil {
+		fmt.Printf("⚠️ [Warehouse] %s %s 写入失败: %v\n", code, tf.Table(), err)
+	}
+	return nil
+}
+
+// orStale 仓库里有数据时忽略拉取错误 (数据可能不是最新，但可用)
+func orStale(bars []model.KLineData, fetchErr error) error {
+	if len(bars) > 0 {
+		return nil
+	}
+	return fetchErr
+}
@@ -0,0 +1,504 @@
+package warehouse
+
+import (
+	"context"
+	"database/sql"
+	"dragon-quant/calendar"
+	"dragon-quant/data_processor"
+	"dragon-quant/fetcher"
+	"dragon-quant/model"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// --- 本地 K 线导入: 其他工具导出的 CSV / Parquet 历史数据 -> bars_<tf> 表 ---
+// 文件由 DuckDB 的 read_csv_auto / read_parquet 读取，表头按别名映射到 OHLCV 字段。
+// 时间从源时区换算为北京时间 (仓库按北京墙上时间存储，与实时拉取的数据一致)，
+// 时区与复权口径记入 import_meta。
+
+// ImportOptions 导入参数，零值字段自动推断
+type ImportOptions struct {
+	Code        string            // 文件没有代码列时使用 (为空则取文件名中的 6 位数字)
+	Timeframe   Timeframe         // 为空时按相邻K线的间隔推断
+	Timezone    string            // 源数据时区 (IANA 名称)，默认 Asia/Shanghai
+	Adjust      model.Adjust      // 源数据的复权口径，默认不复权
+	StartLabel  bool              // 分钟K线按开始时间标注 (东财按结束时间)，导入时后移一个周期
+	Columns     map[string]string // 字段 -> 表头，优先于内置别名。字段: code/time/open/high/low/close/volume/amount
+	VolumeScale float64           // 成交量换算为手的倍数 (源单位为股时填 0.01)，默认 1
+	AmountScale float64           // 成交额换算为元的倍数 (如 tushare 的千元填 1000)，默认 1
+}
+
+// ImportResult 一个文件中某代码某周期的导入结果
+type ImportResult struct {
+	File      string
+	Code      string
+	Timeframe Timeframe
+	Rows      int
+	Skipped   int // 时间或收盘价无法解析的行
+	First     time.Time
+	Last      time.Time
+}
+
+// ImportMeta import_meta 中记录的来源信息
+type ImportMeta struct {
+	Code       string
+	Timeframe  Timeframe
+	Source     string
+	Timezone   string
+	Adjust     model.Adjust
+	Rows       int
+	First      time.Time
+	Last       time.Time
+	ImportedAt time.Time
+}
+
+// importAliases 各字段常见的表头 (不区分大小写)
+var importAliases = map[string][]string{
+	"code":   {"code", "symbol", "ts_code", "ticker", "证券代码", "股票代码", "代码"},
+	"time":   {"datetime", "trade_time", "timestamp", "date", "trade_date", "日期", "time", "时间"},
+	"open":   {"open", "开盘", "开盘价"},
+	"high":   {"high", "最高", "最高价"},
+	"low":    {"low", "最低", "最低价"},
+	"close":  {"close", "收盘", "收盘价"},
+	"volume": {"volume", "vol", "成交量"},
+	"amount": {"amount", "money", "成交额"},
+}
+
+var importFields = []string{"code", "time", "open", "high", "low", "close", "volume", "amount"}
+
+// clockAliases 日期与时间分两列存放时的时间列 (只有时分秒)
+var clockAliases = []string{"time", "时间"}
+
+var sixDigits = regexp.MustCompile(`\d{6}`)
+
+// importRow 解析后的一行
+type importRow struct {
+	t                                      time.Time
+	dateOnly                               bool
+	open, high, low, close, volume, amount float64
+}
+
+// ImportFile 导入一个 CSV / Parquet 文件，按代码、周期写入K线并记录元数据
+func (w *Warehouse) ImportFile(path string, opts ImportOptions) ([]ImportResult, error) {
+	if w == nil {
+		return nil, errors.New("warehouse not configured")
+	}
+	if opts.Timezone == "" {
+		opts.Timezone = "Asia/Shanghai"
+	}
+	loc, err := time.LoadLocation(opts.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("timezone %q: %w", opts.Timezone, err)
+	}
+	if opts.Adjust, err = model.ParseAdjust(string(opts.Adjust)); err != nil {
+		return nil, err
+	}
+	if opts.Timeframe != "" && !slices.Contains(Timeframes, opts.Timeframe) {
+		return nil, fmt.Errorf("timeframe %q: want 1d / 30m / 5m / 1m", opts.Timeframe)
+	}
+	if opts.VolumeScale == 0 {
+		opts.VolumeScale = 1
+	}
+	if opts.AmountScale == 0 {
+		opts.AmountScale = 1
+	}
+
+	reader, err := importReader(path)
+	if err != nil {
+		return nil, err
+	}
+	cols, err := w.importColumns(reader)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	mapping := mapImportColumns(cols, opts.Columns)
+	if mapping["time"] == "" || mapping["close"] == "" {
+		return nil, fmt.Errorf("%s: no time/close column in %v", path, cols)
+	}
+	defaultCode := opts.Code
+	if defaultCode == "" {
+		defaultCode = sixDigits.FindString(filepath.Base(path))
+	}
+	if mapping["code"] == "" && defaultCode == "" {
+		return nil, fmt.Errorf("%s: no code column; set the code option or put it in the file name", path)
+	}
+
+	// 全部按字符串 / DOUBLE 取出，时间在 Go 里按时区解析
+	selects := make([]string, len(importFields))
+	for i, f := range importFields {
+		col := mapping[f]
+		switch {
+		case col == "":
+			selects[i] = "NULL"
+		case f == "code" || f == "time":
+			selects[i] = fmt.Sprintf("CAST(%s AS VARCHAR)", quoteIdent(col))
+		default:
+			selects[i] = fmt.Sprintf("TRY_CAST(%s AS DOUBLE)", quoteIdent(col))
+		}
+	}
+	// 日期、时间分列 (date + time): 两列拼成完整时间
+	clock := "NULL"
+	if _, ok := opts.Columns["time"]; !ok {
+		if col := findColumn(cols, clockAliases); col != "" && col != mapping["time"] {
+			clock = fmt.Sprintf("CAST(%s AS VARCHAR)", quoteIdent(col))
+		}
+	}
+	selects = append(selects, clock)
+	rows, err := w.Duck.DB.Query(fmt.Sprintf("SELECT %s FROM %s", strings.Join(selects, ", "), reader))
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	defer rows.Close()
+
+	byCode := make(map[string][]importRow)
+	skipped := make(map[string]int)
+	for rows.Next() {
+		var code, ts, clk sql.NullString
+		var nums [6]sql.NullFloat64
+		if err := rows.Scan(&code, &ts, &nums[0], &nums[1], &nums[2], &nums[3], &nums[4], &nums[5], &clk); err != nil {
+			return nil, err
+		}
+		if clk.Valid {
+			ts.String = joinDateClock(ts.String, clk.String)
+		}
+		c := defaultCode
+		if code.Valid {
+			c = sixDigits.FindString(code.String)
+		}
+		if c == "" {
+			continue
+		}
+		t, dateOnly, err := parseImportTime(ts.String, loc)
+		if err != nil || !nums[3].Valid {
+			skipped[c]++
+			continue
+		}
+		byCode[c] = append(byCode[c], importRow{
+			t: t, dateOnly: dateOnly,
+			open: nums[0].Float64, high: nums[1].Float64, low: nums[2].Float64, close: nums[3].Float64,
+			volume: nums[4].Float64 * opts.VolumeScale, amount: nums[5].Float64 * opts.AmountScale,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, 0, len(byCode))
+	for c := range byCode {
+		codes = append(codes, c)
+	}
+	sort.Strings(codes)
+
+	var results []ImportResult
+	for _, code := range codes {
+		data := byCode[code]
+		sort.Slice(data, func(i, j int) bool { return data[i].t.Before(data[j].t) })
+		tf := opts.Timeframe
+		if tf == "" {
+			if tf, err = inferTimeframe(data); err != nil {
+				return results, fmt.Errorf("%s %s: %w", path, code, err)
+			}
+		}
+		shift := time.Duration(0)
+		if opts.StartLabel && tf != TFDaily {
+			shift = tf.duration()
+		}
+
+		bars := make([]model.KLineData, len(data))
+		for i, r := range data {
+			bars[i] = model.KLineData{
+				Date: r.t.Add(shift).Format(tf.layout()),
+				Open: r.open, High: r.high, Low: r.low, Close: r.close,
+				Volume: r.volume, Amount: r.amount,
+			}
+		}
+		// 同一张表只能有一种口径: 复权K线不能混进实时同步的不复权K线 (读取时会被再复权一次)，反之亦然
+		if stored := w.StoredAdjust(code, tf); stored != opts.Adjust && w.CountBars(code, tf) > 0 {
+			return results, fmt.Errorf("%s %s: 仓库中已有 %s 口径的 %s K线，不能再导入 %s 口径；复权数据请导入单独的 warehouse.path",
+				path, code, stored, tf, opts.Adjust)
+		}
+		n, err := w.upsertBars(code, tf, bars)
+		if err != nil {
+			return results, err
+		}
+		res := ImportResult{
+			File: path, Code: code, Timeframe: tf, Rows: n, Skipped: skipped[code],
+			First: data[0].t.Add(shift), Last: data[len(data)-1].t.Add(shift),
+		}
+		if err := w.saveImportMeta(res, opts); err != nil {
+			return results, err
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+// importReader 按扩展名选择 DuckDB 的读取函数
+func importReader(path string) (string, error) {
+	quoted := "'" + strings.ReplaceAll(path, "'", "''") + "'"
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv", ".txt", ".tsv":
+		return fmt.Sprintf("read_csv_auto(%s, all_varchar = true)", quoted), nil
+	case ".parquet", ".pq":
+		return fmt.Sprintf("read_parquet(%s)", quoted), nil
+	}
+	return "", fmt.Errorf("%s: unsupported file type (want .csv or .parquet)", path)
+}
+
+func (w *Warehouse) importColumns(reader string) ([]string, error) {
+	rows, err := w.Duck.DB.Query(fmt.Sprintf("SELECT * FROM %s LIMIT 0", reader))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return rows.Columns()
+}
+
+// mapImportColumns 字段 -> 实际表头: 先看 override，再按别名顺序匹配 (不区分大小写)
+func mapImportColumns(cols []string, override map[string]string) map[string]string {
+	mapping := make(map[string]string)
+	for _, f := range importFields {
+		candidates := importAliases[f]
+		if c, ok := override[f]; ok {
+			candidates = []string{c}
+		}
+		if col := findColumn(cols, candidates); col != "" {
+			mapping[f] = col
+		}
+	}
+	return mapping
+}
+
+// findColumn 按别名顺序找第一个存在的表头 (不区分大小写)
+func findColumn(cols, aliases []string) string {
+	for _, alias := range aliases {
+		for _, col := range cols {
+			if strings.EqualFold(strings.TrimSpace(col), alias) {
+				return col
+			}
+		}
+	}
+	return ""
+}
+
+func quoteIdent(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+// importLayouts 支持的时间格式 (带时区偏移的按偏移解析，其余按源时区解析)
+var importLayouts = []string{
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04:05",
+	"2006/01/02 15:04:05",
+	"2006/01/02 15:04",
+	"20060102150405",
+	"200601021504",
+}
+
+var importZonedLayouts = []string{time.RFC3339, "2006-01-02 15:04:05-07", "2006-01-02 15:04:05-07:00"}
+
+var importDateLayouts = []string{"2006-01-02", "2006/01/02", "20060102"}
+
+// parseImportTime 解析时间并换算为北京时间墙上时间。纯日期不做时区换算 (交易日就是交易日)；
+// 10 / 13 位纯数字视为 Unix 秒 / 毫秒
+func parseImportTime(s string, loc *time.Location) (t time.Time, dateOnly bool, err error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range importDateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true, nil
+		}
+	}
+	beijing := calendar.Now().Location()
+	wall := func(t time.Time) time.Time {
+		b := t.In(beijing)
+		return time.Date(b.Year(), b.Month(), b.Day(), b.Hour(), b.Minute(), b.Second(), 0, time.UTC)
+	}
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil && (len(s) == 10 || len(s) == 13) {
+		if len(s) == 13 {
+			return wall(time.UnixMilli(n)), false, nil
+		}
+		return wall(time.Unix(n, 0)), false, nil
+	}
+	for _, layout := range importZonedLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return wall(t), false, nil
+		}
+	}
+	for _, layout := range importLayouts {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return wall(t), false, nil
+		}
+	}
+	return time.Time{}, false, fmt.Errorf("unrecognized time %q", s)
+}
+
+// joinDateClock 拼接分列的日期与时间: 20260105 + 0931 / 09:31 / 93100 -> 2026-01-05 09:31:00。
+// 日期列本身带时分或无法识别时原样返回，交给 parseImportTime
+func joinDateClock(date, clock string) string {
+	date, clock = strings.TrimSpace(date), strings.TrimSpace(clock)
+	var d time.Time
+	var err error
+	for _, layout := range importDateLayouts {
+		if d, err = time.Parse(layout, date); err == nil {
+			break
+		}
+	}
+	if err != nil || clock == "" {
+		return date
+	}
+	layouts := []string{"15:04:05", "15:04"}
+	if _, err := strconv.Atoi(clock); err == nil {
+		switch {
+		case len(clock) <= 4:
+			clock, layouts = strings.Repeat("0", 4-len(clock))+clock, []string{"1504"}
+		case len(clock) <= 6:
+			clock, layouts = strings.Repeat("0", 6-len(clock))+clock, []string{"150405"}
+		}
+	}
+	for _, layout := range layouts {
+		if c, err := time.Parse(layout, clock); err == nil {
+			return d.Format("2006-01-02") + " " + c.Format("15:04:05")
+		}
+	}
+	return date
+}
+
+// inferTimeframe 全是日期 -> 日K；否则取同一天内相邻K线的最小间隔
+func inferTimeframe(rows []importRow) (Timeframe, error) {
+	gap := time.Duration(0)
+	for i := 1; i < len(rows); i++ {
+		if rows[i].dateOnly || rows[i].t.Hour() == 0 && rows[i].t.Minute() == 0 {
+			continue
+		}
+		d := rows[i].t.Sub(rows[i-1].t)
+		if d > 0 && rows[i].t.YearDay() == rows[i-1].t.YearDay() && (gap == 0 || d < gap) {
+			gap = d
+		}
+	}
+	if gap == 0 {
+		return TFDaily, nil
+	}
+	for _, tf := range []Timeframe{TF1m, TF5m, TF30m} {
+		if gap == tf.duration() {
+			return tf, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported bar interval %s (want 1m / 5m / 30m / 1d)", gap)
+}
+
+// duration 分钟K线的周期长度
+func (tf Timeframe) duration() time.Duration {
+	switch tf {
+	case TF1m:
+		return time.Minute
+	case TF5m:
+		return 5 * time.Minute
+	case TF30m:
+		return 30 * time.Minute
+	}
+	return 24 * time.Hour
+}
+
+// saveImportMeta 记录导入来源。同一代码同一周期多次导入时区间取并集 (先导入新文件再导入旧文件也不丢范围，
+// migrateRawBars 按这个区间保留导入的K线)，行数为区间内的实际K线数
+func (w *Warehouse) saveImportMeta(r ImportResult, opts ImportOptions) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	_, err := w.Duck.DB.Exec(`
+		INSERT INTO import_meta VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (code, timeframe) DO UPDATE SET
+			source = excluded.source, timezone = excluded.timezone, adjust = excluded.adjust,
+			first_time = LEAST(import_meta.first_time, excluded.first_time),
+			last_time = GREATEST(import_meta.last_time, excluded.last_time),
+			imported_at = excluded.imported_at`,
+		r.Code, string(r.Timeframe), r.File, opts.Timezone, string(opts.Adjust), r.Rows, r.First, r.Last, time.Now())
+	if err != nil {
+		return err
+	}
+	_, err = w.Duck.DB.Exec(fmt.Sprintf(`
+		UPDATE import_meta SET rows = (
+			SELECT count(*) FROM %s b WHERE b.code = import_meta.code AND b.time BETWEEN import_meta.first_time AND import_meta.last_time)
+		WHERE code = ? AND timeframe = ?`, r.Timeframe.Table()), r.Code, string(r.Timeframe))
+	return err
+}
+
+// ImportMetaFor 某代码某周期最近一次导入的来源信息；没有导入过时 ok=false
+func (w *Warehouse) ImportMetaFor(code string, tf Timeframe) (ImportMeta, bool) {
+	if w == nil {
+		return ImportMeta{}, false
+	}
+	m := ImportMeta{Code: code, Timeframe: tf}
+	err := w.Duck.DB.QueryRow(`
+		SELECT source, timezone, adjust, rows, first_time, last_time, imported_at
+		FROM import_meta WHERE code = ? AND timeframe = ?`, code, string(tf)).
+		Scan(&m.Source, &m.Timezone, &m.Adjust, &m.Rows, &m.First, &m.Last, &m.ImportedAt)
+	return m, err == nil
+}
+
+// StoredAdjust 仓库中某代码某周期K线的复权口径: 导入的按 import_meta 记录，实时同步的为不复权
+func (w *Warehouse) StoredAdjust(code string, tf Timeframe) model.Adjust {
+	if m, ok := w.ImportMetaFor(code, tf); ok && m.Adjust != "" {
+		return m.Adjust
+	}
+	return model.AdjustNone
+}
+
+// PrintImportReport 打印导入汇总
+func PrintImportReport(results []ImportResult) {
+	fmt.Printf("\n%-8s %-4s %7s %5s %-16s %-16s %s\n", "代码", "周期", "写入", "跳过", "第一根", "最后一根", "文件")
+	for _, r := range results {
+		fmt.Printf("%-8s %-4s %7d %5d %-16s %-16s %s\n", r.Code, r.Timeframe, r.Rows, r.Skipped,
+			r.First.Format(r.Timeframe.layout()), r.Last.Format(r.Timeframe.layout()), r.File)
+	}
+}
+
+// LocalSource 以仓库中的K线 (含导入的历史数据) 作为数据源，用于回测 / 离线研究。
+// 实现 fetcher.Source，可放进数据源链 (sources.order 中的 local)；不提供实时行情。
+// 实时同步的K线为不复权，可按仓库中的复权因子换算；导入的K线只按导入时的口径提供
+type LocalSource struct {
+	W *Warehouse
+}
+
+func (LocalSource) Name() string { return "local" }
+
+func (s LocalSource) Quote(ctx context.Context, code string) (model.Quote, error) {
+	return model.Quote{}, &fetcher.FetchError{Op: "LocalQuote", Target: code, Kind: fetcher.ErrEmpty, Err: errors.New("no real-time quotes in local data")}
+}
+
+func (s LocalSource) Daily(ctx context.Context, code string, limit int, adj model.Adjust) ([]model.KLineData, error) {
+	return s.bars("LocalDaily", code, TFDaily, limit, adj)
+}
+
+func (s LocalSource) Min30(ctx context.Context, code string, limit int, adj model.Adjust) ([]model.KLineData, error) {
+	return s.bars("LocalMin30", code, TF30m, limit, adj)
+}
+
+func (s LocalSource) bars(op, code string, tf Timeframe, limit int, adj model.Adjust) ([]model.KLineData, error) {
+	if s.W == nil {
+		return nil, &fetcher.FetchError{Op: op, Target: code, Kind: fetcher.ErrEmpty, Err: errors.New("warehouse not configured")}
+	}
+	stored := s.W.StoredAdjust(code, tf)
+	factors := s.W.AdjFactors(code)
+	if adj != stored && (stored != model.AdjustNone || len(factors) == 0) {
+		return nil, &fetcher.FetchError{Op: op, Target: code, Kind: fetcher.ErrEmpty, Err: fmt.Errorf("local bars are %s, want %s", stored, adj)}
+	}
+	bars, err := s.W.Bars(code, tf, limit)
+	if err != nil {
+		return nil, err
+	}
+	if len(bars) == 0 {
+		return nil, &fetcher.FetchError{Op: op, Target: code, Kind: fetcher.ErrEmpty, Err: errors.New("no local bars")}
+	}
+	if stored != model.AdjustNone {
+		return bars, nil
+	}
+	return data_processor.AdjustBars(bars, factors, adj), nil
+}
@@ -0,0 +1,224 @@
+package warehouse
+
+import (
+	"context"
+	"dragon-quant/fetcher"
+	"dragon-quant/model"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestImportTushareDaily(t *testing.T) {
+	dir := t.TempDir()
+	wh, err := Open(filepath.Join(dir, "market.duckdb"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wh.Close()
+
+	// tushare pro daily: 倒序、成交额千元、两只股票混在一个文件
+	csv := `ts_code,trade_date,open,high,low,close,pre_close,change,pct_chg,vol,amount
+600519.SH,20260106,1510.0,1530.0,1500.0,1525.0,1508.0,17.0,1.13,32000.5,4870000.0
+000001.SZ,20260106,11.0,11.3,10.9,11.2,11.0,0.2,1.82,900000,1000000
+600519.SH,20260105,1500.0,1512.0,1490.0,1508.0,1495.0,13.0,0.87,28000,4200000.0
+000001.SZ,20260105,10.8,11.1,10.7,11.0,10.8,0.2,1.85,800000,
+bad,notadate,1,1,1,1,1,0,0,1,1
+`
+	path := filepath.Join(dir, "daily.csv")
+	os.WriteFile(path, []byte(csv), 0o644)
+
+	res, err := wh.ImportFile(path, ImportOptions{Adjust: model.AdjustForward, AmountScale: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 2 || res[0].Code != "000001" || res[1].Code != "600519" || res[1].Timeframe != TFDaily || res[1].Rows != 2 {
+		t.Fatalf("results = %+v", res)
+	}
+	bars, _ := wh.Bars("600519", TFDaily, 10)
+	if len(bars) != 2 || bars[0].Date != "2026-01-05" || bars[1].Open != 1510 || bars[1].Volume != 32000.5 || bars[1].Amount != 4.87e9 {
+		t.Fatalf("bars = %+v", bars)
+	}
+	m, ok := wh.ImportMetaFor("600519", TFDaily)
+	if !ok || m.Adjust != model.AdjustForward || m.Timezone != "Asia/Shanghai" || m.Rows != 2 || m.Source != path {
+		t.Errorf("meta = %+v ok=%v", m, ok)
+	}
+	if _, err := wh.ImportFile(path, ImportOptions{Adjust: model.Adjust("split")}); err == nil {
+		t.Error("unknown adjust should fail")
+	}
+}
+
+func TestImportMetaWidensRange(t *testing.T) {
+	dir := t.TempDir()
+	wh, err := Open(filepath.Join(dir, "market.duckdb"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wh.Close()
+
+	// 先导入新文件，再导入更早的文件: 记录的区间取并集
+	newer := filepath.Join(dir, "600519_2026.csv")
+	os.WriteFile(newer, []byte("trade_date,close\n20260105,1508\n20260106,1525\n"), 0o644)
+	older := filepath.Join(dir, "600519_2025.csv")
+	os.WriteFile(older, []byte("trade_date,close\n20251230,1480\n20251231,1490\n"), 0o644)
+	for _, f := range []string{newer, older} {
+		if _, err := wh.ImportFile(f, ImportOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m, ok := wh.ImportMetaFor("600519", TFDaily)
+	if !ok || m.First.Format("2006-01-02") != "2025-12-30" || m.Last.Format("2006-01-02") != "2026-01-06" || m.Rows != 4 || m.Source != older {
+		t.Errorf("meta = %+v ok=%v", m, ok)
+	}
+}
+
+func TestImportAdjustedKeptApartFromLive(t *testing.T) {
+	dir := t.TempDir()
+	wh, err := Open(filepath.Join(dir, "market.duckdb"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wh.Close()
+	fetches := 0
+	wh.src = source{
+		daily: func(_ context.Context, code string, limit int) ([]model.KLineData, error) {
+			fetches++
+			return []model.KLineData{{Date: "2026-01-07", Close: 3000}}, nil
+		},
+		factors: func(_ context.Context, code string, limit int) ([]model.AdjFactor, error) {
+			fetches++
+			return []model.AdjFactor{{Date: "2026-01-05", Factor: 1}, {Date: "2026-01-06", Factor: 2}}, nil
+		},
+	}
+
+	csv := `ts_code,trade_date,close
+600519.SH,20260105,1508.0
+600519.SH,20260106,1525.0
+`
+	path := filepath.Join(dir, "qfq.csv")
+	os.WriteFile(path, []byte(csv), 0o644)
+	if _, err := wh.ImportFile(path, ImportOptions{Adjust: model.AdjustForward}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 读取导入的前复权K线: 不拉取、不写入不复权K线、不再复权一次
+	ctx := context.Background()
+	bars, err := wh.Daily(ctx, "600519", 10)
+	if err != nil || len(bars) != 2 || bars[0].Close != 1508 || bars[1].Close != 1525 || fetches != 0 {
+		t.Errorf("daily = %+v err = %v fetches = %d", bars, err, fetches)
+	}
+	if _, _, err := wh.DailyRaw(ctx, "600519", 10); err == nil {
+		t.Error("imported qfq bars have no raw prices")
+	}
+	if _, err := wh.UpsertBars("600519", TFDaily, []model.KLineData{{Date: "2026-01-07", Close: 3000}}); err == nil {
+		t.Error("live bars should not be mixed into an adjusted import")
+	}
+	if _, err := wh.ImportFile(path, ImportOptions{Adjust: model.AdjustNone}); err == nil {
+		t.Error("raw import over qfq bars should fail")
+	}
+
+	// 已有实时同步的不复权K线时拒绝导入复权K线
+	wh.UpsertBars("000001", TFDaily, []model.KLineData{{Date: "2026-01-05", Close: 11}})
+	live := filepath.Join(dir, "000001.csv")
+	os.WriteFile(live, []byte("trade_date,close\n20260106,5.6\n"), 0o644)
+	if _, err := wh.ImportFile(live, ImportOptions{Adjust: model.AdjustForward}); err == nil {
+		t.Error("qfq import over live raw bars should fail")
+	}
+	if _, ok := wh.ImportMetaFor("000001", TFDaily); ok {
+		t.Error("rejected import should not record meta")
+	}
+
+	// 后复权导入: 没有因子时拒绝当作前复权返回，有因子时除以最新因子折回前复权价位
+	hfq := filepath.Join(dir, "300750.csv")
+	os.WriteFile(hfq, []byte("trade_date,close\n20260105,400\n20260106,420\n"), 0o644)
+	if _, err := wh.ImportFile(hfq, ImportOptions{Adjust: model.AdjustBackward}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wh.Daily(ctx, "300750", 10); err == nil {
+		t.Error("hfq bars without factors should not be served as qfq")
+	}
+	wh.SaveAdjFactors("300750", []model.AdjFactor{{Date: "2026-01-05", Factor: 2}})
+	if bars, err := wh.Daily(ctx, "300750", 10); err != nil || len(bars) != 2 || bars[0].Close != 200 || bars[1].Close != 210 || bars[1].Change != 10 {
+		t.Errorf("hfq -> qfq = %+v err = %v", bars, err)
+	}
+}
+
+func TestImportSplitDateTime(t *testing.T) {
+	dir := t.TempDir()
+	wh, err := Open(filepath.Join(dir, "market.duckdb"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wh.Close()
+
+	// 日期、时间分两列: 不能只取时间列而丢掉日期
+	files := map[string]string{
+		"600519_1m.csv": "date,time,close,volume\n2026-01-05,09:31,1500,10\n2026-01-05,09:32,1501,12\n2026-01-06,09:31,1510,9\n",
+		"000001_1m.csv": "日期,时间,收盘\n20260105,0931,11.0\n20260105,0932,11.1\n20260106,931,11.2\n",
+	}
+	for name, csv := range files {
+		path := filepath.Join(dir, name)
+		os.WriteFile(path, []byte(csv), 0o644)
+		res, err := wh.ImportFile(path, ImportOptions{})
+		if err != nil || len(res) != 1 || res[0].Timeframe != TF1m || res[0].Rows != 3 {
+			t.Fatalf("%s: results = %+v err = %v", name, res, err)
+		}
+		bars, _ := wh.Bars(res[0].Code, TF1m, 10)
+		if len(bars) != 3 || bars[0].Date != "2026-01-05 09:31" || bars[2].Date != "2026-01-06 09:31" {
+			t.Errorf("%s: bars = %+v", name, bars)
+		}
+	}
+}
+
+func TestImportMinutesTimezoneAndParquet(t *testing.T) {
+	dir := t.TempDir()
+	wh, err := Open(filepath.Join(dir, "market.duckdb"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wh.Close()
+
+	// UTC 时间戳、按开始时间标注: 01:30Z = 北京 09:30 开始 -> 10:00 这根
+	csv := `datetime,open,high,low,close,volume
+2026-01-05 01:30:00,10.0,10.2,9.9,10.1,120000
+2026-01-05 02:00:00,10.1,10.3,10.0,10.2,80000
+2026-01-05 02:30:00,10.2,10.4,10.1,10.3,60000
+`
+	path := filepath.Join(dir, "sz300750_30m.csv")
+	os.WriteFile(path, []byte(csv), 0o644)
+
+	res, err := wh.ImportFile(path, ImportOptions{Timezone: "UTC", StartLabel: true, VolumeScale: 0.01})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 1 || res[0].Code != "300750" || res[0].Timeframe != TF30m {
+		t.Fatalf("results = %+v", res)
+	}
+	bars, _ := wh.Bars("300750", TF30m, 10)
+	if len(bars) != 3 || bars[0].Date != "2026-01-05 10:00" || bars[2].Date != "2026-01-05 11:00" || bars[0].Volume != 1200 {
+		t.Fatalf("bars = %+v", bars)
+	}
+
+	// DuckDB 导出 Parquet 再导入到另一个代码
+	pq := filepath.Join(dir, "bars.parquet")
+	if _, err := wh.Duck.DB.Exec(`COPY (SELECT '002594' AS symbol, time AS trade_time, close, amount FROM bars_30m)
+		TO '` + pq + `' (FORMAT PARQUET)`); err != nil {
+		t.Fatal(err)
+	}
+	res, err = wh.ImportFile(pq, ImportOptions{})
+	if err != nil || len(res) != 1 || res[0].Code != "002594" || res[0].Timeframe != TF30m || res[0].Rows != 3 {
+		t.Fatalf("parquet results = %+v err=%v", res, err)
+	}
+
+	// local 数据源读取导入的K线，没有数据时返回空数据错误
+	src := LocalSource{W: wh}
+	got, err := src.Min30(context.Background(), "002594", 10, model.AdjustNone)
+	if err != nil || len(got) != 3 || got[0].Date != "2026-01-05 10:00" {
+		t.Fatalf("local min30 = %+v err=%v", got, err)
+	}
+	var fe *fetcher.FetchError
+	if _, err := src.Daily(context.Background(), "002594", 10, model.AdjustNone); !errors.As(err, &fe) || fe.Kind != fetcher.ErrEmpty {
+		t.Errorf("local daily err = %v", err)
+	}
+}
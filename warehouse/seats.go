@@ -0,0 +1,318 @@
+package warehouse
+
+import (
+	"context"
+	"database/sql"
+	"dragon-quant/calendar"
+	"dragon-quant/data_processor"
+	"dragon-quant/fetcher"
+	"dragon-quant/model"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// --- 游资席位画像: 基于本地龙虎榜库统计每个席位的次日胜率、平均次日涨幅与持股风格 ---
+
+// HotSeatMinSamples 未在席位字典中的营业部，至少有这么多历史样本才展示
+var HotSeatMinSamples = 3
+
+// seatStyle 按买入到上榜卖出的中位持有天数归类持股风格
+func seatStyle(holds []int) string {
+	if len(holds) == 0 {
+		return "未见卖出"
+	}
+	sorted := append([]int(nil), holds...)
+	sort.Ints(sorted)
+	switch median := sorted[len(sorted)/2]; {
+	case median <= 1:
+		return "一日游"
+	case median <= 3:
+		return "超短接力"
+	case median <= 10:
+		return "波段"
+	}
+	return "中线锁仓"
+}
+
+// SeatProfiles 统计指定席位在 before (不含) 之前的历史表现，避免用到当日之后的信息。
+// 样本为席位净买入的 (个股, 交易日)；次日涨幅取仓库日K，次日停牌的样本不计入胜率。
+// 仓库日K为不复权价，跨除权日的次日涨幅按复权因子换算 (否则送转 / 分红会被算成大跌)。
+func (w *Warehouse) SeatProfiles(names []string, before string) map[string]model.SeatProfile {
+	profiles := make(map[string]model.SeatProfile)
+	if w == nil || len(names) == 0 {
+		return profiles
+	}
+	holders := strings.TrimSuffix(strings.Repeat("?, ", len(names)), ", ")
+	args := []interface{}{before}
+	for _, n := range names {
+		args = append(args, n)
+	}
+
+	dict := fetcher.DefaultSeatDict()
+	for _, n := range names {
+		kind, alias := dict.Tag(n)
+		profiles[n] = model.SeatProfile{Name: n, Kind: kind, Alias: alias}
+	}
+
+	// 1. 上榜次数
+	rows, err := w.Duck.DB.Query(fmt.Sprintf(`
+		SELECT seat_name, COUNT(DISTINCT (code, trade_date))
+		FROM lhb_seats WHERE trade_date < CAST(? AS DATE) AND seat_name IN (%s)
+		GROUP BY seat_name`, holders), args...)
+	if err != nil {
+		fmt.Printf("⚠️ [Warehouse] 席位统计失败: %v\n", err)
+		return profiles
+	}
+	for rows.Next() {
+		var name string
+		var n int
+		if rows.Scan(&name, &n) == nil {
+			p := profiles[name]
+			p.Appearances = n
+			profiles[name] = p
+		}
+	}
+	rows.Close()
+
+	// 2. 净买入样本: 次日收盘 + 此后首次净卖出上榜的日期
+	rows, err = w.Duck.DB.Query(fmt.Sprintf(`
+		WITH px AS (
+			SELECT code, CAST(time AS DATE) AS d, close,
+				LEAD(close) OVER (PARTITION BY code ORDER BY time) AS next_close,
+				LEAD(CAST(time AS DATE)) OVER (PARTITION BY code ORDER BY time) AS next_d
+			FROM bars_1d
+		),
+		buys AS (
+			SELECT DISTINCT seat_name, code, trade_date FROM lhb_seats
+			WHERE net_amt > 0 AND trade_date < CAST($1 AS DATE) AND seat_name IN (%s)
+		)
+		SELECT b.seat_name, b.code, strftime(b.trade_date, '%%Y-%%m-%%d'), px.close, px.next_close,
+			strftime(px.next_d, '%%Y-%%m-%%d'),
+			(SELECT strftime(MIN(x.trade_date), '%%Y-%%m-%%d') FROM lhb_seats x
+				WHERE x.seat_name = b.seat_name AND x.code = b.code AND x.net_amt < 0
+				AND x.trade_date > b.trade_date AND x.trade_date < CAST($1 AS DATE))
+		FROM buys b LEFT JOIN px ON px.code = b.code AND px.d = b.trade_date`,
+		placeholders(2, len(names))), args...)
+	if err != nil {
+		fmt.Printf("⚠️ [Warehouse] 席位样本查询失败: %v\n", err)
+		return profiles
+	}
+	type sample struct {
+		name, code, day  string
+		close, nextClose sql.NullFloat64
+		nextDay, exit    sql.NullString
+	}
+	var samples []sample
+	for rows.Next() {
+		var sm sample
+		if rows.Scan(&sm.name, &sm.code, &sm.day, &sm.close, &sm.nextClose, &sm.nextDay, &sm.exit) == nil {
+			samples = append(samples, sm)
+		}
+	}
+	rows.Close()
+
+	cal := calendar.Default()
+	wins := make(map[string]int)
+	retSum := make(map[string]float64)
+	holds := make(map[string][]int)
+	factors := make(map[string][]model.AdjFactor)
+	for _, sm := range samples {
+		d, err := time.Parse("2006-01-02", sm.day)
+		if err != nil {
+			continue
+		}
+		p := profiles[sm.name]
+		if sm.close.Valid && sm.nextClose.Valid && sm.close.Float64 > 0 &&
+			sm.nextDay.String == cal.NextTradingDay(d).Format("2006-01-02") {
+			f, ok := factors[sm.code]
+			if !ok {
+				// 导入的复权K线本身已连续，只有不复权价需要换算
+				if w.StoredAdjust(sm.code, TFDaily) == model.AdjustNone {
+					f = w.AdjFactors(sm.code)
+				}
+				factors[sm.code] = f
+			}
+			ratio := data_processor.FactorAt(f, sm.nextDay.String) / data_processor.FactorAt(f, sm.day)
+			ret := (sm.nextClose.Float64*ratio/sm.close.Float64 - 1) * 100
+			p.Samples++
+			retSum[sm.name] += ret
+			if ret > 0 {
+				wins[sm.name]++
+			}
+		}
+		if sm.exit.Valid {
+			if e, err := time.Parse("2006-01-02", sm.exit.String); err == nil {
+				holds[sm.name] = append(holds[sm.name], cal.TradingDaysBetween(d, e))
+			}
+		}
+		profiles[sm.name] = p
+	}
+
+	for name, p := range profiles {
+		if p.Samples > 0 {
+			p.WinRate = float64(wins[name]) / float64(p.Samples)
+			p.AvgNextRet = retSum[name] / float64(p.Samples)
+		}
+		if h := holds[name]; len(h) > 0 {
+			sum := 0
+			for _, v := range h {
+				sum += v
+			}
+			p.AvgHoldDays = float64(sum) / float64(len(h))
+		}
+		if p.Appearances > 0 {
+			p.Style = seatStyle(holds[name])
+		}
+		profiles[name] = p
+	}
+	return profiles
+}
+
+// placeholders 生成 $start..$start+n-1 的参数占位符 (同一查询中复用 $1 时使用)
+func placeholders(start, n int) string {
+	parts := make([]string, n)
+	for i := range parts {
+		parts[i] = fmt.Sprintf("$%d", start+i)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// AnnotateHotSeats 为上榜个股填充 HotSeats: 当日净买入的知名席位 (字典已识别或历史样本充足) 及其历史跟风表现
+func (w *Warehouse) AnnotateHotSeats(s *model.StockInfo) {
+	if w == nil || s.LHBDate == "" || len(s.LHBSeats) == 0 {
+		return
+	}
+	net := make(map[string]float64)
+	var names []string
+	for _, st := range s.LHBSeats {
+		if st.NetAmt <= 0 {
+			continue
+		}
+		if _, ok := net[st.Name]; !ok {
+			names = append(names, st.Name)
+		}
+		net[st.Name] += st.NetAmt
+	}
+
+	profiles := w.SeatProfiles(names, s.LHBDate)
+	s.HotSeats = nil
+	for _, name := range names {
+		p := profiles[name]
+		if p.Kind == "" && p.Samples < HotSeatMinSamples {
+			continue
+		}
+		s.HotSeats = append(s.HotSeats, model.SeatFollow{SeatProfile: p, NetAmt: net[name]})
+	}
+	sort.SliceStable(s.HotSeats, func(i, j int) bool { return s.HotSeats[i].NetAmt > s.HotSeats[j].NetAmt })
+}
+
+// TopSeats 返回净买入上榜次数最多的 limit 个席位画像 (按上榜次数降序)
+func (w *Warehouse) TopSeats(limit int) []model.SeatProfile {
+	rows, err := w.Duck.DB.Query(`
+		SELECT seat_name FROM lhb_seats WHERE net_amt > 0
+		GROUP BY seat_name ORDER BY COUNT(DISTINCT (code, trade_date)) DESC, seat_name LIMIT ?`, limit)
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for rows.Next() {
+		var n string
+		if rows.Scan(&n) == nil {
+			names = append(names, n)
+		}
+	}
+	rows.Close()
+
+	tomorrow := calendar.Now().AddDate(0, 0, 1).Format("2006-01-02")
+	profiles := w.SeatProfiles(names, tomorrow)
+	out := make([]model.SeatProfile, 0, len(names))
+	for _, n := range names {
+		out = append(out, profiles[n])
+	}
+	return out
+}
+
+// BackfillLHB 拉取最近 days 个已收盘交易日的全市场龙虎榜入库 (已入库的日期跳过)，
+// 并补齐上榜个股的日K，供计算次日涨幅。返回新写入的记录数。
+func (w *Warehouse) BackfillLHB(ctx context.Context, days int, now time.Time) int {
+	if days <= 0 {
+		return 0
+	}
+	cal := calendar.Default()
+	latest := cal.LatestClosedSession(now)
+	var dates []time.Time
+	for d := latest; len(dates) < days; d = cal.PrevTradingDay(d) {
+		dates = append(dates, d)
+	}
+
+	saved := 0
+	for _, d := range dates {
+		if ctx.Err() != nil {
+			break
+		}
+		day := d.Format("2006-01-02")
+		var n int
+		w.Duck.DB.QueryRow("SELECT COUNT(*) FROM lhb_seats WHERE trade_date = CAST(? AS DATE)", day).Scan(&n)
+		if n > 0 {
+			continue
+		}
+		records, err := w.src.lhbDay(ctx, day)
+		if err != nil {
+			fmt.Printf("⚠️ [LHB] %s 拉取失败: %v\n", day, err)
+			continue
+		}
+		if err := w.SaveLHBRecords(records); err != nil {
+			fmt.Printf("⚠️ [LHB] %s 入库失败: %v\n", day, err)
+			continue
+		}
+		fmt.Printf("🐉 [LHB] %s: %d 只个股上榜\n", day, len(records))
+		saved += len(records)
+	}
+
+	// 次日涨幅需要上榜日之后的日K (包括之前已入库、当时还没有次日行情的日期)
+	first := dates[len(dates)-1]
+	var codes []string
+	rows, err := w.Duck.DB.Query("SELECT DISTINCT code FROM lhb WHERE trade_date >= CAST(? AS DATE)", first.Format("2006-01-02"))
+	if err == nil {
+		for rows.Next() {
+			var c string
+			if rows.Scan(&c) == nil {
+				codes = append(codes, c)
+			}
+		}
+		rows.Close()
+	}
+	limit := cal.TradingDaysBetween(first, now) + 2
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, 5)
+	for _, code := range codes {
+		wg.Add(1)
+		go func(code string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			w.Daily(ctx, code, limit) // 失败已记入数据质量报告
+		}(code)
+	}
+	wg.Wait()
+	return saved
+}
+
+// PrintSeatProfiles 打印席位画像表
+func PrintSeatProfiles(profiles []model.SeatProfile) {
+	fmt.Printf("\n%-28s %-8s %6s %6s %8s %10s %8s %s\n", "席位", "标签", "上榜", "样本", "次日胜率", "次日均涨幅", "均持有", "风格")
+	for _, p := range profiles {
+		tag := p.Alias
+		if tag == "" {
+			tag = p.Kind
+		}
+		if tag == "" {
+			tag = "-"
+		}
+		fmt.Printf("%-28s %-8s %6d %6d %7.0f%% %9.2f%% %7.1fd %s\n",
+			p.Name, tag, p.Appearances, p.Samples, p.WinRate*100, p.AvgNextRet, p.AvgHoldDays, p.Style)
+	}
+}
@@ -0,0 +1,85 @@
+package warehouse
+
+import (
+	"dragon-quant/model"
+	"math"
+	"testing"
+)
+
+const (
+	seatZhang   = "国泰君安证券股份有限公司上海江苏路证券营业部"
+	seatUnknown = "某某证券有限公司某地证券营业部"
+)
+
+func lhbRec(date, code string, seats ...model.LHBSeat) model.LHBRecord {
+	return model.LHBRecord{Date: date, Code: code, Seats: seats}
+}
+
+func TestSeatProfilesAndHotSeats(t *testing.T) {
+	wh, err := Open("")
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer wh.Close()
+
+	// 600001: 01-05 买入，次日 +10%，01-06 卖出 (持有 1 天)
+	// 600002: 01-07 买入，次日 -5%，01-08 卖出 (持有 1 天)
+	wh.UpsertBars("600001", TFDaily, []model.KLineData{
+		{Date: "2026-01-05", Close: 10}, {Date: "2026-01-06", Close: 11}, {Date: "2026-01-07", Close: 11},
+	})
+	wh.UpsertBars("600002", TFDaily, []model.KLineData{
+		{Date: "2026-01-07", Close: 20}, {Date: "2026-01-08", Close: 19},
+	})
+	buy := model.LHBSeat{Name: seatZhang, Side: model.SeatBuy, BuyAmt: 5e7, NetAmt: 5e7}
+	sell := model.LHBSeat{Name: seatZhang, Side: model.SeatSell, SellAmt: 5e7, NetAmt: -5e7}
+	unknown := model.LHBSeat{Name: seatUnknown, Side: model.SeatBuy, BuyAmt: 1e7, NetAmt: 1e7}
+	err = wh.SaveLHBRecords([]model.LHBRecord{
+		lhbRec("2026-01-05", "600001", buy, unknown),
+		lhbRec("2026-01-06", "600001", sell),
+		lhbRec("2026-01-07", "600002", buy),
+		lhbRec("2026-01-08", "600002", sell),
+		// 当日及之后的记录不应计入画像
+		lhbRec("2026-01-12", "600003", buy),
+	})
+	if err != nil {
+		t.Fatalf("SaveLHBRecords failed: %v", err)
+	}
+
+	p := wh.SeatProfiles([]string{seatZhang}, "2026-01-12")[seatZhang]
+	if p.Alias != "章盟主" || p.Appearances != 4 || p.Samples != 2 {
+		t.Fatalf("profile = %+v", p)
+	}
+	if p.WinRate != 0.5 || math.Abs(p.AvgNextRet-2.5) > 1e-9 {
+		t.Errorf("WinRate=%.2f AvgNextRet=%.2f, want 0.5 / 2.5", p.WinRate, p.AvgNextRet)
+	}
+	if p.AvgHoldDays != 1 || p.Style != "一日游" {
+		t.Errorf("hold=%.1f style=%s, want 1 / 一日游", p.AvgHoldDays, p.Style)
+	}
+
+	s := &model.StockInfo{Code: "600003", LHBDate: "2026-01-12", LHBSeats: []model.LHBSeat{buy, unknown}}
+	wh.AnnotateHotSeats(s)
+	if len(s.HotSeats) != 1 || s.HotSeats[0].Alias != "章盟主" || s.HotSeats[0].NetAmt != 5e7 {
+		t.Fatalf("HotSeats = %+v, want only 章盟主 (unknown seat lacks samples)", s.HotSeats)
+	}
+}
+
+func TestSeatProfilesAcrossExRights(t *testing.T) {
+	wh, err := Open("")
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer wh.Close()
+
+	// 10 送 10: 不复权收盘 10 → 5.5，复权后次日实为 +10%
+	wh.UpsertBars("600004", TFDaily, []model.KLineData{{Date: "2026-01-05", Close: 10}, {Date: "2026-01-06", Close: 5.5}})
+	wh.SaveAdjFactors("600004", []model.AdjFactor{{Date: "2026-01-05", Factor: 1}, {Date: "2026-01-06", Factor: 2}})
+	buy := model.LHBSeat{Name: seatUnknown, Side: model.SeatBuy, BuyAmt: 1e7, NetAmt: 1e7}
+	if err := wh.SaveLHBRecords([]model.LHBRecord{lhbRec("2026-01-05", "600004", buy)}); err != nil {
+		t.Fatalf("SaveLHBRecords failed: %v", err)
+	}
+
+	p := wh.SeatProfiles([]string{seatUnknown}, "2026-01-12")[seatUnknown]
+	if p.Samples != 1 || p.WinRate != 1 || math.Abs(p.AvgNextRet-10) > 1e-9 {
+		t.Errorf("profile = %+v, want one winning sample at +10%%", p)
+	}
+}
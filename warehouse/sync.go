@@ -0,0 +1,306 @@
+package warehouse
+
+import (
+	"context"
+	"dragon-quant/calendar"
+	"dragon-quant/fetcher"
+	"dragon-quant/model"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// source 仓库使用的行情来源 (默认 fetcher，测试中替换为假数据)
+type source struct {
+	daily   func(ctx context.Context, code string, limit int) ([]model.KLineData, error)
+	min30   func(ctx context.Context, code string, limit int) ([]model.KLineData, error)
+	factors func(ctx context.Context, code string, limit int) ([]model.AdjFactor, error)
+	min5    func(ctx context.Context, code string, limit int) ([]model.KLineData, error)
+	min1Day func(ctx context.Context, code, date string) ([]model.KLineData, error)
+	sector  func(ctx context.Context, code string) ([]model.StockInfo, error)
+	lhbDay  func(ctx context.Context, date string) ([]model.LHBRecord, error)
+}
+
+// 仓库只存不复权K线，复权价由 adj_factors 换算
+var liveSource = source{
+	daily: func(ctx context.Context, code string, limit int) ([]model.KLineData, error) {
+		return fetcher.Sources().Daily(ctx, code, limit, model.AdjustNone) // 东财失败时切到腾讯 / 新浪
+	},
+	min30: func(ctx context.Context, code string, limit int) ([]model.KLineData, error) {
+		return fetcher.Sources().Min30(ctx, code, limit, model.AdjustNone)
+	},
+	factors: fetcher.FetchAdjFactors,
+	min5:    fetcher.Fetch5MinKlineN,
+	min1Day: fetcher.Fetch1MinKlineForDate,
+	sector:  fetcher.FetchSectorStocks,
+	lhbDay:  fetcher.FetchLHBByDate,
+}
+
+// barsPerDay 每个完整交易日应有的K线根数 (达到即视为无缺口)
+func (tf Timeframe) barsPerDay() int {
+	switch tf {
+	case TF30m:
+		return 8
+	case TF5m:
+		return 48
+	case TF1m:
+		return 240
+	}
+	return 1
+}
+
+// ParseTimeframes 解析配置中的周期列表，忽略未知值；为空时返回 1d/30m/1m
+func ParseTimeframes(names []string) []Timeframe {
+	if len(names) == 0 {
+		return []Timeframe{TFDaily, TF30m, TF1m}
+	}
+	var tfs []Timeframe
+	for _, n := range names {
+		for _, tf := range Timeframes {
+			if strings.EqualFold(strings.TrimSpace(n), string(tf)) {
+				tfs = append(tfs, tf)
+			}
+		}
+	}
+	return tfs
+}
+
+// SyncOptions 同步范围
+type SyncOptions struct {
+	Codes      []string    // 自选股代码
+	Sectors    []string    // 板块代码 (如 BK0477)，展开为全部成分股
+	Timeframes []Timeframe // 为空则 1d/30m/1m
+	Days       int         // 分钟级K线回看交易日数 (默认 5)
+	DailyLimit int         // 日K回看交易日数 (默认 120)
+	Now        time.Time   // 为零值时取当前时间
+}
+
+// SyncResult 单个代码单个周期的同步结果
+type SyncResult struct {
+	Code       string
+	Timeframe  Timeframe
+	Fetched    int       // 本次写入的K线数
+	Backfilled []string  // 本次补齐的缺口交易日
+	Gaps       []string  // 补拉后仍缺失的交易日 (停牌/接口无数据)
+	Last       time.Time // 同步后最后一根K线
+	Stale      bool      // 最后一根K线早于最近一个交易日
+	Err        error     // 本次拉取失败的原因 (fetcher.FetchError)
+}
+
+// Sync 对自选股与板块成分股逐代码、逐周期增量同步: 只拉最后一根之后的新K线，并按交易日历检测缺口回补。
+// ctx 取消后不再发起新的同步，已完成的结果照常返回。
+func (w *Warehouse) Sync(ctx context.Context, opts SyncOptions) []SyncResult {
+	if opts.Now.IsZero() {
+		opts.Now = time.Now()
+	}
+	if len(opts.Timeframes) == 0 {
+		opts.Timeframes = ParseTimeframes(nil)
+	}
+	if opts.Days <= 0 {
+		opts.Days = 5
+	}
+	if opts.DailyLimit <= 0 {
+		opts.DailyLimit = 120
+	}
+
+	codes := w.expandCodes(ctx, opts)
+	fmt.Printf("🔄 [Sync] %d 只股票 x %d 个周期...\n", len(codes), len(opts.Timeframes))
+
+	var results []SyncResult
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, 5)
+
+	for _, code := range codes {
+		for _, tf := range opts.Timeframes {
+			wg.Add(1)
+			go func(code string, tf Timeframe) {
+				defer wg.Done()
+				sem <- struct{}{}
+				defer func() { <-sem }()
+				if ctx.Err() != nil {
+					return
+				}
+
+				res := w.syncOne(ctx, code, tf, opts)
+				mu.Lock()
+				results = append(results, res)
+				mu.Unlock()
+			}(code, tf)
+		}
+	}
+	wg.Wait()
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Code != results[j].Code {
+			return results[i].Code < results[j].Code
+		}
+		return results[i].Timeframe < results[j].Timeframe
+	})
+	return results
+}
+
+// expandCodes 合并自选股与板块成分股 (去重，保持顺序)
+func (w *Warehouse) expandCodes(ctx context.Context, opts SyncOptions) []string {
+	seen := make(map[string]bool)
+	var codes []string
+	add := func(c string) {
+		if c != "" && !seen[c] {
+			seen[c] = true
+			codes = append(codes, c)
+		}
+	}
+	for _, c := range opts.Codes {
+		add(c)
+	}
+	for _, sec := range opts.Sectors {
+		stocks, err := w.src.sector(ctx, sec)
+		if err != nil {
+			fmt.Printf("⚠️ [Sync] 板块 %s 成分股拉取失败: %v\n", sec, err)
+			continue
+		}
+		w.SaveSectorMembers(model.SectorInfo{Code: sec}, stocks)
+		for _, s := range stocks {
+			add(s.Code)
+		}
+	}
+	return codes
+}
+
+func (w *Warehouse) syncOne(ctx context.Context, code string, tf Timeframe, opts SyncOptions) SyncResult {
+	res := SyncResult{Code: code, Timeframe: tf}
+
+	window := opts.Days
+	if tf == TFDaily {
+		window = opts.DailyLimit
+	}
+	cal := calendar.Default()
+	expected := calendar.Dates(cal.RecentTradingDays(opts.Now, window))
+	// 未收盘的交易日数据必然不完整，不算缺口
+	inProgress := ""
+	if latest := cal.LatestSession(opts.Now); !cal.SessionComplete(latest, opts.Now) {
+		inProgress = latest.Format("2006-01-02")
+	}
+	before := w.gapDays(code, tf, expected, inProgress)
+
+	if tf == TF1m {
+		// 1m 只能按日拉: 补缺口 + 刷新盘中的今天
+		for _, d := range expected {
+			if before[d] || d == inProgress {
+				bars, err := w.src.min1Day(ctx, code, d)
+				if err != nil {
+					res.Err = err
+					continue
+				}
+				n, _ := w.UpsertBars(code, tf, bars)
+				res.Fetched += n
+			}
+		}
+	} else {
+		// 其余周期接口只支持 "最近 N 根": 从最早缺口 (或最后一根之后) 起算需要的根数
+		from := len(expected)
+		for i, d := range expected {
+			if before[d] {
+				from = i
+				break
+			}
+		}
+		if last, ok := w.LastBarTime(code, tf); ok {
+			for i, d := range expected {
+				if d >= last.Format("2006-01-02") {
+					from = min(from, i)
+					break
+				}
+			}
+		} else {
+			from = 0
+		}
+		if need := (len(expected) - from) * tf.barsPerDay(); need > 0 {
+			fetch := w.src.daily
+			switch tf {
+			case TF30m:
+				fetch = w.src.min30
+			case TF5m:
+				fetch = w.src.min5
+			}
+			if bars, err := fetch(ctx, code, need); err != nil {
+				res.Err = err
+			} else {
+				n, _ := w.UpsertBars(code, tf, bars)
+				res.Fetched += n
+				if tf == TFDaily {
+					w.refreshFactors(ctx, code, window) // 失败已记入数据质量报告
+				}
+			}
+		}
+	}
+
+	after := w.gapDays(code, tf, expected, inProgress)
+	for _, d := range expected {
+		if before[d] && !after[d] {
+			res.Backfilled = append(res.Backfilled, d)
+		}
+		if after[d] {
+			res.Gaps = append(res.Gaps, d)
+		}
+	}
+
+	last, ok := w.LastBarTime(code, tf)
+	res.Last = last
+	res.Stale = !ok || (len(expected) > 0 && last.Format("2006-01-02") < expected[len(expected)-1])
+	return res
+}
+
+// gapDays 返回预期交易日中K线不完整的日期 (inProgress 为盘中的今天；早于首根K线的日期视为未上市，不算缺口)
+func (w *Warehouse) gapDays(code string, tf Timeframe, expected []string, inProgress string) map[string]bool {
+	gaps := make(map[string]bool)
+	first, ok := w.FirstBarTime(code, tf)
+	if !ok {
+		for _, d := range expected {
+			if d != inProgress {
+				gaps[d] = true
+			}
+		}
+		return gaps
+	}
+	counts := w.DayBarCounts(code, tf, expected)
+	for _, d := range expected {
+		if d == inProgress || d < first.Format("2006-01-02") {
+			continue
+		}
+		if counts[d] < tf.barsPerDay() {
+			gaps[d] = true
+		}
+	}
+	return gaps
+}
+
+// PrintSyncReport 打印同步汇总，并单独列出数据陈旧的代码
+func PrintSyncReport(results []SyncResult) {
+	fmt.Printf("\n%-8s %-4s %6s %8s %6s %-16s %s\n", "代码", "周期", "新增", "补缺口", "缺口", "最后一根", "状态")
+	var stale []string
+	for _, r := range results {
+		status := "✅"
+		if r.Err != nil && fetcher.KindOf(r.Err) != fetcher.ErrEmpty {
+			status = "❌ 拉取失败"
+			stale = append(stale, fmt.Sprintf("%s/%s", r.Code, r.Timeframe))
+		} else if r.Stale {
+			status = "⚠️ 陈旧"
+			stale = append(stale, fmt.Sprintf("%s/%s", r.Code, r.Timeframe))
+		} else if len(r.Gaps) > 0 {
+			status = "🕳️ 有缺口"
+		}
+		last := "-"
+		if !r.Last.IsZero() {
+			last = r.Last.Format("2006-01-02 15:04")
+		}
+		fmt.Printf("%-8s %-4s %6d %8d %6d %-16s %s\n", r.Code, r.Timeframe, r.Fetched, len(r.Backfilled), len(r.Gaps), last, status)
+	}
+	if len(stale) > 0 {
+		fmt.Printf("⚠️ 数据陈旧 (%d): %s\n", len(stale), strings.Join(stale, ", "))
+	} else {
+		fmt.Println("✅ 全部代码已同步到最近交易日")
+	}
+}
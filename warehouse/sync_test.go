@@ -0,0 +1,136 @@
+package warehouse
+
+import (
+	"context"
+	"dragon-quant/calendar"
+	"dragon-quant/model"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeSource 按预期交易日生成K线，并记录每次请求的根数/日期
+type fakeSource struct {
+	mu        sync.Mutex
+	now       time.Time
+	dailyReqs []int
+	min1Reqs  []string        // code@date
+	dead      map[string]bool // 这些代码没有任何数据 (模拟停牌/退市)
+}
+
+func (f *fakeSource) source() source {
+	return source{
+		daily: func(_ context.Context, code string, limit int) ([]model.KLineData, error) {
+			f.mu.Lock()
+			f.dailyReqs = append(f.dailyReqs, limit)
+			f.mu.Unlock()
+			if f.dead[code] {
+				return nil, nil
+			}
+			var bars []model.KLineData
+			for _, d := range calendar.Dates(calendar.Default().RecentTradingDays(f.now, limit)) {
+				bars = append(bars, model.KLineData{Date: d, Close: 10, Amount: 1e8})
+			}
+			return bars, nil
+		},
+		min30: func(_ context.Context, code string, limit int) ([]model.KLineData, error) { return nil, nil },
+		min5:  func(_ context.Context, code string, limit int) ([]model.KLineData, error) { return nil, nil },
+		min1Day: func(_ context.Context, code, date string) ([]model.KLineData, error) {
+			f.mu.Lock()
+			f.min1Reqs = append(f.min1Reqs, code+"@"+date)
+			f.mu.Unlock()
+			if f.dead[code] {
+				return nil, nil
+			}
+			return synth1m(date, 240, 10), nil
+		},
+		sector: func(_ context.Context, code string) ([]model.StockInfo, error) {
+			return []model.StockInfo{{Code: "600036"}, {Code: "000001"}}, nil
+		},
+	}
+}
+
+func TestSyncIncrementalAndGaps(t *testing.T) {
+	wh, err := Open("")
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer wh.Close()
+
+	now, _ := time.ParseInLocation("2006-01-02 15:04", "2026-01-16 16:00", time.Local) // 周五收盘后
+	fake := &fakeSource{now: now, dead: map[string]bool{"000002": true}}
+	wh.src = fake.source()
+
+	// 预置日K: 最近 10 个交易日中缺 01-12 (缺口)，且最新只到 01-14
+	days := calendar.Dates(calendar.Default().RecentTradingDays(now, 10))
+	var daily []model.KLineData
+	for _, d := range days {
+		if d == "2026-01-12" || d > "2026-01-14" {
+			continue
+		}
+		daily = append(daily, model.KLineData{Date: d, Close: 10})
+	}
+	wh.UpsertBars("600036", TFDaily, daily)
+	// 预置 1m: 01-14 已完整
+	wh.UpsertBars("600036", TF1m, synth1m("2026-01-14", 240, 10))
+
+	opts := SyncOptions{
+		Codes:      []string{"600036", "000002"},
+		Timeframes: []Timeframe{TFDaily, TF1m},
+		Days:       3,
+		DailyLimit: 10,
+		Now:        now,
+	}
+	results := wh.Sync(context.Background(), opts)
+
+	byKey := make(map[string]SyncResult)
+	for _, r := range results {
+		byKey[r.Code+"/"+string(r.Timeframe)] = r
+	}
+
+	d := byKey["600036/1d"]
+	if len(d.Backfilled) != 3 || d.Backfilled[0] != "2026-01-12" {
+		t.Errorf("1d backfilled = %v, want [2026-01-12 2026-01-15 2026-01-16]", d.Backfilled)
+	}
+	if len(d.Gaps) != 0 || d.Stale {
+		t.Errorf("1d should be complete: gaps=%v stale=%v", d.Gaps, d.Stale)
+	}
+
+	m := byKey["600036/1m"]
+	for _, req := range fake.min1Reqs {
+		if req == "600036@2026-01-14" {
+			t.Error("complete 1m day 2026-01-14 should not be re-downloaded")
+		}
+	}
+	if m.Stale || len(m.Gaps) != 0 {
+		t.Errorf("1m should be complete: %+v", m)
+	}
+
+	if r := byKey["000002/1d"]; !r.Stale {
+		t.Errorf("code without data should be stale: %+v", r)
+	}
+
+	// 第二次同步: 日K只需刷新最后一天
+	fake.dailyReqs = nil
+	wh.Sync(context.Background(), SyncOptions{Codes: []string{"600036"}, Timeframes: []Timeframe{TFDaily}, DailyLimit: 10, Now: now})
+	if len(fake.dailyReqs) != 1 || fake.dailyReqs[0] != 1 {
+		t.Errorf("second sync daily requests = %v, want [1]", fake.dailyReqs)
+	}
+}
+
+func TestSyncExpandsSectors(t *testing.T) {
+	wh, err := Open("")
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer wh.Close()
+
+	now, _ := time.ParseInLocation("2006-01-02 15:04", "2026-01-16 16:00", time.Local)
+	fake := &fakeSource{now: now}
+	wh.src = fake.source()
+
+	codes := wh.expandCodes(context.Background(), SyncOptions{Codes: []string{"600036"}, Sectors: []string{"BK0477"}})
+	if len(codes) != 2 || codes[0] != "600036" || codes[1] != "000001" {
+		t.Errorf("expandCodes = %v", codes)
+	}
+}
@@ -0,0 +1,404 @@
+package warehouse
+
+import (
+	"database/sql"
+	"dragon-quant/data_processor"
+	"dragon-quant/model"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Timeframe K线周期，对应仓库中的 bars_<tf> 表
+type Timeframe string
+
+const (
+	TFDaily Timeframe = "1d"
+	TF30m   Timeframe = "30m"
+	TF5m    Timeframe = "5m"
+	TF1m    Timeframe = "1m"
+)
+
+// Timeframes 仓库支持的全部周期
+var Timeframes = []Timeframe{TFDaily, TF30m, TF5m, TF1m}
+
+// Table 返回周期对应的表名
+func (tf Timeframe) Table() string {
+	return "bars_" + string(tf)
+}
+
+// layout 返回该周期 K线 Date 字段的格式
+func (tf Timeframe) layout() string {
+	if tf == TFDaily {
+		return "2006-01-02"
+	}
+	return "2006-01-02 15:04"
+}
+
+const schema = `
+CREATE TABLE IF NOT EXISTS bars_1d  (code VARCHAR, time TIMESTAMP, close DOUBLE, amount DOUBLE, PRIMARY KEY (code, time));
+CREATE TABLE IF NOT EXISTS bars_30m (code VARCHAR, time TIMESTAMP, close DOUBLE, amount DOUBLE, PRIMARY KEY (code, time));
+CREATE TABLE IF NOT EXISTS bars_5m  (code VARCHAR, time TIMESTAMP, close DOUBLE, amount DOUBLE, PRIMARY KEY (code, time));
+CREATE TABLE IF NOT EXISTS bars_1m  (code VARCHAR, time TIMESTAMP, close DOUBLE, amount DOUBLE, PRIMARY KEY (code, time));
+ALTER TABLE bars_1d  ADD COLUMN IF NOT EXISTS open DOUBLE;
+ALTER TABLE bars_1d  ADD COLUMN IF NOT EXISTS high DOUBLE;
+ALTER TABLE bars_1d  ADD COLUMN IF NOT EXISTS low DOUBLE;
+ALTER TABLE bars_1d  ADD COLUMN IF NOT EXISTS volume DOUBLE;
+ALTER TABLE bars_30m ADD COLUMN IF NOT EXISTS open DOUBLE;
+ALTER TABLE bars_30m ADD COLUMN IF NOT EXISTS high DOUBLE;
+ALTER TABLE bars_30m ADD COLUMN IF NOT EXISTS low DOUBLE;
+ALTER TABLE bars_30m ADD COLUMN IF NOT EXISTS volume DOUBLE;
+ALTER TABLE bars_5m  ADD COLUMN IF NOT EXISTS open DOUBLE;
+ALTER TABLE bars_5m  ADD COLUMN IF NOT EXISTS high DOUBLE;
+ALTER TABLE bars_5m  ADD COLUMN IF NOT EXISTS low DOUBLE;
+ALTER TABLE bars_5m  ADD COLUMN IF NOT EXISTS volume DOUBLE;
+ALTER TABLE bars_1m  ADD COLUMN IF NOT EXISTS open DOUBLE;
+ALTER TABLE bars_1m  ADD COLUMN IF NOT EXISTS high DOUBLE;
+ALTER TABLE bars_1m  ADD COLUMN IF NOT EXISTS low DOUBLE;
+ALTER TABLE bars_1m  ADD COLUMN IF NOT EXISTS volume DOUBLE;
+CREATE TABLE IF NOT EXISTS import_meta (
+	code VARCHAR, timeframe VARCHAR, source VARCHAR, timezone VARCHAR, adjust VARCHAR,
+	rows INTEGER, first_time TIMESTAMP, last_time TIMESTAMP, imported_at TIMESTAMP,
+	PRIMARY KEY (code, timeframe)
+);
+CREATE TABLE IF NOT EXISTS adj_factors (
+	code VARCHAR, date DATE, factor DOUBLE,
+	PRIMARY KEY (code, date)
+);
+CREATE TABLE IF NOT EXISTS meta (key VARCHAR PRIMARY KEY, value VARCHAR);
+CREATE TABLE IF NOT EXISTS sector_members (
+	sector_code VARCHAR, sector_name VARCHAR, sector_type VARCHAR,
+	code VARCHAR, name VARCHAR, updated_at TIMESTAMP,
+	PRIMARY KEY (sector_code, code)
+);
+CREATE TABLE IF NOT EXISTS lhb (
+	code VARCHAR, trade_date DATE, info VARCHAR, net_amt DOUBLE,
+	PRIMARY KEY (code, trade_date)
+);
+ALTER TABLE lhb ADD COLUMN IF NOT EXISTS name VARCHAR;
+ALTER TABLE lhb ADD COLUMN IF NOT EXISTS reason VARCHAR;
+ALTER TABLE lhb ADD COLUMN IF NOT EXISTS buy_amt DOUBLE;
+ALTER TABLE lhb ADD COLUMN IF NOT EXISTS sell_amt DOUBLE;
+CREATE TABLE IF NOT EXISTS lhb_seats (
+	trade_date DATE, code VARCHAR, side VARCHAR, rank INTEGER,
+	seat_name VARCHAR, kind VARCHAR, alias VARCHAR,
+	buy_amt DOUBLE, sell_amt DOUBLE, net_amt DOUBLE,
+	PRIMARY KEY (trade_date, code, side, rank)
+);
+CREATE TABLE IF NOT EXISTS auction_ticks (
+	code VARCHAR, time TIMESTAMP, price DOUBLE, volume BIGINT, amount DOUBLE,
+	unmatched_buy BIGINT, unmatched_sell BIGINT, prev_close DOUBLE,
+	PRIMARY KEY (code, time)
+);
+CREATE TABLE IF NOT EXISTS watch_targets (
+	code VARCHAR, source VARCHAR, name VARCHAR, stop_loss DOUBLE, target_price DOUBLE, updated_at TIMESTAMP,
+	PRIMARY KEY (code, source)
+);
+CREATE TABLE IF NOT EXISTS snapshots (
+	code VARCHAR, time TIMESTAMP, name VARCHAR,
+	price DOUBLE, change_pct DOUBLE, turnover DOUBLE, vol_ratio DOUBLE,
+	net_inflow DOUBLE, call_auction_amt DOUBLE, payload JSON,
+	PRIMARY KEY (code, time)
+);
+`
+
+// Warehouse 持久化行情仓库 (DuckDB 文件)。按 code + time 存储各周期K线、板块成分、龙虎榜与个股快照。
+// 方法对 nil 安全: 未配置仓库时直接透传到 fetcher，不做缓存。
+type Warehouse struct {
+	Duck *data_processor.DuckDB
+	Path string
+
+	mu  sync.Mutex // DuckDB 单写者，写入串行化避免事务冲突
+	src source
+}
+
+// Open 打开 (或创建) 仓库文件并初始化表结构。path 为空时使用内存库。
+func Open(path string) (*Warehouse, error) {
+	if path != "" {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return nil, fmt.Errorf("create warehouse dir failed: %w", err)
+		}
+	}
+	duck, err := data_processor.NewDuckDB(path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := duck.DB.Exec(schema); err != nil {
+		duck.Close()
+		return nil, fmt.Errorf("init warehouse schema failed: %w", err)
+	}
+	w := &Warehouse{Duck: duck, Path: path, src: liveSource}
+	if err := w.migrateRawBars(); err != nil {
+		duck.Close()
+		return nil, fmt.Errorf("migrate warehouse failed: %w", err)
+	}
+	return w, nil
+}
+
+func (w *Warehouse) Close() error {
+	if w == nil {
+		return nil
+	}
+	return w.Duck.Close()
+}
+
+// UpsertBars 写入实时拉取的不复权K线 (同 code+time 覆盖)，返回写入条数。
+// 该代码该周期已导入复权K线时拒绝写入，避免两种口径混存
+func (w *Warehouse) UpsertBars(code string, tf Timeframe, bars []model.KLineData) (int, error) {
+	if len(bars) == 0 {
+		return 0, nil
+	}
+	if stored := w.StoredAdjust(code, tf); stored != model.AdjustNone {
+		return 0, fmt.Errorf("%s %s 为导入的 %s K线，不写入不复权数据", code, tf.Table(), stored)
+	}
+	return w.upsertBars(code, tf, bars)
+}
+
+// upsertBars 写入K线，不检查口径 (导入时已检查)
+func (w *Warehouse) upsertBars(code string, tf Timeframe, bars []model.KLineData) (int, error) {
+	if len(bars) == 0 {
+		return 0, nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	tx, err := w.Duck.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	stmt, err := tx.Prepare(fmt.Sprintf("INSERT OR REPLACE INTO %s (code, time, close, amount, open, high, low, volume) VALUES (?, ?, ?, ?, ?, ?, ?, ?)", tf.Table()))
+	if err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("prepare upsert failed: %w", err)
+	}
+	defer stmt.Close()
+
+	n := 0
+	for _, k := range bars {
+		t, err := time.Parse(tf.layout(), k.Date)
+		if err != nil {
+			continue
+		}
+		if _, err := stmt.Exec(code, t, k.Close, k.Amount, k.Open, k.High, k.Low, k.Volume); err != nil {
+			tx.Rollback()
+			return 0, fmt.Errorf("upsert %s failed: %w", tf.Table(), err)
+		}
+		n++
+	}
+	return n, tx.Commit()
+}
+
+// Bars 返回最近 limit 根K线 (时间升序)。Change 用 LAG 现算，避免增量拉取时首根涨跌额失真。
+func (w *Warehouse) Bars(code string, tf Timeframe, limit int) ([]model.KLineData, error) {
+	query := fmt.Sprintf(`
+		SELECT time, close, amount, close - LAG(close) OVER (ORDER BY time) AS change, open, high, low, volume
+		FROM (SELECT * FROM %s WHERE code = ? ORDER BY time DESC LIMIT ?)
+		ORDER BY time ASC`, tf.Table())
+	return w.queryBars(tf, query, code, limit)
+}
+
+// BarsSince 返回 since (含) 之后的全部K线 (时间升序)
+func (w *Warehouse) BarsSince(code string, tf Timeframe, since time.Time) ([]model.KLineData, error) {
+	query := fmt.Sprintf(`
+		SELECT time, close, amount, close - LAG(close) OVER (ORDER BY time) AS change, open, high, low, volume
+		FROM %s WHERE code = ? AND time >= ?
+		ORDER BY time ASC`, tf.Table())
+	return w.queryBars(tf, query, code, since)
+}
+
+func (w *Warehouse) queryBars(tf Timeframe, query string, args ...interface{}) ([]model.KLineData, error) {
+	rows, err := w.Duck.DB.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query %s failed: %w", tf.Table(), err)
+	}
+	defer rows.Close()
+
+	var bars []model.KLineData
+	for rows.Next() {
+		var t time.Time
+		var k model.KLineData
+		var change, open, high, low, volume sql.NullFloat64
+		if err := rows.Scan(&t, &k.Close, &k.Amount, &change, &open, &high, &low, &volume); err != nil {
+			return nil, err
+		}
+		k.Date = t.Format(tf.layout())
+		k.Change = change.Float64
+		k.Open, k.High, k.Low, k.Volume = open.Float64, high.Float64, low.Float64, volume.Float64
+		bars = append(bars, k)
+	}
+	return bars, rows.Err()
+}
+
+// LastBarTime 返回某代码某周期最后一根K线时间；没有数据时 ok=false
+func (w *Warehouse) LastBarTime(code string, tf Timeframe) (time.Time, bool) {
+	var t sql.NullTime
+	err := w.Duck.DB.QueryRow(fmt.Sprintf("SELECT MAX(time) FROM %s WHERE code = ?", tf.Table()), code).Scan(&t)
+	if err != nil || !t.Valid {
+		return time.Time{}, false
+	}
+	return t.Time, true
+}
+
+// FirstBarTime 返回某代码某周期第一根K线时间；没有数据时 ok=false
+func (w *Warehouse) FirstBarTime(code string, tf Timeframe) (time.Time, bool) {
+	var t sql.NullTime
+	err := w.Duck.DB.QueryRow(fmt.Sprintf("SELECT MIN(time) FROM %s WHERE code = ?", tf.Table()), code).Scan(&t)
+	if err != nil || !t.Valid {
+		return time.Time{}, false
+	}
+	return t.Time, true
+}
+
+// CountBars 返回某代码某周期已存K线数量
+func (w *Warehouse) CountBars(code string, tf Timeframe) int {
+	var n int
+	w.Duck.DB.QueryRow(fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE code = ?", tf.Table()), code).Scan(&n)
+	return n
+}
+
+// DayBarCounts 返回指定日期 (2006-01-02, 升序) 各自已存的K线根数
+func (w *Warehouse) DayBarCounts(code string, tf Timeframe, dates []string) map[string]int {
+	counts := make(map[string]int)
+	if len(dates) == 0 {
+		return counts
+	}
+	rows, err := w.Duck.DB.Query(fmt.Sprintf(`
+		SELECT strftime(CAST(time AS DATE), '%%Y-%%m-%%d') AS d, COUNT(*)
+		FROM %s WHERE code = ? AND CAST(time AS DATE) BETWEEN CAST(? AS DATE) AND CAST(? AS DATE)
+		GROUP BY 1`, tf.Table()), code, dates[0], dates[len(dates)-1])
+	if err != nil {
+		return counts
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var d string
+		var n int
+		if rows.Scan(&d, &n) == nil {
+			counts[d] = n
+		}
+	}
+	return counts
+}
+
+// SaveSectorMembers 记录板块成分股 (覆盖同板块同代码)
+func (w *Warehouse) SaveSectorMembers(sector model.SectorInfo, stocks []model.StockInfo) error {
+	if w == nil || len(stocks) == 0 {
+		return nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	now := time.Now()
+	tx, err := w.Duck.DB.Begin()
+	if err != nil {
+		return err
+	}
+	for _, s := range stocks {
+		_, err := tx.Exec("INSERT OR REPLACE INTO sector_members VALUES (?, ?, ?, ?, ?, ?)",
+			sector.Code, sector.Name, sector.Type, s.Code, s.Name, now)
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("save sector members failed: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// SaveLHB 记录个股龙虎榜摘要与席位明细 (需 s.LHBDate 非空)
+func (w *Warehouse) SaveLHB(s *model.StockInfo) error {
+	if w == nil || s.LHBDate == "" {
+		return nil
+	}
+	return w.SaveLHBRecords([]model.LHBRecord{{
+		Date: s.LHBDate, Code: s.Code, Name: s.Name, Explain: s.LHBInfo,
+		NetAmt: s.LHBNet, Seats: s.LHBSeats,
+	}})
+}
+
+// SaveLHBRecords 写入龙虎榜记录，同一 code+日期 的席位整体覆盖
+func (w *Warehouse) SaveLHBRecords(records []model.LHBRecord) error {
+	if w == nil || len(records) == 0 {
+		return nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	tx, err := w.Duck.DB.Begin()
+	if err != nil {
+		return err
+	}
+	for _, r := range records {
+		_, err := tx.Exec(`INSERT OR REPLACE INTO lhb (code, trade_date, info, net_amt, name, reason, buy_amt, sell_amt)
+			VALUES (?, CAST(? AS DATE), ?, ?, ?, ?, ?, ?)`,
+			r.Code, r.Date, r.Explain, r.NetAmt, r.Name, r.Reason, r.BuyAmt, r.SellAmt)
+		if err == nil {
+			_, err = tx.Exec("DELETE FROM lhb_seats WHERE code = ? AND trade_date = CAST(? AS DATE)", r.Code, r.Date)
+		}
+		rank := map[string]int{}
+		for _, st := range r.Seats {
+			if err != nil {
+				break
+			}
+			rank[st.Side]++
+			_, err = tx.Exec("INSERT INTO lhb_seats VALUES (CAST(? AS DATE), ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+				r.Date, r.Code, st.Side, rank[st.Side], st.Name, st.Kind, st.Alias, st.BuyAmt, st.SellAmt, st.NetAmt)
+		}
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("save lhb failed: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// SaveSnapshot 记录个股在 t 时刻的完整快照 (含全部衍生指标的 JSON)
+func (w *Warehouse) SaveSnapshot(s *model.StockInfo, t time.Time) error {
+	if w == nil {
+		return nil
+	}
+	payload, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	_, err = w.Duck.DB.Exec("INSERT OR REPLACE INTO snapshots VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		s.Code, t.Truncate(time.Minute), s.Name, s.Price, s.ChangePct, s.Turnover, s.VolRatio,
+		s.NetInflow, s.CallAuctionAmt, string(payload))
+	if err != nil {
+		return fmt.Errorf("save snapshot failed: %w", err)
+	}
+	return nil
+}
+
+// Codes 返回某周期下已有数据的全部代码
+func (w *Warehouse) Codes(tf Timeframe) []string {
+	rows, err := w.Duck.DB.Query(fmt.Sprintf("SELECT DISTINCT code FROM %s ORDER BY code", tf.Table()))
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+	var codes []string
+	for rows.Next() {
+		var c string
+		if rows.Scan(&c) == nil {
+			codes = append(codes, c)
+		}
+	}
+	return codes
+}
+
+// Stats 各表行数，用于日志
+func (w *Warehouse) Stats() string {
+	var parts []string
+	for _, table := range []string{"bars_1d", "bars_30m", "bars_5m", "bars_1m", "sector_members", "lhb", "lhb_seats", "auction_ticks", "snapshots"} {
+		var n int
+		w.Duck.DB.QueryRow("SELECT COUNT(*) FROM " + table).Scan(&n)
+		parts = append(parts, fmt.Sprintf("%s=%d", table, n))
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,217 @@
+package warehouse
+
+import (
+	"context"
+	"dragon-quant/data_processor"
+	"dragon-quant/model"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func synth1m(day string, n int, base float64) []model.KLineData {
+	start, _ := time.Parse("2006-01-02 15:04", day+" 09:31")
+	var bars []model.KLineData
+	for i := 0; i < n; i++ {
+		vol := 100.0
+		price := base
+		if i == n/2 {
+			vol = 5000 // 放量异动
+			price = base * 1.05
+		}
+		bars = append(bars, model.KLineData{
+			Date:   start.Add(time.Duration(i) * time.Minute).Format("2006-01-02 15:04"),
+			Close:  price,
+			Amount: vol,
+		})
+	}
+	return bars
+}
+
+func TestWarehousePersistAndUpsert(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wh", "market.duckdb")
+	wh, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+
+	daily := []model.KLineData{
+		{Date: "2026-01-05", Close: 10, Amount: 1e8},
+		{Date: "2026-01-06", Close: 11, Amount: 2e8},
+	}
+	if n, err := wh.UpsertBars("600036", TFDaily, daily); err != nil || n != 2 {
+		t.Fatalf("UpsertBars = %d, %v", n, err)
+	}
+	// 同一天再写一次应覆盖而不是重复
+	if _, err := wh.UpsertBars("600036", TFDaily, []model.KLineData{{Date: "2026-01-06", Close: 12, Amount: 3e8}}); err != nil {
+		t.Fatalf("UpsertBars overwrite failed: %v", err)
+	}
+	wh.Close()
+
+	// 重新打开: 数据应持久化
+	wh, err = Open(path)
+	if err != nil {
+		t.Fatalf("Reopen failed: %v", err)
+	}
+	defer wh.Close()
+
+	bars, err := wh.Bars("600036", TFDaily, 10)
+	if err != nil {
+		t.Fatalf("Bars failed: %v", err)
+	}
+	if len(bars) != 2 {
+		t.Fatalf("expected 2 bars, got %d", len(bars))
+	}
+	if bars[1].Close != 12 || bars[1].Change != 2 || bars[1].Date != "2026-01-06" {
+		t.Errorf("unexpected last bar: %+v", bars[1])
+	}
+	if last, ok := wh.LastBarTime("600036", TFDaily); !ok || last.Format("2006-01-02") != "2026-01-06" {
+		t.Errorf("LastBarTime = %v, %v", last, ok)
+	}
+	if _, ok := wh.LastBarTime("000001", TFDaily); ok {
+		t.Error("expected no data for unknown code")
+	}
+}
+
+func TestWarehouseCodeScopedKline(t *testing.T) {
+	wh, err := Open("")
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer wh.Close()
+
+	wh.UpsertBars("600036", TF1m, synth1m("2026-01-05", 240, 10))
+	wh.UpsertBars("600036", TF1m, synth1m("2026-01-06", 120, 10))
+	wh.UpsertBars("000001", TF1m, synth1m("2026-01-06", 240, 50))
+
+	counts := wh.DayBarCounts("600036", TF1m, []string{"2026-01-05", "2026-01-06"})
+	if counts["2026-01-05"] != 240 || counts["2026-01-06"] != 120 {
+		t.Errorf("DayBarCounts = %v", counts)
+	}
+
+	proc, err := data_processor.NewKlineProcessorForCode(wh.Duck, "600036", time.Time{})
+	if err != nil {
+		t.Fatalf("NewKlineProcessorForCode failed: %v", err)
+	}
+	events, err := proc.AnalyzeVolatility()
+	if err != nil {
+		t.Fatalf("AnalyzeVolatility failed: %v", err)
+	}
+	if len(events) == 0 {
+		t.Fatal("expected volume spike events")
+	}
+	for _, e := range events {
+		if e.Price > 11 {
+			t.Errorf("event leaked from another code: %+v", e)
+		}
+	}
+
+	stats, err := proc.PositionStats(9.5, 1000, 1000)
+	if err != nil {
+		t.Fatalf("PositionStats failed: %v", err)
+	}
+	if stats.LastPrice != 10 {
+		t.Errorf("LastPrice = %.2f, want 10", stats.LastPrice)
+	}
+
+	if _, err := data_processor.NewKlineProcessorForCode(wh.Duck, "1' OR '1'='1", time.Time{}); err == nil {
+		t.Error("expected invalid code to be rejected")
+	}
+}
+
+func TestCodeScopedKlineWindow(t *testing.T) {
+	wh, err := Open("")
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer wh.Close()
+
+	// 窗口外的旧K线: 价格 20，放量在 10:31
+	wh.UpsertBars("600036", TF1m, synth1m("2026-01-05", 240, 20))
+	wh.UpsertBars("600036", TF1m, synth1m("2026-01-06", 240, 10))
+
+	since := time.Date(2026, 1, 6, 0, 0, 0, 0, time.UTC)
+	proc, err := data_processor.NewKlineProcessorForCode(wh.Duck, "600036", since)
+	if err != nil {
+		t.Fatalf("NewKlineProcessorForCode failed: %v", err)
+	}
+	events, err := proc.AnalyzeVolatility()
+	if err != nil {
+		t.Fatalf("AnalyzeVolatility failed: %v", err)
+	}
+	if len(events) == 0 {
+		t.Fatal("expected volume spike events inside the window")
+	}
+	for _, e := range events {
+		if e.Time.Before(since) || e.Price > 11 {
+			t.Errorf("event from before the window: %+v", e)
+		}
+	}
+
+	unbounded, _ := data_processor.NewKlineProcessorForCode(wh.Duck, "600036", time.Time{})
+	if allEvents, err := unbounded.AnalyzeVolatility(); err != nil || len(allEvents) <= len(events) {
+		t.Errorf("unbounded query should also see the older day: %d vs %d (%v)", len(allEvents), len(events), err)
+	}
+}
+
+func TestDailyAdjustFromFactors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "market.duckdb")
+	wh, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	factorReqs := 0
+	wh.src = source{
+		daily: func(_ context.Context, code string, limit int) ([]model.KLineData, error) {
+			return []model.KLineData{{Date: "2026-06-03", Close: 20}, {Date: "2026-06-04", Close: 19}}, nil
+		},
+		factors: func(_ context.Context, code string, limit int) ([]model.AdjFactor, error) {
+			factorReqs++
+			return []model.AdjFactor{{Date: "2026-06-03", Factor: 2}, {Date: "2026-06-04", Factor: 2.1}}, nil
+		},
+	}
+	ctx := context.Background()
+	raw, factors, err := wh.DailyRaw(ctx, "600519", 2)
+	if err != nil || len(raw) != 2 || raw[0].Close != 20 || len(factors) != 2 {
+		t.Fatalf("raw = %+v factors = %+v err = %v", raw, factors, err)
+	}
+	qfq, _ := wh.Daily(ctx, "600519", 2)
+	if qfq[0].Close != 19.048 || qfq[1].Close != 19 || factorReqs != 1 {
+		t.Errorf("qfq = %+v, factor requests = %d", qfq, factorReqs)
+	}
+	if ev := wh.ExRights("600519"); len(ev) != 1 || ev[0].Date != "2026-06-04" {
+		t.Errorf("ex-rights = %+v", ev)
+	}
+
+	// 旧版仓库 (没有 bars_adjust 标记) 的前复权K线在升级时清空，各周期都要清 (分钟K线同样是 fqt=1)
+	wh.UpsertBars("600519", TF30m, []model.KLineData{{Date: "2026-06-04 10:00", Close: 19}})
+	wh.UpsertBars("600519", TF1m, synth1m("2026-06-04", 10, 19))
+	// 按不复权口径导入的区间保留
+	wh.UpsertBars("000651", TF1m, synth1m("2026-06-04", 10, 40))
+	first, _ := time.Parse("2006-01-02 15:04", "2026-06-04 09:31")
+	wh.saveImportMeta(ImportResult{File: "raw.csv", Code: "000651", Timeframe: TF1m, Rows: 10, First: first, Last: first.Add(9 * time.Minute)},
+		ImportOptions{Timezone: "Asia/Shanghai", Adjust: model.AdjustNone})
+	wh.saveImportMeta(ImportResult{File: "qfq.csv", Code: "600519", Timeframe: TF1m, Rows: 10, First: first, Last: first.Add(9 * time.Minute)},
+		ImportOptions{Timezone: "Asia/Shanghai", Adjust: model.AdjustForward})
+	wh.Duck.DB.Exec("DELETE FROM meta")
+	wh.Close()
+	wh, err = Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wh.Close()
+	for _, tf := range Timeframes {
+		if n := wh.CountBars("600519", tf); n != 0 {
+			t.Errorf("legacy %s bars kept: %d", tf, n)
+		}
+	}
+	if n := wh.CountBars("000651", TF1m); n != 10 {
+		t.Errorf("raw import dropped: %d bars left", n)
+	}
+	if _, ok := wh.ImportMetaFor("600519", TF1m); ok {
+		t.Error("meta of the wiped qfq import should be removed")
+	}
+	if len(wh.AdjFactors("600519")) != 2 {
+		t.Error("factors should survive the migration")
+	}
+}
@@ -0,0 +1,57 @@
+package warehouse
+
+import (
+	"dragon-quant/model"
+	"fmt"
+	"time"
+)
+
+// ReplaceWatchTargets 用最新一轮结果整体替换某来源 (pool / hold) 的盯盘列表
+func (w *Warehouse) ReplaceWatchTargets(source string, targets []model.WatchTarget) error {
+	if w == nil {
+		return nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	tx, err := w.Duck.DB.Begin()
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM watch_targets WHERE source = ?", source); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("replace watch targets failed: %w", err)
+	}
+	now := time.Now()
+	for _, t := range targets {
+		_, err := tx.Exec("INSERT OR REPLACE INTO watch_targets VALUES (?, ?, ?, ?, ?, ?)",
+			t.Code, source, t.Name, t.StopLoss, t.TargetPrice, now)
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("replace watch targets failed: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// WatchTargets 返回全部盯盘对象 (持仓在前)
+func (w *Warehouse) WatchTargets() []model.WatchTarget {
+	if w == nil {
+		return nil
+	}
+	rows, err := w.Duck.DB.Query(`
+		SELECT code, source, name, stop_loss, target_price FROM watch_targets
+		ORDER BY CASE source WHEN 'hold' THEN 0 ELSE 1 END, code`)
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+	var targets []model.WatchTarget
+	for rows.Next() {
+		var t model.WatchTarget
+		if rows.Scan(&t.Code, &t.Source, &t.Name, &t.StopLoss, &t.TargetPrice) == nil {
+			targets = append(targets, t)
+		}
+	}
+	return targets
+}